@@ -0,0 +1,106 @@
+package admin
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/interfaces"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/provider"
+	"github.com/labstack/echo/v5"
+)
+
+// Api serves the operational actions on a separate listener, so that it is never exposed together with the webhook.
+type Api struct {
+	Config   ApiConfig
+	Echo     *echo.Echo
+	log      services.ZapSugaredLogger
+	listener net.Listener
+	server   *http.Server
+	mu       sync.RWMutex
+
+	*ApiSvc
+}
+
+var _ interfaces.RegisterRoutes = (*Api)(nil)
+
+type ApiConfig struct {
+	// AuthToken is the bearer token that every request must present, which is required to start the admin server.
+	AuthToken string
+}
+
+type ApiSvc struct {
+	Logger    *services.Logger
+	Validator *services.Validator
+
+	Provider *provider.Provider
+	// Certificates enables TLS on the listener when it is set.
+	Certificates *services.Certificates
+}
+
+func NewApi(svc *ApiSvc, conf ApiConfig) *Api {
+	e := echo.New()
+
+	a := &Api{
+		Config: conf,
+		Echo:   e,
+		ApiSvc: svc,
+		log:    svc.Logger.WithCaller(),
+		server: &http.Server{},
+	}
+
+	a.SetupMiddleware()
+	a.RegisterRoutes(a.Echo.Group(""))
+
+	return a
+}
+
+// Start listens on the address and serves in the background, where the returned channel receives the error that stops the server.
+// The listener is ready when it returns, so that the errors of listening are received right away.
+func (a *Api) Start(address string) chan error {
+	errCh := make(chan error, 1)
+
+	listener, err := services.Listen(address)
+	if err != nil {
+		errCh <- err
+
+		return errCh
+	}
+
+	if a.Certificates != nil {
+		listener = tls.NewListener(listener, a.Certificates.TLSConfig())
+	}
+
+	a.mu.Lock()
+	a.listener = listener
+	a.mu.Unlock()
+
+	a.server.Handler = a.Echo
+
+	a.log.Infof("Starting admin server at address: %s", listener.Addr().String())
+
+	go func() {
+		errCh <- a.server.Serve(listener)
+	}()
+
+	return errCh
+}
+
+// Listener returns the listener of the server, which is nil until the server is started.
+func (a *Api) Listener() net.Listener {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	return a.listener
+}
+
+func (a *Api) Shutdown() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return a.server.Shutdown(ctx)
+}
@@ -0,0 +1,51 @@
+package admin_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/api/admin"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/test/fixtures"
+	"github.com/labstack/echo/v5"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("API", func() {
+	newApi := func(token string) *admin.Api {
+		return admin.NewApi(&admin.ApiSvc{
+			Logger:    fixtures.NewTestLogger(),
+			Validator: services.NewValidator(),
+			Provider:  handler.Provider,
+		}, admin.ApiConfig{AuthToken: token})
+	}
+
+	serve := func(a *admin.Api, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/admin/read-only", nil)
+		if token != "" {
+			req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+		}
+
+		res := httptest.NewRecorder()
+		a.Echo.ServeHTTP(res, req)
+
+		return res
+	}
+
+	It("should serve the requests with the token", func() {
+		Expect(serve(newApi("token"), "token").Code).To(Equal(http.StatusOK))
+	})
+
+	It("should reject the requests without the token", func() {
+		a := newApi("token")
+
+		Expect(serve(a, "").Code).To(Equal(http.StatusUnauthorized))
+		Expect(serve(a, "wrong").Code).To(Equal(http.StatusUnauthorized))
+	})
+
+	It("should reject every request when the token is not set", func() {
+		Expect(serve(newApi(""), "").Code).To(Equal(http.StatusUnauthorized))
+	})
+})
@@ -0,0 +1,117 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/ctx"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/provider"
+)
+
+type ApprovalPath struct {
+	ID string `param:"id" validate:"required,hexadecimal"`
+}
+
+type ApprovalApproveQuery struct {
+	// OverrideDeletionThresholds applies the batch even when it exceeds the deletion thresholds, like an intentional mass delete.
+	OverrideDeletionThresholds bool `query:"override-deletion-thresholds"`
+}
+
+type ApprovalResponse struct {
+	*provider.PendingBatch
+	Diff []string `json:"diff"`
+}
+
+// @Tags		Admin
+// @Summary	Returns the batches of changes that are held for approval, the oldest first.
+// @Produce	json
+// @Success	200	{array}	ApprovalResponse
+// @Router  /admin/approvals [get]
+func (h *Handler) HandleApprovalsGet(c *ctx.Context) error {
+	batches, err := h.Provider.PendingBatches()
+	if err != nil {
+		return newProviderError(c, err)
+	}
+
+	res := make([]ApprovalResponse, 0, len(batches))
+	for _, batch := range batches {
+		res = append(res, ApprovalResponse{
+			PendingBatch: batch,
+			Diff:         batch.Diff(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// @Tags		Admin
+// @Summary	Returns a batch of changes that is held for approval.
+// @Produce	json
+// @Param		id	path		string	true	"Identifier of the pending batch."
+// @Success	200	{object}	ApprovalResponse
+// @Failure	404	{object}	interfaces.ApiError
+// @Router  /admin/approvals/{id} [get]
+func (h *Handler) HandleApprovalGet(c *ctx.Context) error {
+	path := &ApprovalPath{}
+	if err := c.BindPathParams(path); err != nil {
+		return err
+	}
+
+	batch, err := h.Provider.PendingBatch(path.ID)
+	if err != nil {
+		return newProviderError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, ApprovalResponse{
+		PendingBatch: batch,
+		Diff:         batch.Diff(),
+	})
+}
+
+// @Tags		Admin
+// @Summary	Applies a batch of changes that is held for approval.
+// @Param		id								path	string	true	"Identifier of the pending batch."
+// @Param		override-deletion-thresholds	query	bool	false	"Apply the batch even when it exceeds the deletion thresholds."
+// @Success	204
+// @Failure	404	{object}	interfaces.ApiError
+// @Router  /admin/approvals/{id}/approve [post]
+func (h *Handler) HandleApprovalApprovePost(c *ctx.Context) error {
+	path := &ApprovalPath{}
+	if err := c.BindPathParams(path); err != nil {
+		return err
+	}
+
+	query := &ApprovalApproveQuery{}
+	if err := c.BindQueryParams(query); err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+	if query.OverrideDeletionThresholds {
+		ctx = provider.WithDeletionOverride(ctx)
+	}
+
+	if err := h.Provider.ApproveBatch(ctx, path.ID); err != nil {
+		return newProviderError(c, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// @Tags		Admin
+// @Summary	Discards a batch of changes that is held for approval.
+// @Param		id	path	string	true	"Identifier of the pending batch."
+// @Success	204
+// @Failure	404	{object}	interfaces.ApiError
+// @Router  /admin/approvals/{id}/reject [post]
+func (h *Handler) HandleApprovalRejectPost(c *ctx.Context) error {
+	path := &ApprovalPath{}
+	if err := c.BindPathParams(path); err != nil {
+		return err
+	}
+
+	if err := h.Provider.RejectBatch(c.Request().Context(), path.ID); err != nil {
+		return newProviderError(c, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
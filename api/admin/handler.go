@@ -0,0 +1,40 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/api/webhook"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/ctx"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/interfaces"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/provider"
+)
+
+type Handler struct {
+	*HandlerSvc
+}
+
+var _ interfaces.RegisterRoutes = (*Handler)(nil)
+
+type HandlerSvc struct {
+	Log      *services.Logger
+	Provider *provider.Provider
+}
+
+func NewHandler(svc *HandlerSvc) *Handler {
+	h := &Handler{
+		HandlerSvc: svc,
+	}
+
+	return h
+}
+
+// newProviderError maps the failures of the provider like the webhook does, with the pending batches that do not exist as 404.
+func newProviderError(c *ctx.Context, err error) error {
+	if errors.Is(err, provider.ErrPendingBatchNotFound) || errors.Is(err, provider.ErrApprovalDisabled) {
+		return c.NewHTTPError(http.StatusNotFound, err)
+	}
+
+	return webhook.NewProviderError(err)
+}
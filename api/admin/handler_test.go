@@ -0,0 +1,120 @@
+package admin_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/api/admin"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/opnsense"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/provider"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/test/fixtures"
+	"github.com/labstack/echo/v5"
+	"github.com/stretchr/testify/mock"
+	"sigs.k8s.io/external-dns/endpoint"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("handler", func() {
+	Context("records", func() {
+		It("should return the records with their owners", func() {
+			row := opnsense.UnboundSearchHostOverrideItem{Id: "id-1", Enabled: "1", Hostname: "app", Domain: "example.com", Type: endpoint.RecordTypeA, Server: "192.168.1.1"}
+			mocks.Client.EXPECT().UnboundSearchHostOverrides(mock.Anything, mock.Anything).Return(&opnsense.UnboundSearchHostOverrideResponse{
+				Rows: []opnsense.UnboundSearchHostOverrideItem{
+					row,
+					{Id: "id-2", Enabled: "1", Hostname: "nas", Domain: "example.com", Type: endpoint.RecordTypeA, Server: "192.168.1.2", Description: "added by hand"},
+					{
+						Id:      "id-3",
+						Enabled: "1",
+						Domain:  "a-app.example.com",
+						Type:    endpoint.RecordTypeTXT,
+						TxtData: `"heritage=external-dns,external-dns/owner=default,external-dns/set-identifier=` + provider.NewDnsRecord(row).GenerateSetIdentifier() + `"`,
+					},
+				},
+			}, nil).Once()
+
+			c, res := fixtures.CreateEchoContext(nil, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			Expect(fixtures.Respond(c, handler.HandleRecordsGet)).ToNot(HaveOccurred())
+			Expect(res.Code).To(Equal(http.StatusOK))
+
+			body := fixtures.MustJsonUnmarshal([]provider.ManagedRecord{}, res.Body.Bytes())
+			Expect(body).To(HaveLen(3))
+			Expect(body[0].DNSName).To(Equal("app.example.com"))
+			Expect(body[0].Owner).To(Equal("default"))
+			Expect(body[1].Owner).To(BeEmpty())
+			Expect(body[1].Description).To(Equal("added by hand"))
+			Expect(body[2].Owner).To(Equal("default"))
+		})
+	})
+
+	Context("read-only", func() {
+		It("should toggle the read-only mode", func() {
+			req := fixtures.SetRequestContentJson(httptest.NewRequest(http.MethodPut, "/", strings.NewReader(`{"enabled":true}`)))
+			c, res := fixtures.CreateEchoContext(nil, req)
+
+			Expect(fixtures.Respond(c, handler.HandleReadOnlyPut)).ToNot(HaveOccurred())
+			Expect(res.Code).To(Equal(http.StatusOK))
+			Expect(handler.Provider.IsReadOnly()).To(BeTrue())
+
+			body := fixtures.MustJsonUnmarshal(admin.ReadOnlyBody{}, res.Body.Bytes())
+			Expect(body.Enabled).To(BeTrue())
+		})
+	})
+
+	Context("deletion override", func() {
+		It("should arm the deletion override", func() {
+			DeferCleanup(handler.Provider.ArmDeletionOverride, false)
+
+			req := fixtures.SetRequestContentJson(httptest.NewRequest(http.MethodPut, "/", strings.NewReader(`{"armed":true}`)))
+			c, res := fixtures.CreateEchoContext(nil, req)
+
+			Expect(fixtures.Respond(c, handler.HandleDeletionOverridePut)).ToNot(HaveOccurred())
+			Expect(res.Code).To(Equal(http.StatusOK))
+			Expect(handler.Provider.IsDeletionOverrideArmed()).To(BeTrue())
+
+			body := fixtures.MustJsonUnmarshal(admin.DeletionOverrideBody{}, res.Body.Bytes())
+			Expect(body.Armed).To(BeTrue())
+		})
+	})
+
+	Context("log level", func() {
+		It("should change the log level", func() {
+			req := fixtures.SetRequestContentJson(httptest.NewRequest(http.MethodPut, "/", strings.NewReader(`{"level":"debug"}`)))
+			c, res := fixtures.CreateEchoContext(nil, req)
+
+			Expect(fixtures.Respond(c, handler.HandleLogLevelPut)).ToNot(HaveOccurred())
+			Expect(res.Code).To(Equal(http.StatusOK))
+			Expect(handler.Log.GetLevel()).To(Equal("debug"))
+		})
+
+		It("should reject the unknown log levels", func() {
+			req := fixtures.SetRequestContentJson(httptest.NewRequest(http.MethodPut, "/", strings.NewReader(`{"level":"verbose"}`)))
+			c, res := fixtures.CreateEchoContext(nil, req)
+
+			Expect(fixtures.Respond(c, handler.HandleLogLevelPut)).To(HaveOccurred())
+			Expect(res.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	Context("approvals", func() {
+		It("should return http.StatusNotFound when the approval is not enabled", func() {
+			c, res := fixtures.CreateEchoContext(nil, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			Expect(fixtures.Respond(c, handler.HandleApprovalsGet)).To(HaveOccurred())
+			Expect(res.Code).To(Equal(http.StatusNotFound))
+		})
+
+		It("should return http.StatusNotFound for the unknown pending batches", func() {
+			handler.Provider.Config.Approval.Directory = GinkgoT().TempDir()
+
+			c, res := fixtures.CreateEchoContext(nil, httptest.NewRequest(http.MethodPost, "/", nil))
+			c.SetPathValues([]echo.PathValue{{Name: "id", Value: "0123456789abcdef"}})
+
+			Expect(fixtures.Respond(c, handler.HandleApprovalApprovePost)).To(HaveOccurred())
+			Expect(res.Code).To(Equal(http.StatusNotFound))
+		})
+	})
+})
@@ -0,0 +1,117 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/ctx"
+)
+
+type ReadOnlyBody struct {
+	Enabled bool `json:"enabled"`
+}
+
+type DeletionOverrideBody struct {
+	Armed bool `json:"armed"`
+}
+
+type LogLevelBody struct {
+	Level string `json:"level" validate:"required,oneof=debug info warn warning error dpanic panic fatal"`
+}
+
+// @Tags		Admin
+// @Summary	Returns whether the batches of changes are rejected.
+// @Produce	json
+// @Success	200	{object}	ReadOnlyBody
+// @Router  /admin/read-only [get]
+func (h *Handler) HandleReadOnlyGet(c *ctx.Context) error {
+	return c.JSON(http.StatusOK, ReadOnlyBody{
+		Enabled: h.Provider.IsReadOnly(),
+	})
+}
+
+// @Tags		Admin
+// @Summary	Enables or disables the read-only mode, where the records are served while the batches of changes are rejected.
+// @Accept		json
+// @Produce	json
+// @Param		body	body		ReadOnlyBody	true	"Read-only mode."
+// @Success	200		{object}	ReadOnlyBody
+// @Router  /admin/read-only [put]
+func (h *Handler) HandleReadOnlyPut(c *ctx.Context) error {
+	body := &ReadOnlyBody{}
+	if err := c.BindBody(body); err != nil {
+		return err
+	}
+
+	h.Provider.SetReadOnly(body.Enabled)
+
+	return c.JSON(http.StatusOK, ReadOnlyBody{
+		Enabled: h.Provider.IsReadOnly(),
+	})
+}
+
+// @Tags		Admin
+// @Summary	Returns whether the next batch that exceeds the deletion thresholds is applied.
+// @Produce	json
+// @Success	200	{object}	DeletionOverrideBody
+// @Router  /admin/deletion-override [get]
+func (h *Handler) HandleDeletionOverrideGet(c *ctx.Context) error {
+	return c.JSON(http.StatusOK, DeletionOverrideBody{
+		Armed: h.Provider.IsDeletionOverrideArmed(),
+	})
+}
+
+// @Tags		Admin
+// @Summary	Arms or disarms a single use override of the deletion thresholds, which lets the next batch that exceeds them through.
+// @Accept		json
+// @Produce	json
+// @Param		body	body		DeletionOverrideBody	true	"Deletion override."
+// @Success	200		{object}	DeletionOverrideBody
+// @Router  /admin/deletion-override [put]
+func (h *Handler) HandleDeletionOverridePut(c *ctx.Context) error {
+	body := &DeletionOverrideBody{}
+	if err := c.BindBody(body); err != nil {
+		return err
+	}
+
+	h.Provider.ArmDeletionOverride(body.Armed)
+
+	return c.JSON(http.StatusOK, DeletionOverrideBody{
+		Armed: h.Provider.IsDeletionOverrideArmed(),
+	})
+}
+
+// @Tags		Admin
+// @Summary	Returns the current log level.
+// @Produce	json
+// @Success	200	{object}	LogLevelBody
+// @Router  /admin/log-level [get]
+func (h *Handler) HandleLogLevelGet(c *ctx.Context) error {
+	return c.JSON(http.StatusOK, LogLevelBody{
+		Level: h.Log.GetLevel(),
+	})
+}
+
+// @Tags		Admin
+// @Summary	Changes the log level at runtime, until the configuration is reloaded.
+// @Accept		json
+// @Produce	json
+// @Param		body	body		LogLevelBody	true	"Log level."
+// @Success	200		{object}	LogLevelBody
+// @Router  /admin/log-level [put]
+func (h *Handler) HandleLogLevelPut(c *ctx.Context) error {
+	body := &LogLevelBody{}
+	if err := c.BindBody(body); err != nil {
+		return err
+	}
+
+	previous := h.Log.GetLevel()
+	if err := h.Log.SetLevel(body.Level); err != nil {
+		return c.NewHTTPError(http.StatusBadRequest, err)
+	}
+
+	h.Log.WithCaller().Infof("Log level changed: %s -> %s", previous, h.Log.GetLevel())
+
+	return c.JSON(http.StatusOK, LogLevelBody{
+		Level: h.Log.GetLevel(),
+	})
+}
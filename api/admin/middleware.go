@@ -0,0 +1,67 @@
+package admin
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/interfaces"
+	"github.com/labstack/echo/v5"
+	"github.com/labstack/echo/v5/middleware"
+	"go.uber.org/zap"
+)
+
+func (a *Api) SetupMiddleware() {
+	e := a.Echo
+	e.Validator = a.Validator
+
+	e.OnAddRoute = func(route echo.Route) error {
+		a.log.Debugf("Registered route: %s %s", route.Method, route.Path)
+
+		return nil
+	}
+
+	e.Use(a.GetMiddlewares()...)
+
+	e.HTTPErrorHandler = a.HTTPErrorHandler
+}
+
+func (a *Api) GetMiddlewares() []echo.MiddlewareFunc {
+	return []echo.MiddlewareFunc{
+		middleware.Recover(),
+		middleware.RequestID(),
+		// every action is logged, since they change the state of the service out of band
+		middleware.RequestLoggerWithConfig(middleware.RequestLoggerConfig{
+			LogStatus:   true,
+			LogURI:      true,
+			LogMethod:   true,
+			LogLatency:  true,
+			LogRemoteIP: true,
+			LogValuesFunc: func(c *echo.Context, v middleware.RequestLoggerValues) error {
+				logger := a.Logger.WithEchoContext(c).With(zap.Duration("latency", v.Latency))
+				if v.Error != nil {
+					logger.Error(v.Error.Error())
+				} else {
+					logger.Info("admin request")
+				}
+
+				return nil
+			},
+		}),
+		// the token is compared even when it is not set, so that the admin server never serves without one
+		middleware.KeyAuthWithConfig(middleware.KeyAuthConfig{
+			KeyLookup: "header:" + echo.HeaderAuthorization + ":Bearer ",
+			Validator: func(_ *echo.Context, key string, _ middleware.ExtractorSource) (bool, error) {
+				return a.Config.AuthToken != "" && subtle.ConstantTimeCompare([]byte(key), []byte(a.Config.AuthToken)) == 1, nil
+			},
+			ErrorHandler: func(c *echo.Context, _ error) error {
+				c.Response().Header().Set(echo.HeaderWWWAuthenticate, "Bearer")
+
+				return echo.NewHTTPError(http.StatusUnauthorized, "missing or invalid bearer token")
+			},
+		}),
+	}
+}
+
+func (a *Api) HTTPErrorHandler(c *echo.Context, err error) {
+	_ = interfaces.ToApiError(err).Render(c)
+}
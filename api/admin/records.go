@@ -0,0 +1,68 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/ctx"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/provider"
+)
+
+type DriftResponse struct {
+	Drift []provider.DriftRecord `json:"drift"`
+}
+
+// @Tags		Admin
+// @Summary	Returns the records in the domain filter with their owners.
+// @Produce	json
+// @Success	200	{array}	provider.ManagedRecord
+// @Router  /admin/records [get]
+func (h *Handler) HandleRecordsGet(c *ctx.Context) error {
+	records, err := h.Provider.ManagedRecords(c.Request().Context())
+	if err != nil {
+		return newProviderError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, records)
+}
+
+// @Tags		Admin
+// @Summary	Drops the cached host overrides, so that the next record query reaches OPNsense.
+// @Success	204
+// @Router  /admin/cache/flush [post]
+func (h *Handler) HandleCacheFlushPost(c *ctx.Context) error {
+	h.Provider.FlushCache()
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// @Tags		Admin
+// @Summary	Reconfigures the Unbound service to apply the host overrides.
+// @Success	204
+// @Router  /admin/reconfigure [post]
+func (h *Handler) HandleReconfigurePost(c *ctx.Context) error {
+	if err := h.Provider.Reconfigure(c.Request().Context()); err != nil {
+		return newProviderError(c, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// @Tags		Admin
+// @Summary	Checks the records against the desired state right away, without healing the drift.
+// @Produce	json
+// @Success	200	{object}	DriftResponse
+// @Router  /admin/drift [post]
+func (h *Handler) HandleDriftPost(c *ctx.Context) error {
+	drift, err := h.Provider.CheckDrift(c.Request().Context())
+	if err != nil {
+		return newProviderError(c, err)
+	}
+
+	if drift == nil {
+		drift = []provider.DriftRecord{}
+	}
+
+	return c.JSON(http.StatusOK, DriftResponse{
+		Drift: drift,
+	})
+}
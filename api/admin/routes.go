@@ -0,0 +1,79 @@
+package admin
+
+import (
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/ctx"
+	"github.com/labstack/echo/v5"
+)
+
+func (a *Api) RegisterRoutes(group *echo.Group) {
+	NewHandler(&HandlerSvc{
+		Log:      a.Logger,
+		Provider: a.Provider,
+	}).
+		RegisterRoutes(group)
+}
+
+func (h *Handler) RegisterRoutes(r *echo.Group) {
+	g := r.Group("/admin")
+
+	g.GET("/records", ctx.With(
+		h.HandleRecordsGet,
+		h.Log,
+	))
+	g.POST("/cache/flush", ctx.With(
+		h.HandleCacheFlushPost,
+		h.Log,
+	))
+	g.POST("/reconfigure", ctx.With(
+		h.HandleReconfigurePost,
+		h.Log,
+	))
+	g.POST("/drift", ctx.With(
+		h.HandleDriftPost,
+		h.Log,
+	))
+
+	g.GET("/read-only", ctx.With(
+		h.HandleReadOnlyGet,
+		h.Log,
+	))
+	g.PUT("/read-only", ctx.With(
+		h.HandleReadOnlyPut,
+		h.Log,
+	))
+
+	g.GET("/deletion-override", ctx.With(
+		h.HandleDeletionOverrideGet,
+		h.Log,
+	))
+	g.PUT("/deletion-override", ctx.With(
+		h.HandleDeletionOverridePut,
+		h.Log,
+	))
+
+	g.GET("/log-level", ctx.With(
+		h.HandleLogLevelGet,
+		h.Log,
+	))
+	g.PUT("/log-level", ctx.With(
+		h.HandleLogLevelPut,
+		h.Log,
+	))
+
+	g.GET("/approvals", ctx.With(
+		h.HandleApprovalsGet,
+		h.Log,
+	))
+	g.GET("/approvals/:id", ctx.With(
+		h.HandleApprovalGet,
+		h.Log,
+	))
+	g.POST("/approvals/:id/approve", ctx.With(
+		h.HandleApprovalApprovePost,
+		h.Log,
+	))
+	g.POST("/approvals/:id/reject", ctx.With(
+		h.HandleApprovalRejectPost,
+		h.Log,
+	))
+}
@@ -2,14 +2,14 @@ package api
 
 import (
 	"context"
-	"errors"
+	"crypto/tls"
 	"net"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/interfaces"
 	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services"
-	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/opnsense"
 	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/provider"
 	"github.com/labstack/echo/v5"
 )
@@ -20,6 +20,7 @@ type Api struct {
 	log      services.ZapSugaredLogger
 	listener net.Listener
 	server   *http.Server
+	mu       sync.RWMutex
 
 	*ApiSvc
 }
@@ -27,14 +28,26 @@ type Api struct {
 var _ interfaces.RegisterRoutes = (*Api)(nil)
 
 type ApiConfig struct {
+	TLSCertFile          string        `validate:"required_with=TLSKeyFile TLSClientCAFile,omitempty,file"`
+	TLSKeyFile           string        `validate:"required_with=TLSCertFile,omitempty,file"`
+	TLSClientCAFile      string        `validate:"omitempty,file"`
+	TLSMinVersion        string        `validate:"omitempty,oneof=1.0 1.1 1.2 1.3"`
+	TLSWatchInterval     time.Duration `validate:"gte=0"`
+	AuthToken            string
+	CORSAllowOrigins     []string
+	CORSAllowMethods     []string
+	CORSAllowHeaders     []string
+	CORSAllowCredentials bool
 }
 
 type ApiSvc struct {
 	Logger    *services.Logger
 	Validator *services.Validator
+	Metrics   *services.Metrics
 
-	Provider       *provider.Provider
-	OpnsenseClient *opnsense.Client
+	Provider *provider.Provider
+	// Certificates enables TLS on the listener when it is set.
+	Certificates *services.Certificates
 }
 
 func NewApi(svc *ApiSvc, conf ApiConfig) *Api {
@@ -54,70 +67,43 @@ func NewApi(svc *ApiSvc, conf ApiConfig) *Api {
 	return a
 }
 
+// Start listens on the address and serves in the background, where the returned channel receives the error that stops the server.
+// The listener is ready when it returns, so that the errors of listening are received right away.
 func (a *Api) Start(address string) chan error {
-	errCh := make(chan error)
-	isListenerReady := make(chan struct{})
+	errCh := make(chan error, 1)
 
-	go func() {
-		listener, err := net.Listen("tcp", address)
-		if err != nil {
-			errCh <- err
-
-			return
-		}
-
-		a.listener = listener
-		a.server.Handler = a.Echo
-		close(isListenerReady)
-
-		a.log.Infof("Starting server at address: %s", listener.Addr().String())
-
-		errCh <- a.server.Serve(listener)
-	}()
-
-	<-isListenerReady
-
-	return errCh
-}
+	listener, err := services.Listen(address)
+	if err != nil {
+		errCh <- err
 
-func (a *Api) IsReady() chan bool {
-	res := make(chan bool, 1)
-
-	<-a.GetListener()
-
-	if err := a.OpnsenseClient.CheckUnboundService(context.Background()); err != nil {
-		a.log.Errorf("Unbound service is not running: %v", err)
-
-		res <- false
+		return errCh
+	}
 
-		return res
+	if a.Certificates != nil {
+		listener = tls.NewListener(listener, a.Certificates.TLSConfig())
 	}
 
-	res <- true
+	a.mu.Lock()
+	a.listener = listener
+	a.mu.Unlock()
 
-	return res
-}
+	a.server.Handler = a.Echo
 
-func (a *Api) GetListener() chan net.Listener {
-	listener := make(chan net.Listener, 1)
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
+	a.log.Infof("Starting server at address: %s", listener.Addr().String())
 
 	go func() {
-		<-ctx.Done()
-
-		if err := ctx.Err(); err != nil && !errors.Is(err, context.Canceled) {
-			a.log.Panicf("Listener not ready: %w", err)
-		}
+		errCh <- a.server.Serve(listener)
 	}()
 
-	for a.listener == nil {
-		time.Sleep(10 * time.Millisecond)
-	}
+	return errCh
+}
 
-	listener <- a.listener
+// Listener returns the listener of the server, which is nil until the server is started.
+func (a *Api) Listener() net.Listener {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
 
-	return listener
+	return a.listener
 }
 
 func (a *Api) Shutdown() error {
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"time"
 
 	"github.com/cenk1cenk2/external-dns-webhook-opnsense/api"
 	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/interfaces"
@@ -87,6 +88,35 @@ var _ = Describe("API", func() {
 			Expect(body.Message).To(Equal("test"))
 			Expect(body.Error()).To(Equal("test"))
 		})
+
+		It("should return a JSON response with the code and the retry after header of the api error", func() {
+			c, res := fixtures.CreateEchoContext(a.Echo, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			a.Echo.HTTPErrorHandler(c, fmt.Errorf("wrapped: %w", &interfaces.ApiError{
+				Status:     http.StatusServiceUnavailable,
+				Code:       interfaces.ApiErrorCodeUpstreamUnavailable,
+				Message:    "test",
+				Retryable:  true,
+				RetryAfter: 1500 * time.Millisecond,
+			}))
+
+			Expect(res.Code).To(Equal(http.StatusServiceUnavailable))
+			Expect(res.Header().Get("Retry-After")).To(Equal("2"))
+
+			body := fixtures.MustJsonUnmarshal(&interfaces.ApiError{}, res.Body.String())
+			Expect(body.Code).To(Equal(interfaces.ApiErrorCodeUpstreamUnavailable))
+			Expect(body.Retryable).To(BeTrue())
+		})
+
+		It("should return the generic code of the http error", func() {
+			c, res := fixtures.CreateEchoContext(a.Echo, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			a.Echo.HTTPErrorHandler(c, echo.NewHTTPError(http.StatusUnsupportedMediaType, "test"))
+
+			body := fixtures.MustJsonUnmarshal(&interfaces.ApiError{}, res.Body.String())
+			Expect(body.Code).To(Equal(interfaces.ApiErrorCode("unsupported_media_type")))
+			Expect(body.Retryable).To(BeFalse())
+		})
 	})
 
 	Describe("Validator", func() {
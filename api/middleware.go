@@ -1,14 +1,22 @@
 package api
 
 import (
-	"errors"
+	"crypto/subtle"
+	"fmt"
 	"net/http"
 	"slices"
 	"strings"
+	"time"
 
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/ctx"
 	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/interfaces"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services"
 	"github.com/labstack/echo/v5"
 	"github.com/labstack/echo/v5/middleware"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
@@ -28,9 +36,12 @@ func (a *Api) SetupMiddleware() {
 }
 
 func (a *Api) GetMiddlewares() []echo.MiddlewareFunc {
-	return []echo.MiddlewareFunc{
+	middlewares := []echo.MiddlewareFunc{
 		middleware.Recover(),
+		a.TracingMiddleware(),
+		a.MetricsMiddleware(),
 		middleware.RequestID(),
+		a.LogFieldsMiddleware(),
 		middleware.RequestLoggerWithConfig(middleware.RequestLoggerConfig{
 			LogStatus:   true,
 			LogURI:      true,
@@ -63,29 +74,123 @@ func (a *Api) GetMiddlewares() []echo.MiddlewareFunc {
 				return nil
 			},
 		}),
-		middleware.CORSWithConfig(middleware.CORSConfig{
-			AllowOrigins: []string{"*"},
-		}),
+		middleware.CORSWithConfig(a.GetCORSConfig()),
+	}
+
+	if a.Config.AuthToken != "" {
+		middlewares = append(middlewares, middleware.KeyAuthWithConfig(middleware.KeyAuthConfig{
+			KeyLookup: "header:" + echo.HeaderAuthorization + ":Bearer ",
+			Validator: func(_ *echo.Context, key string, _ middleware.ExtractorSource) (bool, error) {
+				return subtle.ConstantTimeCompare([]byte(key), []byte(a.Config.AuthToken)) == 1, nil
+			},
+			ErrorHandler: func(c *echo.Context, _ error) error {
+				c.Response().Header().Set(echo.HeaderWWWAuthenticate, "Bearer")
+
+				return echo.NewHTTPError(http.StatusUnauthorized, "missing or invalid bearer token")
+			},
+		}))
+	}
+
+	return middlewares
+}
+
+// TracingMiddleware starts a span for every request, continuing the trace from the incoming trace context headers.
+func (a *Api) TracingMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c *echo.Context) error {
+			req := c.Request()
+
+			spanCtx := otel.GetTextMapPropagator().Extract(req.Context(), propagation.HeaderCarrier(req.Header))
+			spanCtx, span := services.Tracer().Start(
+				spanCtx,
+				fmt.Sprintf("%s %s", req.Method, c.Path()),
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					attribute.String("http.request.method", req.Method),
+					attribute.String("http.route", c.Path()),
+					attribute.String("url.path", req.URL.Path),
+				),
+			)
+			defer span.End()
+
+			c.SetRequest(req.WithContext(spanCtx))
+
+			err := next(c)
+
+			_, status := echo.ResolveResponseStatus(c.Response(), err)
+			span.SetAttributes(attribute.Int("http.response.status_code", status))
+			if status >= http.StatusInternalServerError {
+				services.RecordSpanError(span, err)
+			}
+
+			return err
+		}
+	}
+}
+
+// LogFieldsMiddleware carries the request ID, the route and the trace ID in the request context,
+// so that the logs of the provider and the client can be correlated with the request that caused them.
+func (a *Api) LogFieldsMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c *echo.Context) error {
+			req := c.Request()
+
+			fields := []zap.Field{
+				zap.String("request_id", services.GetRequestID(c)),
+				zap.String("route", c.Path()),
+			}
+
+			if span := trace.SpanContextFromContext(req.Context()); span.HasTraceID() {
+				fields = append(fields, zap.String("trace_id", span.TraceID().String()))
+			}
+
+			c.SetRequest(req.WithContext(ctx.WithLogFields(req.Context(), fields...)))
+
+			return next(c)
+		}
+	}
+}
+
+// MetricsMiddleware records the requests to the webhook by the route template, so that the metrics have a bounded set of labels.
+func (a *Api) MetricsMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c *echo.Context) error {
+			start := time.Now()
+
+			err := next(c)
+
+			_, status := echo.ResolveResponseStatus(c.Response(), err)
+			a.Metrics.ObserveWebhookRequest(c.Path(), c.Request().Method, status, time.Since(start))
+
+			return err
+		}
+	}
+}
+
+// GetCORSConfig returns the configured CORS policy, which allows every origin when nothing is configured.
+func (a *Api) GetCORSConfig() middleware.CORSConfig {
+	origins := a.Config.CORSAllowOrigins
+	if len(origins) == 0 {
+		origins = []string{"*"}
+	}
+
+	return middleware.CORSConfig{
+		AllowOrigins:     origins,
+		AllowMethods:     a.Config.CORSAllowMethods,
+		AllowHeaders:     a.Config.CORSAllowHeaders,
+		AllowCredentials: a.Config.CORSAllowCredentials,
 	}
 }
 
 func (a *Api) HTTPErrorHandler(c *echo.Context, err error) {
 	log := a.Logger.WithEchoContext(c)
 
-	var e *echo.HTTPError
-	if ok := errors.As(err, &e); ok {
-		log.Errorf("HTTP %d - %s", e.Code, e.Message)
-		_ = c.JSON(e.Code, interfaces.ApiError{
-			Status:  e.Code,
-			Message: e.Message,
-		})
-
-		return
+	e := interfaces.ToApiError(err)
+	if e.Retryable {
+		log.Warnf("HTTP %d - %s - %s", e.Status, e.Code, e.Message)
+	} else {
+		log.Errorf("HTTP %d - %s - %s", e.Status, e.Code, e.Message)
 	}
 
-	log.Errorf("HTTP %d - %s", http.StatusInternalServerError, err.Error())
-	_ = c.JSON(http.StatusInternalServerError, interfaces.ApiError{
-		Status:  http.StatusInternalServerError,
-		Message: err.Error(),
-	})
+	_ = e.Render(c)
 }
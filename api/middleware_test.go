@@ -8,8 +8,14 @@ import (
 	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/ctx"
 	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services"
 	"github.com/cenk1cenk2/external-dns-webhook-opnsense/test/fixtures"
+	"github.com/labstack/echo/v5"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var _ = Describe("Middleware", func() {
@@ -45,4 +51,138 @@ var _ = Describe("Middleware", func() {
 		Expect(err.Error()).To(ContainSubstring("PANIC RECOVER"))
 		Expect(res.Code).To(Equal(http.StatusInternalServerError))
 	})
+
+	Describe("Authentication", func() {
+		BeforeEach(func() {
+			c := fixtures.NewTestConfig()
+			c.Api.AuthToken = "token"
+
+			a = api.NewApi(&api.ApiSvc{
+				Logger:    fixtures.NewTestLogger(),
+				Validator: services.NewValidator(),
+			}, c.Api)
+
+			a.Echo.GET("/protected", ctx.With(
+				func(c *ctx.Context) error {
+					return c.NoContent(http.StatusOK)
+				}),
+			)
+		})
+
+		DescribeTable("should authenticate the requests with the bearer token", func(header string, status int) {
+			req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+			if header != "" {
+				req.Header.Set(echo.HeaderAuthorization, header)
+			}
+			res := httptest.NewRecorder()
+
+			a.Echo.ServeHTTP(res, req)
+
+			Expect(res.Code).To(Equal(status))
+		},
+			Entry("valid token", "Bearer token", http.StatusOK),
+			Entry("invalid token", "Bearer invalid", http.StatusUnauthorized),
+			Entry("missing token", "", http.StatusUnauthorized),
+			Entry("basic auth", "Basic dG9rZW46dG9rZW4=", http.StatusUnauthorized),
+		)
+	})
+
+	Describe("CORS", func() {
+		It("should allow every origin by default", func() {
+			Expect(a.GetCORSConfig().AllowOrigins).To(Equal([]string{"*"}))
+		})
+
+		It("should only allow the configured origins", func() {
+			c := fixtures.NewTestConfig()
+			c.Api.CORSAllowOrigins = []string{"https://allowed.example.com"}
+
+			a = api.NewApi(&api.ApiSvc{
+				Logger:    fixtures.NewTestLogger(),
+				Validator: services.NewValidator(),
+			}, c.Api)
+
+			for origin, allowed := range map[string]string{
+				"https://allowed.example.com": "https://allowed.example.com",
+				"https://denied.example.com":  "",
+			} {
+				req := httptest.NewRequest(http.MethodOptions, "/", nil)
+				req.Header.Set(echo.HeaderOrigin, origin)
+				req.Header.Set(echo.HeaderAccessControlRequestMethod, http.MethodPost)
+				res := httptest.NewRecorder()
+
+				a.Echo.ServeHTTP(res, req)
+
+				Expect(res.Header().Get(echo.HeaderAccessControlAllowOrigin)).To(Equal(allowed))
+			}
+		})
+	})
+})
+
+var _ = Describe("Tracing", func() {
+	It("should continue the trace from the incoming trace context", func() {
+		recorder := tracetest.NewSpanRecorder()
+		previous := otel.GetTracerProvider()
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+		DeferCleanup(otel.SetTracerProvider, previous)
+		otel.SetTextMapPropagator(propagation.TraceContext{})
+
+		a := api.NewApi(&api.ApiSvc{
+			Logger:    fixtures.NewTestLogger(),
+			Validator: services.NewValidator(),
+		}, fixtures.NewTestConfig().Api)
+
+		var traceID string
+		a.Echo.GET("/traced", ctx.With(
+			func(c *ctx.Context) error {
+				traceID = trace.SpanContextFromContext(c.Request().Context()).TraceID().String()
+
+				return c.NoContent(http.StatusOK)
+			}),
+		)
+
+		req := httptest.NewRequest(http.MethodGet, "/traced", nil)
+		req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+		res := httptest.NewRecorder()
+
+		a.Echo.ServeHTTP(res, req)
+
+		Expect(res.Code).To(Equal(http.StatusOK))
+		Expect(traceID).To(Equal("4bf92f3577b34da6a3ce929d0e0e4736"))
+
+		spans := recorder.Ended()
+		Expect(spans).To(HaveLen(1))
+		Expect(spans[0].Name()).To(Equal("GET /traced"))
+		Expect(spans[0].Parent().SpanID().String()).To(Equal("00f067aa0ba902b7"))
+	})
+})
+
+var _ = Describe("Log Fields", func() {
+	It("should carry the request ID and the route in the request context", func() {
+		a := api.NewApi(&api.ApiSvc{
+			Logger:    fixtures.NewTestLogger(),
+			Validator: services.NewValidator(),
+		}, fixtures.NewTestConfig().Api)
+
+		var fields map[string]string
+		a.Echo.GET("/records/:id", ctx.With(
+			func(c *ctx.Context) error {
+				fields = map[string]string{}
+				for _, field := range ctx.LogFields(c.Request().Context()) {
+					fields[field.Key] = field.String
+				}
+
+				return c.NoContent(http.StatusOK)
+			}),
+		)
+
+		req := httptest.NewRequest(http.MethodGet, "/records/1", nil)
+		req.Header.Set(echo.HeaderXRequestID, "request-1")
+		res := httptest.NewRecorder()
+
+		a.Echo.ServeHTTP(res, req)
+
+		Expect(res.Code).To(Equal(http.StatusOK))
+		Expect(fields).To(HaveKeyWithValue("request_id", "request-1"))
+		Expect(fields).To(HaveKeyWithValue("route", "/records/:id"))
+	})
 })
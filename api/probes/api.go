@@ -2,14 +2,14 @@ package probes
 
 import (
 	"context"
-	"errors"
 	"net"
 	"net/http"
+	"sync"
 	"time"
 
-	"github.com/cenk1cenk2/external-dns-webhook-opnsense/api"
 	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/interfaces"
 	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/health"
 	"github.com/labstack/echo/v5"
 )
 
@@ -19,6 +19,7 @@ type Api struct {
 	log      services.ZapSugaredLogger
 	listener net.Listener
 	server   *http.Server
+	mu       sync.RWMutex
 
 	*ApiSvc
 }
@@ -31,8 +32,8 @@ type ApiConfig struct {
 type ApiSvc struct {
 	Logger    *services.Logger
 	Validator *services.Validator
-
-	WebhookApi *api.Api
+	Metrics   *services.Metrics
+	Health    *health.Checker
 }
 
 func NewApi(svc *ApiSvc, conf ApiConfig) *Api {
@@ -52,64 +53,39 @@ func NewApi(svc *ApiSvc, conf ApiConfig) *Api {
 	return a
 }
 
+// Start listens on the address and serves in the background, where the returned channel receives the error that stops the server.
+// The listener is ready when it returns, so that the errors of listening are received right away.
 func (a *Api) Start(address string) chan error {
-	errCh := make(chan error)
-	isListenerReady := make(chan struct{})
+	errCh := make(chan error, 1)
 
-	go func() {
-		listener, err := net.Listen("tcp", address)
-		if err != nil {
-			errCh <- err
+	listener, err := services.Listen(address)
+	if err != nil {
+		errCh <- err
 
-			return
-		}
+		return errCh
+	}
 
-		a.listener = listener
-		a.server.Handler = a.Echo
-		close(isListenerReady)
+	a.mu.Lock()
+	a.listener = listener
+	a.mu.Unlock()
 
-		a.log.Infof("Starting health server at address: %s", listener.Addr().String())
+	a.server.Handler = a.Echo
 
+	a.log.Infof("Starting health server at address: %s", listener.Addr().String())
+
+	go func() {
 		errCh <- a.server.Serve(listener)
 	}()
 
-	<-isListenerReady
-
 	return errCh
 }
 
-func (a *Api) IsReady() chan bool {
-	res := make(chan bool, 1)
-
-	<-a.GetListener()
-
-	<-a.WebhookApi.IsReady()
-
-	res <- true
-
-	return res
-}
-
-func (a *Api) GetListener() chan net.Listener {
-	listener := make(chan net.Listener, 1)
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
-
-	go func() {
-		<-ctx.Done()
-
-		if err := ctx.Err(); err != nil && !errors.Is(err, context.Canceled) {
-			a.log.Panicf("Listener not ready: %w", err)
-		}
-	}()
-
-	for a.listener == nil {
-		time.Sleep(10 * time.Millisecond)
-	}
-
-	listener <- a.listener
+// Listener returns the listener of the server, which is nil until the server is started.
+func (a *Api) Listener() net.Listener {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
 
-	return listener
+	return a.listener
 }
 
 func (a *Api) Shutdown() error {
@@ -3,6 +3,7 @@ package probes
 import (
 	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/interfaces"
 	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/health"
 )
 
 type Handler struct {
@@ -11,11 +12,10 @@ type Handler struct {
 
 var _ interfaces.RegisterRoutes = (*Handler)(nil)
 
-type IsReadyFunc = func() chan bool
-
 type HandlerSvc struct {
 	Log     *services.Logger
-	IsReady IsReadyFunc
+	Metrics *services.Metrics
+	Health  *health.Checker
 }
 
 func NewHandler(svc *HandlerSvc) *Handler {
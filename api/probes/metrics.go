@@ -0,0 +1,16 @@
+package probes
+
+import (
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/ctx"
+)
+
+// @Tags		Probes
+// @Summary	Returns the metrics of the service in the Prometheus exposition format.
+// @Produce	plain
+// @Success	200	{string}	string
+// @Router	/metrics [get]
+func (h *Handler) HandleMetricsGet(c *ctx.Context) error {
+	h.Metrics.Handler().ServeHTTP(c.Response(), c.Request())
+
+	return nil
+}
@@ -0,0 +1,28 @@
+package probes_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/test/fixtures"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("metrics", func() {
+	Context("GET", func() {
+		It("should return the metrics in the Prometheus exposition format", func() {
+			handler.Metrics = services.NewMetrics()
+			handler.Metrics.ObserveWebhookRequest("/records", http.MethodGet, http.StatusOK, time.Second)
+
+			c, res := fixtures.CreateEchoContext(nil, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+			Expect(fixtures.Respond(c, handler.HandleMetricsGet)).ToNot(HaveOccurred())
+			Expect(res.Code).To(Equal(http.StatusOK))
+			Expect(res.Body.String()).To(ContainSubstring(`external_dns_opnsense_webhook_requests_total{method="GET",route="/records",status="200"} 1`))
+		})
+	})
+})
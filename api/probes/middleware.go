@@ -1,9 +1,6 @@
 package probes
 
 import (
-	"errors"
-	"net/http"
-
 	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/interfaces"
 	"github.com/labstack/echo/v5"
 	"github.com/labstack/echo/v5/middleware"
@@ -35,18 +32,5 @@ func (a *Api) GetMiddlewares() []echo.MiddlewareFunc {
 }
 
 func (a *Api) HTTPErrorHandler(c *echo.Context, err error) {
-	var e *echo.HTTPError
-	if ok := errors.As(err, &e); ok {
-		_ = c.JSON(e.Code, interfaces.ApiError{
-			Status:  e.Code,
-			Message: e.Message,
-		})
-
-		return
-	}
-
-	_ = c.JSON(http.StatusInternalServerError, interfaces.ApiError{
-		Status:  http.StatusInternalServerError,
-		Message: err.Error(),
-	})
+	_ = interfaces.ToApiError(err).Render(c)
 }
@@ -5,15 +5,44 @@ import (
 	"net/http"
 
 	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/ctx"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/health"
 )
 
+type ReadyQuery struct {
+	Verbose bool `query:"verbose"`
+}
+
+type ReadyResponse struct {
+	Ready      bool                    `json:"ready"`
+	Components []health.ComponentState `json:"components"`
+}
+
 // @Tags		Probes
-// @Summary	Returns the ready status of the service.
+// @Summary	Returns the ready status of the service from the cached states of the components.
 // @Produce	json
-// @Success	200	{string}	string
+// @Param		verbose	query		bool	false	"Return the state of every component."
+// @Success	200		{object}	ReadyResponse
+// @Failure	503		{object}	ReadyResponse
 // @Router  /readyz [get]
 func (h *Handler) HandleReadyGet(c *ctx.Context) error {
-	ready := <-h.IsReady()
+	query := &ReadyQuery{}
+	if err := c.BindQueryParams(query); err != nil {
+		return err
+	}
+
+	ready := h.Health.IsReady()
+
+	if query.Verbose {
+		status := http.StatusOK
+		if !ready {
+			status = http.StatusServiceUnavailable
+		}
+
+		return c.JSON(status, ReadyResponse{
+			Ready:      ready,
+			Components: h.Health.Components(),
+		})
+	}
 
 	if !ready {
 		return c.NewHTTPError(http.StatusServiceUnavailable, fmt.Errorf("service is not ready."))
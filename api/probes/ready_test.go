@@ -1,9 +1,12 @@
 package probes_test
 
 import (
+	"errors"
 	"net/http"
 	"net/http/httptest"
 
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/api/probes"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/health"
 	"github.com/cenk1cenk2/external-dns-webhook-opnsense/test/fixtures"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -11,33 +14,81 @@ import (
 )
 
 var _ = Describe("readyz", func() {
-	Context("GET", func() {
-		It("should return http.StatusOK when not ready", func() {
-			handler.IsReady = func() chan bool {
-				c := make(chan bool, 1)
+	var checker *health.Checker
+
+	BeforeEach(func() {
+		checker = health.NewChecker(&health.CheckerSvc{
+			Logger: fixtures.NewTestLogger(),
+		}, health.CheckerConfig{})
+		checker.Register(nil, true, health.ComponentListener, health.ComponentUnbound)
+		checker.Register(nil, false, health.ComponentReconfigure)
+
+		handler.Health = checker
+	})
 
-				c <- true
+	Context("GET", func() {
+		It("should return http.StatusOK when ready", func() {
+			checker.Set(health.ComponentListener, nil)
+			checker.Set(health.ComponentUnbound, nil)
 
-				return c
-			}
 			c, res := fixtures.CreateEchoContext(nil, httptest.NewRequest(http.MethodGet, "/", nil))
 
 			Expect(fixtures.Respond(c, handler.HandleReadyGet)).ToNot(HaveOccurred())
 			Expect(res.Code).To(Equal(http.StatusOK))
 		})
 
-		It("should return http.StatusServiceUnavailable when not ready", func() {
-			handler.IsReady = func() chan bool {
-				c := make(chan bool, 1)
+		It("should return http.StatusServiceUnavailable when not checked yet", func() {
+			c, res := fixtures.CreateEchoContext(nil, httptest.NewRequest(http.MethodGet, "/", nil))
 
-				c <- false
+			Expect(fixtures.Respond(c, handler.HandleReadyGet)).To(HaveOccurred())
+			Expect(res.Code).To(Equal(http.StatusServiceUnavailable))
+		})
+
+		It("should return http.StatusServiceUnavailable when not ready", func() {
+			checker.Set(health.ComponentListener, nil)
+			checker.Set(health.ComponentUnbound, errors.New("unbound service is not running"))
 
-				return c
-			}
 			c, res := fixtures.CreateEchoContext(nil, httptest.NewRequest(http.MethodGet, "/", nil))
 
 			Expect(fixtures.Respond(c, handler.HandleReadyGet)).To(HaveOccurred())
 			Expect(res.Code).To(Equal(http.StatusServiceUnavailable))
 		})
+
+		It("should stay ready when a component that is not critical is down", func() {
+			checker.Set(health.ComponentListener, nil)
+			checker.Set(health.ComponentUnbound, nil)
+			checker.Set(health.ComponentReconfigure, errors.New("failed to reconfigure"))
+
+			c, res := fixtures.CreateEchoContext(nil, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			Expect(fixtures.Respond(c, handler.HandleReadyGet)).ToNot(HaveOccurred())
+			Expect(res.Code).To(Equal(http.StatusOK))
+		})
+
+		It("should return the state of every component when verbose", func() {
+			checker.Set(health.ComponentListener, nil)
+			checker.Set(health.ComponentUnbound, errors.New("unbound service is not running"))
+
+			c, res := fixtures.CreateEchoContext(nil, httptest.NewRequest(http.MethodGet, "/?verbose=1", nil))
+
+			Expect(fixtures.Respond(c, handler.HandleReadyGet)).ToNot(HaveOccurred())
+			Expect(res.Code).To(Equal(http.StatusServiceUnavailable))
+
+			body := fixtures.MustJsonUnmarshal(probes.ReadyResponse{}, res.Body.Bytes())
+			Expect(body.Ready).To(BeFalse())
+			Expect(body.Components).To(HaveLen(3))
+
+			Expect(body.Components[0].Name).To(Equal(health.ComponentListener))
+			Expect(body.Components[0].Status).To(Equal(health.StatusUp))
+			Expect(body.Components[0].LastCheck).ToNot(BeZero())
+
+			Expect(body.Components[1].Name).To(Equal(health.ComponentReconfigure))
+			Expect(body.Components[1].Status).To(Equal(health.StatusUnknown))
+			Expect(body.Components[1].Critical).To(BeFalse())
+
+			Expect(body.Components[2].Name).To(Equal(health.ComponentUnbound))
+			Expect(body.Components[2].Status).To(Equal(health.StatusDown))
+			Expect(body.Components[2].Error).To(Equal("unbound service is not running"))
+		})
 	})
 })
@@ -8,7 +8,8 @@ import (
 func (a *Api) RegisterRoutes(group *echo.Group) {
 	NewHandler(&HandlerSvc{
 		Log:     a.Logger,
-		IsReady: a.IsReady,
+		Metrics: a.Metrics,
+		Health:  a.Health,
 	}).
 		RegisterRoutes(group)
 }
@@ -22,4 +23,8 @@ func (h *Handler) RegisterRoutes(g *echo.Group) {
 		h.HandleReadyGet,
 		h.Log,
 	))
+	g.GET("/metrics", ctx.With(
+		h.HandleMetricsGet,
+		h.Log,
+	))
 }
@@ -20,7 +20,7 @@ func (h *Handler) HandleAdjustEndpointsPost(c *ctx.Context) error {
 
 	endpoints, err := h.Provider.AdjustEndpoints(body)
 	if err != nil {
-		return c.NewHTTPError(http.StatusUnprocessableEntity, err)
+		return NewProviderError(err)
 	}
 
 	c.Response().Header().Set(echo.HeaderContentType, ExternalDnsAcceptedMedia)
@@ -0,0 +1,151 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/interfaces"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/opnsense"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/provider"
+)
+
+const (
+	// RetryAfterUpstream is the time that external-dns is asked to wait when OPNsense is failing.
+	RetryAfterUpstream = 10 * time.Second
+	// RetryAfterConflict is the time that external-dns is asked to wait when another batch is being applied.
+	RetryAfterConflict = time.Second
+	// RetryAfterRefused is the time that external-dns is asked to wait when the batch is refused by a guard until an operator acts.
+	RetryAfterRefused = time.Minute
+)
+
+// NewProviderError maps the failures of the provider to the status codes, so that external-dns retries
+// the 5xx responses as soft errors, while the invalid endpoints are rejected with 400 as permanent errors.
+// The batches that are refused by the ownership and deletion guards are held back with 503 as well, rather than
+// stopping external-dns, since the same plan is computed again until an operator acts.
+// external-dns only retries the statuses between 500 and 510 and treats any other failure as fatal,
+// so that every failure that can succeed later has to be answered with one of them.
+func NewProviderError(err error) *interfaces.ApiError {
+	e := &interfaces.ApiError{
+		Message: err.Error(),
+		Details: newErrorDetails(err),
+		Err:     err,
+	}
+
+	var circuitErr *opnsense.CircuitOpenError
+	switch {
+	case errors.Is(err, provider.ErrInvalidEndpoint), errors.Is(err, opnsense.ErrValidation):
+		e.Status = http.StatusBadRequest
+		e.Code = interfaces.ApiErrorCodeInvalidEndpoint
+	case errors.Is(err, provider.ErrProtectedRecord):
+		// the refused batches are held back rather than being fatal for external-dns, which retries them until an operator acts
+		e.Status = http.StatusServiceUnavailable
+		e.Code = interfaces.ApiErrorCodeProtectedRecord
+		e.Retryable = true
+		e.RetryAfter = RetryAfterRefused
+	case errors.Is(err, provider.ErrUnmanagedRecord):
+		e.Status = http.StatusServiceUnavailable
+		e.Code = interfaces.ApiErrorCodeUnmanagedRecord
+		e.Retryable = true
+		e.RetryAfter = RetryAfterRefused
+	case errors.Is(err, provider.ErrDeletionThreshold):
+		e.Status = http.StatusServiceUnavailable
+		e.Code = interfaces.ApiErrorCodeDeletionThreshold
+		e.Retryable = true
+		e.RetryAfter = RetryAfterRefused
+	case errors.Is(err, provider.ErrReadOnly):
+		// the batch is retried by external-dns until the read-only mode is disabled
+		e.Status = http.StatusServiceUnavailable
+		e.Code = interfaces.ApiErrorCodeReadOnly
+		e.Retryable = true
+		e.RetryAfter = RetryAfterUpstream
+	case errors.Is(err, provider.ErrChangeFreeze):
+		// the batch is retried by external-dns until the change freeze window ends
+		e.Status = http.StatusServiceUnavailable
+		e.Code = interfaces.ApiErrorCodeChangeFreeze
+		e.Retryable = true
+		e.RetryAfter = RetryAfterUpstream
+	case errors.Is(err, provider.ErrBatchInProgress):
+		e.Status = http.StatusServiceUnavailable
+		e.Code = interfaces.ApiErrorCodeConflict
+		e.Retryable = true
+		e.RetryAfter = RetryAfterConflict
+	case errors.Is(err, opnsense.ErrNotFound):
+		// the records have changed on OPNsense since external-dns has fetched them, which is planned again on the retry
+		e.Status = http.StatusServiceUnavailable
+		e.Code = interfaces.ApiErrorCodeConflict
+		e.Retryable = true
+		e.RetryAfter = RetryAfterConflict
+	case errors.As(err, &circuitErr):
+		e.Status = http.StatusServiceUnavailable
+		e.Code = interfaces.ApiErrorCodeUpstreamUnavailable
+		e.Retryable = true
+		e.RetryAfter = max(circuitErr.RetryAfter, time.Second)
+	case errors.Is(err, opnsense.ErrTransport), errors.Is(err, context.DeadlineExceeded), errors.Is(err, context.Canceled):
+		e.Status = http.StatusServiceUnavailable
+		e.Code = interfaces.ApiErrorCodeUpstreamUnavailable
+		e.Retryable = true
+		e.RetryAfter = RetryAfterUpstream
+	case errors.Is(err, opnsense.ErrServer):
+		e.Status = http.StatusBadGateway
+		e.Code = interfaces.ApiErrorCodeUpstreamError
+		e.Retryable = true
+		e.RetryAfter = RetryAfterUpstream
+	case errors.Is(err, opnsense.ErrUnauthorized), errors.Is(err, opnsense.ErrForbidden):
+		// the credentials and their privileges can be fixed on OPNsense or rotated without a restart
+		e.Status = http.StatusBadGateway
+		e.Code = interfaces.ApiErrorCodeUpstreamRejected
+		e.Retryable = true
+		e.RetryAfter = RetryAfterUpstream
+	default:
+		// external-dns retries every 5xx response, so the body tells the same
+		e.Status = http.StatusInternalServerError
+		e.Code = interfaces.ApiErrorCodeInternal
+		e.Retryable = true
+		e.RetryAfter = RetryAfterUpstream
+	}
+
+	return e
+}
+
+// newErrorDetails collects the failures of the endpoints with the validation messages of OPNsense.
+func newErrorDetails(err error) []interfaces.ApiErrorDetail {
+	details := []interfaces.ApiErrorDetail{}
+
+	for _, e := range endpointErrors(err) {
+		detail := interfaces.ApiErrorDetail{
+			DNSName:    e.DNSName,
+			RecordType: e.RecordType,
+			Message:    e.Error(),
+		}
+
+		var apiErr *opnsense.APIError
+		if errors.As(e, &apiErr) {
+			detail.Fields = apiErr.Fields
+		}
+
+		details = append(details, detail)
+	}
+
+	return details
+}
+
+// endpointErrors returns the failures of the endpoints in the tree of the wrapped errors.
+func endpointErrors(err error) []*provider.EndpointError {
+	switch e := err.(type) {
+	case *provider.EndpointError:
+		return []*provider.EndpointError{e}
+	case interface{ Unwrap() []error }:
+		errs := []*provider.EndpointError{}
+		for _, err := range e.Unwrap() {
+			errs = append(errs, endpointErrors(err)...)
+		}
+
+		return errs
+	case interface{ Unwrap() error }:
+		return endpointErrors(e.Unwrap())
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,104 @@
+package webhook_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/api/webhook"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/interfaces"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/opnsense"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/provider"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/test/fixtures"
+	"github.com/labstack/echo/v5"
+	"sigs.k8s.io/external-dns/endpoint"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("errors", func() {
+	// retriedByExternalDns is how external-dns classifies the status of a failed webhook request,
+	// where any status outside of this range is fatal for the controller.
+	retriedByExternalDns := func(status int) bool {
+		return status >= http.StatusInternalServerError && status <= http.StatusNotExtended
+	}
+
+	DescribeTable("should map the provider failures to the status codes", func(err error, status int, code interfaces.ApiErrorCode, retryable bool) {
+		e := webhook.NewProviderError(fmt.Errorf("failed: %w", err))
+
+		Expect(e.Status).To(Equal(status))
+		Expect(e.Code).To(Equal(code))
+		Expect(e.Retryable).To(Equal(retryable))
+		Expect(e.RetryAfter > 0).To(Equal(retryable))
+
+		Expect(retriedByExternalDns(e.Status)).To(Equal(e.Retryable), "external-dns does not handle the status %d as retryable %t", e.Status, e.Retryable)
+	},
+		Entry("invalid endpoint", &provider.EndpointError{Invalid: true, Err: errors.New("unsupported record type")}, http.StatusBadRequest, interfaces.ApiErrorCodeInvalidEndpoint, false),
+		Entry("validation", &opnsense.APIError{Kind: opnsense.ErrValidation}, http.StatusBadRequest, interfaces.ApiErrorCodeInvalidEndpoint, false),
+		Entry("unmanaged record", provider.ErrUnmanagedRecord, http.StatusServiceUnavailable, interfaces.ApiErrorCodeUnmanagedRecord, true),
+		Entry("protected record", provider.ErrProtectedRecord, http.StatusServiceUnavailable, interfaces.ApiErrorCodeProtectedRecord, true),
+		Entry("deletion threshold", provider.ErrDeletionThreshold, http.StatusServiceUnavailable, interfaces.ApiErrorCodeDeletionThreshold, true),
+		Entry("read only", provider.ErrReadOnly, http.StatusServiceUnavailable, interfaces.ApiErrorCodeReadOnly, true),
+		Entry("change freeze", &provider.FreezeError{Reason: "change freeze window is active", Until: time.Now().Add(time.Hour)}, http.StatusServiceUnavailable, interfaces.ApiErrorCodeChangeFreeze, true),
+		Entry("batch in progress", provider.ErrBatchInProgress, http.StatusServiceUnavailable, interfaces.ApiErrorCodeConflict, true),
+		Entry("stale record", &opnsense.APIError{Kind: opnsense.ErrNotFound, StatusCode: http.StatusNotFound}, http.StatusServiceUnavailable, interfaces.ApiErrorCodeConflict, true),
+		Entry("circuit open", &opnsense.CircuitOpenError{RetryAfter: 5 * time.Second}, http.StatusServiceUnavailable, interfaces.ApiErrorCodeUpstreamUnavailable, true),
+		Entry("transport", &opnsense.APIError{Kind: opnsense.ErrTransport, Err: errors.New("connection refused")}, http.StatusServiceUnavailable, interfaces.ApiErrorCodeUpstreamUnavailable, true),
+		Entry("server", &opnsense.APIError{Kind: opnsense.ErrServer, StatusCode: http.StatusInternalServerError}, http.StatusBadGateway, interfaces.ApiErrorCodeUpstreamError, true),
+		Entry("credentials", &opnsense.APIError{Kind: opnsense.ErrUnauthorized, StatusCode: http.StatusUnauthorized}, http.StatusBadGateway, interfaces.ApiErrorCodeUpstreamRejected, true),
+		Entry("privileges", &opnsense.APIError{Kind: opnsense.ErrForbidden, StatusCode: http.StatusForbidden}, http.StatusBadGateway, interfaces.ApiErrorCodeUpstreamRejected, true),
+		Entry("unknown", errors.New("unknown"), http.StatusInternalServerError, interfaces.ApiErrorCodeInternal, true),
+	)
+
+	It("should wait until the circuit breaker probes the API again", func() {
+		e := webhook.NewProviderError(&opnsense.CircuitOpenError{RetryAfter: 5 * time.Second})
+
+		Expect(e.RetryAfter).To(Equal(5 * time.Second))
+	})
+
+	It("should report the validation messages of the endpoint", func() {
+		e := webhook.NewProviderError(fmt.Errorf("phase failed: %w", &provider.EndpointError{
+			DNSName:    "example.com",
+			RecordType: endpoint.RecordTypeA,
+			Err: fmt.Errorf("failed to create host override example.com: %w", &opnsense.APIError{
+				Kind:   opnsense.ErrValidation,
+				Fields: map[string]string{"host.server": "A valid IP address is required."},
+			}),
+		}))
+
+		Expect(e.Status).To(Equal(http.StatusBadRequest))
+		Expect(e.Details).To(HaveLen(1))
+		Expect(e.Details[0].DNSName).To(Equal("example.com"))
+		Expect(e.Details[0].RecordType).To(Equal(endpoint.RecordTypeA))
+		Expect(e.Details[0].Fields).To(HaveKeyWithValue("host.server", "A valid IP address is required."))
+	})
+
+	It("should reject all the invalid endpoints at once", func() {
+		req := httptest.NewRequest(
+			http.MethodPost,
+			"/",
+			strings.NewReader(fixtures.MustJsonMarshal([]*endpoint.Endpoint{
+				endpoint.NewEndpoint("a.example.com", "SRV", "0 5 5060 sip.example.com"),
+				endpoint.NewEndpoint("b.example.com", endpoint.RecordTypeA, "192.168.1.1"),
+				endpoint.NewEndpoint("c.example.com", "NS", "ns.example.com"),
+			})),
+		)
+		req.Header.Set(echo.HeaderContentType, webhook.ExternalDnsAcceptedMedia)
+		c, res := fixtures.CreateEchoContext(nil, req)
+
+		err := fixtures.Respond(c, handler.HandleAdjustEndpointsPost)
+		Expect(err).To(HaveOccurred())
+		Expect(res.Code).To(Equal(http.StatusBadRequest))
+
+		var e *interfaces.ApiError
+		Expect(errors.As(err, &e)).To(BeTrue())
+		Expect(e.Code).To(Equal(interfaces.ApiErrorCodeInvalidEndpoint))
+		Expect(e.Details).To(HaveLen(2))
+		Expect(e.Details[0].DNSName).To(Equal("a.example.com"))
+		Expect(e.Details[1].DNSName).To(Equal("c.example.com"))
+	})
+})
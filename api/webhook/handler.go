@@ -33,6 +33,8 @@ func NewHandler(svc *HandlerSvc) *Handler {
 
 const (
 	ExternalDnsAcceptedMedia string = externaldnsapi.MediaTypeFormatAndVersion
+	// HeaderChangesDiscarded carries the reason that the batch is acknowledged without being applied.
+	HeaderChangesDiscarded string = "X-Changes-Discarded"
 )
 
 func (h *Handler) VerifyHeaders(c *ctx.Context) error {
@@ -1,9 +1,11 @@
 package webhook
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/ctx"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/provider"
 	"github.com/labstack/echo/v5"
 	"sigs.k8s.io/external-dns/plan"
 )
@@ -15,7 +17,7 @@ func (h *Handler) HandleRecordsGet(c *ctx.Context) error {
 
 	endpoints, err := h.Provider.Records(c.Request().Context())
 	if err != nil {
-		return c.NewHTTPError(http.StatusUnprocessableEntity, err)
+		return NewProviderError(err)
 	}
 
 	c.Response().Header().Set(echo.HeaderContentType, ExternalDnsAcceptedMedia)
@@ -34,8 +36,13 @@ func (h *Handler) HandleRecordsPost(c *ctx.Context) error {
 	}
 
 	err := h.Provider.ApplyChanges(c.Request().Context(), body)
-	if err != nil {
-		return c.NewHTTPError(http.StatusUnprocessableEntity, err)
+	// the discarded batch is acknowledged, since external-dns treats any other status as a failure
+	if errors.Is(err, provider.ErrChangesDiscarded) {
+		c.Response().Header().Set(HeaderChangesDiscarded, err.Error())
+
+		return c.NoContent(http.StatusNoContent)
+	} else if err != nil {
+		return NewProviderError(err)
 	}
 
 	return c.NoContent(http.StatusNoContent)
@@ -36,7 +36,18 @@ var _ = Describe("records", func() {
 			mocks.Client.EXPECT().UnboundSearchHostOverrides(mock.Anything, mock.Anything).Return(nil, fmt.Errorf("")).Once()
 
 			Expect(fixtures.Respond(c, handler.HandleRecordsGet)).To(HaveOccurred())
-			Expect(res.Code).To(Equal(http.StatusUnprocessableEntity))
+			Expect(res.Code).To(Equal(http.StatusInternalServerError))
+		})
+
+		It("should ask to retry later when OPNsense is not reachable", func() {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set(echo.HeaderAccept, webhook.ExternalDnsAcceptedMedia)
+			c, res := fixtures.CreateEchoContext(nil, req)
+
+			mocks.Client.EXPECT().UnboundSearchHostOverrides(mock.Anything, mock.Anything).Return(nil, &opnsense.APIError{Kind: opnsense.ErrTransport, Err: fmt.Errorf("connection refused")}).Once()
+
+			Expect(fixtures.Respond(c, handler.HandleRecordsGet)).To(HaveOccurred())
+			Expect(res.Code).To(Equal(http.StatusServiceUnavailable))
 		})
 
 		It("should be able to fetch the records on empty response", func() {
@@ -359,6 +370,28 @@ var _ = Describe("records", func() {
 			Expect(res.Code).To(Equal(http.StatusNoContent))
 		})
 
+		It("should acknowledge the discarded batch while the changes are frozen", func() {
+			Expect(handler.Provider.SetFreeze(provider.FreezeConfig{ReadOnly: true, Mode: provider.FreezeModeDiscard})).To(Succeed())
+			DeferCleanup(func() {
+				Expect(handler.Provider.SetFreeze(provider.FreezeConfig{})).To(Succeed())
+			})
+
+			req := httptest.NewRequest(
+				http.MethodPost,
+				"/",
+				strings.NewReader(fixtures.MustJsonMarshal(&plan.Changes{
+					Create: []*endpoint.Endpoint{endpoint.NewEndpoint("web.example.com", endpoint.RecordTypeA, "192.168.1.2")},
+				})),
+			)
+			req.Header.Set(echo.HeaderContentType, webhook.ExternalDnsAcceptedMedia)
+
+			c, res := fixtures.CreateEchoContext(nil, req)
+
+			Expect(fixtures.Respond(c, handler.HandleRecordsPost)).ToNot(HaveOccurred())
+			Expect(res.Code).To(Equal(http.StatusNoContent))
+			Expect(res.Header().Get(webhook.HeaderChangesDiscarded)).To(Equal("read-only mode is enabled"))
+		})
+
 		When("deleting records", func() {
 			It("should be able to handle A and AAAA records", func() {
 				req := httptest.NewRequest(
@@ -385,6 +418,28 @@ var _ = Describe("records", func() {
 				Expect(res.Code).To(Equal(http.StatusNoContent))
 			})
 
+			It("should treat the records that are already deleted as deleted", func() {
+				req := httptest.NewRequest(
+					http.MethodPost,
+					"/",
+					strings.NewReader(fixtures.MustJsonMarshal(&plan.Changes{
+						Delete: []*endpoint.Endpoint{
+							endpoint.NewEndpoint("example.com", endpoint.RecordTypeA, "192.168.1.1").
+								WithLabel(provider.EndpointLabelUUID.String(), "id-A"),
+						},
+					})),
+				)
+				req.Header.Set(echo.HeaderContentType, webhook.ExternalDnsAcceptedMedia)
+
+				mocks.Client.EXPECT().UnboundDeleteHostOverride(mock.Anything, "id-A").Return(&opnsense.APIError{Kind: opnsense.ErrNotFound, Result: "not found"}).Once()
+				mocks.Client.EXPECT().ReconfigureService(mock.Anything).Return(nil).Once()
+
+				c, res := fixtures.CreateEchoContext(nil, req)
+
+				Expect(fixtures.Respond(c, handler.HandleRecordsPost)).ToNot(HaveOccurred())
+				Expect(res.Code).To(Equal(http.StatusNoContent))
+			})
+
 			It("should be able to handle normal TXT records with UUID in Labels", func() {
 				req := httptest.NewRequest(
 					http.MethodPost,
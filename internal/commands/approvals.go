@@ -0,0 +1,167 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/config"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/provider"
+	"github.com/urfave/cli/v3"
+)
+
+// NewApprovalsCommand creates the operator commands for the batches of changes that are held for approval.
+func NewApprovalsCommand(conf *config.Config) *cli.Command {
+	yesFlag := &cli.BoolFlag{
+		Name:    "yes",
+		Aliases: []string{"y"},
+		Usage:   "Skip the confirmation prompt.",
+		Value:   false,
+	}
+
+	return &cli.Command{
+		Name:  "approvals",
+		Usage: "Manage the batches of changes that are held for approval.",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "output",
+				Aliases: []string{"o"},
+				Usage:   `Output format. enum("table", "json")`,
+				Value:   string(OutputFormatTable),
+			},
+		},
+		Commands: []*cli.Command{
+			{
+				Name:  "list",
+				Usage: "List the pending batches.",
+				Action: func(_ context.Context, cmd *cli.Command) error {
+					p, closer, err := setupProvider(conf)
+					if err != nil {
+						return err
+					}
+					defer closer()
+
+					batches, err := p.PendingBatches()
+					if err != nil {
+						return err
+					}
+
+					return writePendingBatches(cmd.Root().Writer, OutputFormat(cmd.String("output")), batches)
+				},
+			},
+
+			{
+				Name:      "diff",
+				Usage:     "Show the changes of a pending batch.",
+				ArgsUsage: "<id>",
+				Action: func(_ context.Context, cmd *cli.Command) error {
+					p, closer, err := setupProvider(conf)
+					if err != nil {
+						return err
+					}
+					defer closer()
+
+					batch, err := p.PendingBatch(cmd.Args().First())
+					if err != nil {
+						return err
+					}
+
+					for _, line := range batch.Diff() {
+						fmt.Fprintln(cmd.Root().Writer, line)
+					}
+
+					return nil
+				},
+			},
+
+			{
+				Name:  "approve",
+				Usage: "Apply a pending batch.",
+				Description: "The batch is applied with the configured read-only mode and change freeze windows, while the read-only mode " +
+					"that is toggled at runtime with the admin API is not known to the operator commands and is not respected.",
+				ArgsUsage: "<id>",
+				Flags: []cli.Flag{
+					yesFlag,
+					&cli.BoolFlag{
+						Name:  "override-deletion-thresholds",
+						Usage: "Apply the batch even when it exceeds the deletion thresholds, like an intentional mass delete.",
+						Value: false,
+					},
+				},
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					id := cmd.Args().First()
+
+					if conf.OpnsenseClient.DryRun {
+						fmt.Fprintf(cmd.Root().Writer, "Dry run enabled, skipping: Approve pending batch %s\n", id)
+
+						return nil
+					}
+
+					p, closer, err := setupProvider(conf)
+					if err != nil {
+						return err
+					}
+					defer closer()
+
+					batch, err := p.PendingBatch(id)
+					if err != nil {
+						return err
+					}
+
+					for _, line := range batch.Diff() {
+						fmt.Fprintln(cmd.Root().Writer, line)
+					}
+
+					ok, err := confirm(cmd, fmt.Sprintf("Approve pending batch %s?", id))
+					if err != nil {
+						return err
+					} else if !ok {
+						return fmt.Errorf("aborted by the operator")
+					}
+
+					if cmd.Bool("override-deletion-thresholds") {
+						ctx = provider.WithDeletionOverride(ctx)
+					}
+
+					if err := p.ApproveBatch(ctx, id); err != nil {
+						return err
+					}
+
+					fmt.Fprintf(cmd.Root().Writer, "Approved pending batch: %s\n", id)
+
+					return nil
+				},
+			},
+
+			{
+				Name:      "reject",
+				Usage:     "Discard a pending batch.",
+				ArgsUsage: "<id>",
+				Flags:     []cli.Flag{yesFlag},
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					id := cmd.Args().First()
+
+					ok, err := confirm(cmd, fmt.Sprintf("Reject pending batch %s?", id))
+					if err != nil {
+						return err
+					} else if !ok {
+						return fmt.Errorf("aborted by the operator")
+					}
+
+					p, closer, err := setupProvider(conf)
+					if err != nil {
+						return err
+					}
+					defer closer()
+
+					if err := p.RejectBatch(ctx, id); err != nil {
+						return err
+					}
+
+					fmt.Fprintf(cmd.Root().Writer, "Rejected pending batch: %s\n", id)
+
+					return nil
+				},
+			},
+		},
+	}
+}
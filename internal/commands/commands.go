@@ -0,0 +1,120 @@
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/config"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/notifier"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/opnsense"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/provider"
+	"github.com/urfave/cli/v3"
+)
+
+// setupClient creates the logger and the OPNsense client for the operator commands.
+func setupClient(conf *config.Config) (*services.Logger, *opnsense.Client, error) {
+	logger, err := services.NewLogger(&services.LoggerConfig{
+		Level:   conf.LogLevel,
+		Encoder: services.LogEncoder(conf.LogEncoder),
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := conf.Validate(services.NewValidator()); err != nil {
+		return nil, nil, err
+	}
+
+	client, err := opnsense.NewClient(
+		&opnsense.ClientSvc{
+			Logger: logger,
+		},
+		conf.OpnsenseClient,
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create opnsense client: %w", err)
+	}
+
+	return logger, client, nil
+}
+
+// setupProvider creates the provider for the operator commands, which applies the changes with the same guards, audit log and
+// notifications as the webhook. The returned function flushes the audit log and waits for the notifications before the command exits.
+func setupProvider(conf *config.Config) (*provider.Provider, func(), error) {
+	logger, client, err := setupClient(conf)
+	if err != nil {
+		return nil, nil, err
+	}
+	log := logger.WithCaller()
+
+	metrics := services.NewMetrics()
+
+	audit, err := services.NewAudit(conf.Audit)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create the audit sink: %w", err)
+	}
+
+	notifier, err := notifier.NewNotifier(
+		&notifier.NotifierSvc{
+			Logger:  logger,
+			Metrics: metrics,
+		},
+		conf.Notifier,
+	)
+	if err != nil {
+		_ = audit.Close()
+
+		return nil, nil, fmt.Errorf("failed to create the notifier: %w", err)
+	}
+
+	closer := func() {
+		notifier.Wait()
+
+		if err := audit.Close(); err != nil {
+			log.Warnf("Failed to close the audit sink: %v", err)
+		}
+	}
+
+	p, err := provider.NewProvider(
+		&provider.ProviderSvc{
+			Client:   client,
+			Logger:   logger,
+			Metrics:  metrics,
+			Audit:    audit,
+			Notifier: notifier,
+		},
+		conf.Provider,
+	)
+	if err != nil {
+		closer()
+
+		return nil, nil, fmt.Errorf("failed to create provider: %w", err)
+	}
+
+	return p, closer, nil
+}
+
+// confirm asks the operator to confirm a mutating action unless it has been pre-approved with the yes flag.
+func confirm(cmd *cli.Command, message string) (bool, error) {
+	if cmd.Bool("yes") {
+		return true, nil
+	}
+
+	root := cmd.Root()
+
+	fmt.Fprintf(root.Writer, "%s [y/N]: ", message)
+
+	answer, err := bufio.NewReader(root.Reader).ReadString('\n')
+	if err != nil && answer == "" {
+		return false, fmt.Errorf("failed to read confirmation: %w", err)
+	}
+
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true, nil
+	}
+
+	return false, nil
+}
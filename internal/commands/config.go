@@ -0,0 +1,66 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/BurntSushi/toml"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/config"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services"
+	"github.com/urfave/cli/v3"
+	"gopkg.in/yaml.v3"
+)
+
+// NewConfigCommand creates the commands for inspecting the resolved configuration.
+func NewConfigCommand(conf *config.Config) *cli.Command {
+	return &cli.Command{
+		Name:  "config",
+		Usage: "Inspect the configuration resolved from the flags, environment variables and the configuration file.",
+		Commands: []*cli.Command{
+			{
+				Name:  "validate",
+				Usage: "Validate the configuration.",
+				Action: func(_ context.Context, cmd *cli.Command) error {
+					if err := conf.Validate(services.NewValidator()); err != nil {
+						return err
+					}
+
+					fmt.Fprintln(cmd.Root().Writer, "Configuration is valid.")
+
+					return nil
+				},
+			},
+
+			{
+				Name:  "print",
+				Usage: "Print the resolved configuration in the configuration file format.",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "redacted",
+						Usage: "Redact the sensitive values.",
+						Value: false,
+					},
+					&cli.StringFlag{
+						Name:    "output",
+						Aliases: []string{"o"},
+						Usage:   `Output format. enum("yaml", "toml")`,
+						Value:   string(config.FileFormatYaml),
+					},
+				},
+				Action: func(_ context.Context, cmd *cli.Command) error {
+					values := config.Values(cmd.Root().Flags, cmd.Bool("redacted"))
+					w := cmd.Root().Writer
+
+					switch config.FileFormat(cmd.String("output")) {
+					case config.FileFormatYaml:
+						return yaml.NewEncoder(w).Encode(values)
+					case config.FileFormatToml:
+						return toml.NewEncoder(w).Encode(values)
+					}
+
+					return fmt.Errorf("unsupported output format: %s", cmd.String("output"))
+				},
+			},
+		},
+	}
+}
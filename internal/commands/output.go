@@ -0,0 +1,83 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/provider"
+)
+
+type OutputFormat string
+
+const (
+	OutputFormatTable OutputFormat = "table"
+	OutputFormatJson  OutputFormat = "json"
+)
+
+func writeRecords(w io.Writer, format OutputFormat, records []*provider.DnsRecord) error {
+	switch format {
+	case OutputFormatJson:
+		items := make([]any, 0, len(records))
+		for _, record := range records {
+			items = append(items, record.UnboundSearchHostOverrideItem)
+		}
+
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+
+		return encoder.Encode(items)
+	case OutputFormatTable:
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+		fmt.Fprintln(tw, "UUID\tENABLED\tTYPE\tFQDN\tTARGET\tDESCRIPTION")
+		for _, record := range records {
+			fmt.Fprintf(
+				tw,
+				"%s\t%t\t%s\t%s\t%s\t%s\n",
+				record.Id,
+				record.IsEnabled(),
+				record.Type,
+				record.GetFQDN(),
+				strings.Join(record.GetTarget(), ","),
+				record.Description,
+			)
+		}
+
+		return tw.Flush()
+	}
+
+	return fmt.Errorf("unsupported output format: %s", format)
+}
+
+func writePendingBatches(w io.Writer, format OutputFormat, batches []*provider.PendingBatch) error {
+	switch format {
+	case OutputFormatJson:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+
+		return encoder.Encode(batches)
+	case OutputFormatTable:
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+		fmt.Fprintln(tw, "ID\tCREATED\tCREATES\tUPDATES\tDELETES")
+		for _, batch := range batches {
+			fmt.Fprintf(
+				tw,
+				"%s\t%s\t%d\t%d\t%d\n",
+				batch.ID,
+				batch.CreatedAt.Format(time.RFC3339),
+				len(batch.Changes.Create),
+				len(batch.Changes.UpdateNew),
+				len(batch.Changes.Delete),
+			)
+		}
+
+		return tw.Flush()
+	}
+
+	return fmt.Errorf("unsupported output format: %s", format)
+}
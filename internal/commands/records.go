@@ -0,0 +1,543 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"strings"
+
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/config"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/opnsense"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/provider"
+	"github.com/urfave/cli/v3"
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+//revive:disable:line-length-limit
+
+type RecordsFilter struct {
+	Domain      string
+	Type        string
+	Description string
+}
+
+// Match checks whether the record is in the given domain, has the given type and contains the given description.
+func (f RecordsFilter) Match(record *provider.DnsRecord) bool {
+	if f.Domain != "" {
+		domain := strings.ToLower(strings.TrimSuffix(f.Domain, "."))
+		fqdn := strings.ToLower(record.GetFQDN())
+
+		if fqdn != domain && !strings.HasSuffix(fqdn, "."+domain) {
+			return false
+		}
+	}
+
+	if f.Type != "" && !strings.EqualFold(record.Type, f.Type) {
+		return false
+	}
+
+	if f.Description != "" && !strings.Contains(strings.ToLower(record.Description), strings.ToLower(f.Description)) {
+		return false
+	}
+
+	return true
+}
+
+// NewRecordsCommand creates the operator commands for managing the host overrides directly.
+func NewRecordsCommand(conf *config.Config) *cli.Command {
+	yesFlag := &cli.BoolFlag{
+		Name:    "yes",
+		Aliases: []string{"y"},
+		Usage:   "Skip the confirmation prompt.",
+		Value:   false,
+	}
+
+	writeFlags := []cli.Flag{
+		yesFlag,
+		&cli.BoolFlag{
+			Name:  "reconfigure",
+			Usage: "Reconfigure the Unbound service after the change to apply it.",
+			Value: true,
+		},
+	}
+
+	recordFlags := []cli.Flag{
+		&cli.StringFlag{
+			Name:  "hostname",
+			Usage: "Hostname of the record, leave empty for TXT records.",
+		},
+		&cli.StringFlag{
+			Name:  "domain",
+			Usage: "Domain of the record, or the full name for TXT records.",
+		},
+		&cli.StringFlag{
+			Name:  "type",
+			Usage: `Type of the record. enum("A", "AAAA", "TXT")`,
+			Value: endpoint.RecordTypeA,
+		},
+		&cli.StringFlag{
+			Name:  "target",
+			Usage: "Target of the record, the address for A/AAAA records or the data for TXT records.",
+		},
+		&cli.StringFlag{
+			Name:  "description",
+			Usage: "Description of the record.",
+		},
+	}
+
+	return &cli.Command{
+		Name:  "records",
+		Usage: "Manage the OPNsense Unbound host overrides directly.",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "output",
+				Aliases: []string{"o"},
+				Usage:   `Output format. enum("table", "json")`,
+				Value:   string(OutputFormatTable),
+			},
+		},
+		Commands: []*cli.Command{
+			{
+				Name:  "list",
+				Usage: "List the host overrides.",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "domain",
+						Usage: "Only list records in the given domain.",
+					},
+					&cli.StringFlag{
+						Name:  "type",
+						Usage: "Only list records with the given type.",
+					},
+					&cli.StringFlag{
+						Name:  "description",
+						Usage: "Only list records that contain the given description.",
+					},
+				},
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					_, client, err := setupClient(conf)
+					if err != nil {
+						return err
+					}
+
+					records, err := fetchRecords(ctx, client)
+					if err != nil {
+						return err
+					}
+
+					filter := RecordsFilter{
+						Domain:      cmd.String("domain"),
+						Type:        cmd.String("type"),
+						Description: cmd.String("description"),
+					}
+
+					filtered := make([]*provider.DnsRecord, 0, len(records))
+					for _, record := range records {
+						if filter.Match(record) {
+							filtered = append(filtered, record)
+						}
+					}
+
+					return writeRecords(cmd.Root().Writer, OutputFormat(cmd.String("output")), filtered)
+				},
+			},
+
+			{
+				Name:      "get",
+				Usage:     "Get a host override.",
+				ArgsUsage: "<uuid>",
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					_, client, err := setupClient(conf)
+					if err != nil {
+						return err
+					}
+
+					record, err := findRecord(ctx, client, cmd.Args().First())
+					if err != nil {
+						return err
+					}
+
+					return writeRecords(cmd.Root().Writer, OutputFormat(cmd.String("output")), []*provider.DnsRecord{record})
+				},
+			},
+
+			{
+				Name:  "create",
+				Usage: "Create a host override.",
+				Flags: append(
+					append(append([]cli.Flag{}, recordFlags...), writeFlags...),
+					&cli.BoolFlag{
+						Name:  "disabled",
+						Usage: "Create the record as disabled.",
+					},
+				),
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					record := &provider.DnsRecord{}
+					record.Enabled = "1"
+					if cmd.Bool("disabled") {
+						record.Enabled = "0"
+					}
+
+					if err := applyRecordFlags(cmd, record); err != nil {
+						return err
+					}
+
+					if record.Domain == "" {
+						return fmt.Errorf("domain is required")
+					}
+
+					return runWrite(ctx, cmd, conf, func(_ context.Context, client *opnsense.Client) (*writeAction, error) {
+						return &writeAction{
+							Message:     fmt.Sprintf("Create %s record %s -> %s?", record.Type, record.GetFQDN(), strings.Join(record.GetTarget(), ",")),
+							Reconfigure: cmd.Bool("reconfigure"),
+							Apply: func(ctx context.Context) error {
+								uuid, err := client.UnboundCreateHostOverride(ctx, record.IntoHostOverride())
+								if err != nil {
+									return err
+								}
+
+								fmt.Fprintf(cmd.Root().Writer, "Created host override: %s\n", uuid)
+
+								return nil
+							},
+						}, nil
+					})
+				},
+			},
+
+			{
+				Name:      "update",
+				Usage:     "Update a host override, only the given fields are changed.",
+				ArgsUsage: "<uuid>",
+				Flags:     append(append([]cli.Flag{}, recordFlags...), writeFlags...),
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					id := cmd.Args().First()
+
+					return runWrite(ctx, cmd, conf, func(ctx context.Context, client *opnsense.Client) (*writeAction, error) {
+						record, err := findRecord(ctx, client, id)
+						if err != nil {
+							return nil, err
+						}
+
+						before := *record
+						if err := applyRecordFlags(cmd, record); err != nil {
+							return nil, err
+						}
+
+						diff := diffRecords(&before, record)
+						if len(diff) == 0 {
+							return nil, fmt.Errorf("nothing to update for host override: %s", id)
+						}
+
+						return &writeAction{
+							Message:     fmt.Sprintf("Update host override %s?", id),
+							Diff:        diff,
+							Reconfigure: cmd.Bool("reconfigure"),
+							Apply: func(ctx context.Context) error {
+								if err := client.UnboundUpdateHostOverride(ctx, record.Id, record.IntoHostOverride()); err != nil {
+									return err
+								}
+
+								fmt.Fprintf(cmd.Root().Writer, "Updated host override: %s\n", record.Id)
+
+								return nil
+							},
+						}, nil
+					})
+				},
+			},
+
+			{
+				Name:      "delete",
+				Usage:     "Delete one or more host overrides.",
+				ArgsUsage: "<uuid> [uuid...]",
+				Flags:     writeFlags,
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					ids := cmd.Args().Slice()
+					if len(ids) == 0 {
+						return fmt.Errorf("at least one uuid is required")
+					}
+
+					return runWrite(ctx, cmd, conf, func(ctx context.Context, client *opnsense.Client) (*writeAction, error) {
+						records, err := fetchRecords(ctx, client)
+						if err != nil {
+							return nil, err
+						}
+
+						diff := make([]string, 0, len(ids))
+						for _, id := range ids {
+							record, err := selectRecord(records, id)
+							if err != nil {
+								return nil, err
+							}
+
+							diff = append(diff, "- "+describeRecord(record))
+						}
+
+						return &writeAction{
+							Message:     fmt.Sprintf("Delete host override(s) %s?", strings.Join(ids, ", ")),
+							Diff:        diff,
+							Reconfigure: cmd.Bool("reconfigure"),
+							Apply: func(ctx context.Context) error {
+								for _, id := range ids {
+									if err := client.UnboundDeleteHostOverride(ctx, id); err != nil {
+										return err
+									}
+
+									fmt.Fprintf(cmd.Root().Writer, "Deleted host override: %s\n", id)
+								}
+
+								return nil
+							},
+						}, nil
+					})
+				},
+			},
+
+			{
+				Name:      "toggle",
+				Usage:     "Enable or disable a host override.",
+				ArgsUsage: "<uuid>",
+				Flags:     writeFlags,
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					id := cmd.Args().First()
+
+					return runWrite(ctx, cmd, conf, func(ctx context.Context, client *opnsense.Client) (*writeAction, error) {
+						record, err := findRecord(ctx, client, id)
+						if err != nil {
+							return nil, err
+						}
+
+						verb := "Enable"
+						if record.IsEnabled() {
+							record.Enabled = "0"
+							verb = "Disable"
+						} else {
+							record.Enabled = "1"
+						}
+
+						return &writeAction{
+							Message:     fmt.Sprintf("%s host override %s (%s)?", verb, id, describeRecord(record)),
+							Reconfigure: cmd.Bool("reconfigure"),
+							Apply: func(ctx context.Context) error {
+								if err := client.UnboundUpdateHostOverride(ctx, record.Id, record.IntoHostOverride()); err != nil {
+									return err
+								}
+
+								fmt.Fprintf(cmd.Root().Writer, "Toggled host override: %s, enabled: %t\n", record.Id, record.IsEnabled())
+
+								return nil
+							},
+						}, nil
+					})
+				},
+			},
+
+			{
+				Name:  "reconfigure",
+				Usage: "Reconfigure the Unbound service to apply the pending changes.",
+				Flags: []cli.Flag{yesFlag},
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					return runWrite(ctx, cmd, conf, func(_ context.Context, client *opnsense.Client) (*writeAction, error) {
+						return &writeAction{
+							Message: "Reconfigure the Unbound service?",
+							Apply: func(ctx context.Context) error {
+								return reconfigure(ctx, cmd, client)
+							},
+						}, nil
+					})
+				},
+			},
+		},
+	}
+}
+
+// writeAction is a mutating action of the operator commands, which is described to the operator before it is applied.
+type writeAction struct {
+	// Message is the confirmation prompt of the action.
+	Message string
+	// Diff is shown before the confirmation prompt, like the fields of a record that are changed.
+	Diff []string
+	// Reconfigure reconfigures the Unbound service after the action is applied.
+	Reconfigure bool
+	Apply       func(ctx context.Context) error
+}
+
+// runWrite prepares a mutating action with the current records, and guards it with the dry-run flag and the confirmation prompt.
+func runWrite(ctx context.Context, cmd *cli.Command, conf *config.Config, prepare func(ctx context.Context, client *opnsense.Client) (*writeAction, error)) error {
+	_, client, err := setupClient(conf)
+	if err != nil {
+		return err
+	}
+
+	action, err := prepare(ctx, client)
+	if err != nil {
+		return err
+	}
+
+	for _, line := range action.Diff {
+		fmt.Fprintln(cmd.Root().Writer, line)
+	}
+
+	if conf.OpnsenseClient.DryRun {
+		fmt.Fprintf(cmd.Root().Writer, "Dry run enabled, skipping: %s\n", strings.TrimSuffix(action.Message, "?"))
+
+		return nil
+	}
+
+	ok, err := confirm(cmd, action.Message)
+	if err != nil {
+		return err
+	} else if !ok {
+		return fmt.Errorf("aborted by the operator")
+	}
+
+	if err := action.Apply(ctx); err != nil {
+		return err
+	}
+
+	if !action.Reconfigure {
+		return nil
+	}
+
+	return reconfigure(ctx, cmd, client)
+}
+
+func reconfigure(ctx context.Context, cmd *cli.Command, client *opnsense.Client) error {
+	if err := client.ReconfigureService(ctx); err != nil {
+		return fmt.Errorf("failed to reconfigure Unbound service: %w", err)
+	}
+
+	fmt.Fprintln(cmd.Root().Writer, "Unbound service reconfigured.")
+
+	return nil
+}
+
+func fetchRecords(ctx context.Context, client opnsense.ClientAdapter) ([]*provider.DnsRecord, error) {
+	result, err := client.UnboundSearchHostOverrides(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query for host overrides: %w", err)
+	}
+
+	records := make([]*provider.DnsRecord, 0, len(result.Rows))
+	for _, row := range result.Rows {
+		records = append(records, provider.NewDnsRecord(row))
+	}
+
+	return records, nil
+}
+
+func findRecord(ctx context.Context, client opnsense.ClientAdapter, id string) (*provider.DnsRecord, error) {
+	if id == "" {
+		return nil, fmt.Errorf("uuid is required")
+	}
+
+	records, err := fetchRecords(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+
+	return selectRecord(records, id)
+}
+
+func selectRecord(records []*provider.DnsRecord, id string) (*provider.DnsRecord, error) {
+	for _, record := range records {
+		if record.Id == id {
+			return record, nil
+		}
+	}
+
+	return nil, fmt.Errorf("host override not found: %s", id)
+}
+
+func describeRecord(record *provider.DnsRecord) string {
+	return fmt.Sprintf("%s %s %s", record.GetFQDN(), record.Type, strings.Join(record.GetTarget(), ","))
+}
+
+// diffRecords returns the fields that differ between the records, as they are before and after the change.
+func diffRecords(before *provider.DnsRecord, after *provider.DnsRecord) []string {
+	fields := []struct {
+		name   string
+		before string
+		after  string
+	}{
+		{"type", before.Type, after.Type},
+		{"hostname", before.Hostname, after.Hostname},
+		{"domain", before.Domain, after.Domain},
+		{"target", strings.Join(before.GetTarget(), ","), strings.Join(after.GetTarget(), ",")},
+		{"description", before.Description, after.Description},
+	}
+
+	diff := []string{}
+	for _, field := range fields {
+		if field.before != field.after {
+			diff = append(diff, fmt.Sprintf("~ %s: %q -> %q", field.name, field.before, field.after))
+		}
+	}
+
+	return diff
+}
+
+// applyRecordFlags overrides the fields of the record with the flags that are set by the operator.
+// The target of the previous type is cleared when the type changes, so that it has to be given again for the new type.
+func applyRecordFlags(cmd *cli.Command, record *provider.DnsRecord) error {
+	if cmd.IsSet("type") || record.Type == "" {
+		if t := strings.ToUpper(cmd.String("type")); t != record.Type {
+			record.Type = t
+			record.Server = ""
+			record.TxtData = ""
+			record.MXPriority = ""
+			record.MXDomain = ""
+		}
+	}
+
+	switch record.Type {
+	case endpoint.RecordTypeA, endpoint.RecordTypeAAAA, endpoint.RecordTypeTXT:
+	default:
+		return fmt.Errorf("unsupported record type: %s", record.Type)
+	}
+
+	if cmd.IsSet("hostname") {
+		record.Hostname = cmd.String("hostname")
+	}
+
+	if cmd.IsSet("domain") {
+		record.Domain = cmd.String("domain")
+	}
+
+	if cmd.IsSet("description") {
+		record.Description = cmd.String("description")
+	}
+
+	if cmd.IsSet("target") {
+		switch record.Type {
+		case endpoint.RecordTypeTXT:
+			record.TxtData = cmd.String("target")
+		default:
+			record.Server = cmd.String("target")
+		}
+	}
+
+	return validateRecordTarget(record)
+}
+
+// validateRecordTarget checks that the record has a target that is valid for its type.
+func validateRecordTarget(record *provider.DnsRecord) error {
+	target := strings.Join(record.GetTarget(), "")
+	if target == "" {
+		return fmt.Errorf("target is required for the %s record", record.Type)
+	}
+
+	switch record.Type {
+	case endpoint.RecordTypeA:
+		if addr, err := netip.ParseAddr(target); err != nil || !addr.Is4() {
+			return fmt.Errorf("invalid target for the A record, expected an IPv4 address: %s", target)
+		}
+	case endpoint.RecordTypeAAAA:
+		if addr, err := netip.ParseAddr(target); err != nil || !addr.Is6() || addr.Is4In6() {
+			return fmt.Errorf("invalid target for the AAAA record, expected an IPv6 address: %s", target)
+		}
+	}
+
+	return nil
+}
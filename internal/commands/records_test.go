@@ -0,0 +1,177 @@
+package commands_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/commands"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/config"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/opnsense"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/provider"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/urfave/cli/v3"
+)
+
+var _ = Describe("records", func() {
+	Context("filter", func() {
+		record := provider.NewDnsRecord(opnsense.UnboundSearchHostOverrideItem{
+			Id:          "id",
+			Enabled:     "1",
+			Hostname:    "app",
+			Domain:      "example.com",
+			Type:        "A",
+			Server:      "10.0.0.1",
+			Description: "Managed by Operators",
+		})
+
+		DescribeTable("should be able to match the records", func(filter commands.RecordsFilter, expected bool) {
+			Expect(filter.Match(record)).To(Equal(expected))
+		},
+			Entry("empty filter", commands.RecordsFilter{}, true),
+			Entry("exact domain", commands.RecordsFilter{Domain: "app.example.com"}, true),
+			Entry("parent domain", commands.RecordsFilter{Domain: "example.com."}, true),
+			Entry("partial domain", commands.RecordsFilter{Domain: "ample.com"}, false),
+			Entry("other domain", commands.RecordsFilter{Domain: "example.org"}, false),
+			Entry("type", commands.RecordsFilter{Type: "a"}, true),
+			Entry("other type", commands.RecordsFilter{Type: "TXT"}, false),
+			Entry("description", commands.RecordsFilter{Description: "operators"}, true),
+			Entry("other description", commands.RecordsFilter{Description: "manual"}, false),
+			Entry("combined", commands.RecordsFilter{Domain: "example.com", Type: "A", Description: "managed"}, true),
+		)
+	})
+
+	Context("write", func() {
+		var (
+			requests []string
+			bodies   map[string]string
+			out      *bytes.Buffer
+		)
+
+		rows := []opnsense.UnboundSearchHostOverrideItem{
+			{Id: "id-1", Enabled: "1", Hostname: "app", Domain: "example.com", Type: "A", Server: "10.0.0.1", Description: "app"},
+		}
+
+		BeforeEach(func() {
+			requests = []string{}
+			bodies = map[string]string{}
+			out = &bytes.Buffer{}
+		})
+
+		// run runs the records command against a fake OPNsense, answering the confirmation prompt with the input
+		run := func(ctx SpecContext, input string, args ...string) error {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				request := r.Method + " " + strings.TrimPrefix(r.URL.Path, "/api/unbound")
+				requests = append(requests, request)
+				bodies[request] = string(body)
+
+				switch {
+				case strings.HasSuffix(r.URL.Path, "/search_host_override"):
+					_ = json.NewEncoder(w).Encode(opnsense.UnboundSearchHostOverrideResponse{Rows: rows, Total: len(rows)})
+				case strings.Contains(r.URL.Path, "/addHostOverride"), strings.Contains(r.URL.Path, "/setHostOverride/"):
+					_, _ = w.Write([]byte(`{"result":"saved","uuid":"id-2"}`))
+				case strings.Contains(r.URL.Path, "/delHostOverride/"):
+					_, _ = w.Write([]byte(`{"result":"deleted"}`))
+				case strings.HasSuffix(r.URL.Path, "/reconfigure"):
+					_, _ = w.Write([]byte(`{"status":"ok"}`))
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			DeferCleanup(server.Close)
+
+			conf := config.NewConfig()
+			cmd := &cli.Command{
+				Name:     "app",
+				Flags:    config.BindFlags(conf),
+				Commands: []*cli.Command{commands.NewRecordsCommand(conf)},
+				Reader:   strings.NewReader(input),
+				Writer:   out,
+			}
+
+			return cmd.Run(ctx, append([]string{"app", "--opnsense-url", server.URL, "--opnsense-api-key", "key", "--opnsense-api-secret", "secret"}, args...))
+		}
+
+		It("should not create the record when it is not confirmed", func(ctx SpecContext) {
+			err := run(ctx, "n\n", "records", "create", "--hostname", "new", "--domain", "example.com", "--target", "10.0.0.2")
+			Expect(err).To(MatchError("aborted by the operator"))
+			Expect(out.String()).To(ContainSubstring("Create A record new.example.com -> 10.0.0.2? [y/N]"))
+			Expect(requests).To(BeEmpty())
+		})
+
+		It("should create the record and reconfigure the service when it is confirmed", func(ctx SpecContext) {
+			Expect(run(ctx, "y\n", "records", "create", "--hostname", "new", "--domain", "example.com", "--target", "10.0.0.2")).To(Succeed())
+			Expect(requests).To(Equal([]string{"POST /settings/addHostOverride", "POST /service/reconfigure"}))
+			Expect(bodies["POST /settings/addHostOverride"]).To(ContainSubstring(`"hostname":"new"`))
+			Expect(out.String()).To(ContainSubstring("Created host override: id-2"))
+		})
+
+		It("should not reconfigure the service when it is disabled", func(ctx SpecContext) {
+			Expect(run(ctx, "", "records", "create", "--yes", "--reconfigure=false", "--hostname", "new", "--domain", "example.com", "--target", "10.0.0.2")).To(Succeed())
+			Expect(requests).To(Equal([]string{"POST /settings/addHostOverride"}))
+		})
+
+		It("should show the changed fields before the confirmation of an update", func(ctx SpecContext) {
+			Expect(run(ctx, "y\n", "records", "update", "id-1", "--target", "10.0.0.2")).To(Succeed())
+			Expect(out.String()).To(ContainSubstring("~ target: \"10.0.0.1\" -> \"10.0.0.2\"\nUpdate host override id-1? [y/N]"))
+			Expect(out.String()).ToNot(ContainSubstring("~ hostname"))
+			Expect(requests).To(Equal([]string{"POST /settings/search_host_override", "POST /settings/setHostOverride/id-1", "POST /service/reconfigure"}))
+			Expect(bodies["POST /settings/setHostOverride/id-1"]).To(ContainSubstring(`"server":"10.0.0.2"`))
+			Expect(bodies["POST /settings/setHostOverride/id-1"]).To(ContainSubstring(`"description":"app"`))
+		})
+
+		It("should fail the update before the confirmation when the record does not exist or nothing changes", func(ctx SpecContext) {
+			Expect(run(ctx, "", "records", "update", "id-9", "--target", "10.0.0.2")).To(MatchError(ContainSubstring("host override not found: id-9")))
+			Expect(run(ctx, "", "records", "update", "id-1", "--target", "10.0.0.1")).To(MatchError(ContainSubstring("nothing to update")))
+			Expect(out.String()).ToNot(ContainSubstring("[y/N]"))
+		})
+
+		It("should require a target that is valid for the type of the created record", func(ctx SpecContext) {
+			Expect(run(ctx, "", "records", "create", "--yes", "--hostname", "new", "--domain", "example.com")).To(MatchError("target is required for the A record"))
+			Expect(run(ctx, "", "records", "create", "--yes", "--hostname", "new", "--domain", "example.com", "--type", "TXT")).To(MatchError("target is required for the TXT record"))
+			Expect(run(ctx, "", "records", "create", "--yes", "--hostname", "new", "--domain", "example.com", "--type", "AAAA", "--target", "10.0.0.2")).To(MatchError(ContainSubstring("invalid target for the AAAA record")))
+			Expect(requests).To(BeEmpty())
+		})
+
+		It("should require a new target when the type of the updated record changes", func(ctx SpecContext) {
+			Expect(run(ctx, "", "records", "update", "--yes", "id-1", "--type", "TXT")).To(MatchError("target is required for the TXT record"))
+			Expect(out.String()).ToNot(ContainSubstring("[y/N]"))
+
+			Expect(run(ctx, "", "records", "update", "--yes", "id-1", "--type", "TXT", "--target", "hello")).To(Succeed())
+			Expect(out.String()).To(ContainSubstring("~ type: \"A\" -> \"TXT\"\n~ target: \"10.0.0.1\" -> \"hello\""))
+			Expect(bodies["POST /settings/setHostOverride/id-1"]).To(ContainSubstring(`"server":""`))
+			Expect(bodies["POST /settings/setHostOverride/id-1"]).To(ContainSubstring(`"txtdata":"hello"`))
+		})
+
+		It("should delete the records without a prompt with yes", func(ctx SpecContext) {
+			Expect(run(ctx, "", "records", "delete", "--yes", "id-1")).To(Succeed())
+			Expect(out.String()).To(ContainSubstring("- app.example.com A 10.0.0.1"))
+			Expect(out.String()).ToNot(ContainSubstring("[y/N]"))
+			Expect(requests).To(Equal([]string{"POST /settings/search_host_override", "POST /settings/delHostOverride/id-1", "POST /service/reconfigure"}))
+		})
+
+		It("should toggle the record", func(ctx SpecContext) {
+			Expect(run(ctx, "y\n", "records", "toggle", "id-1")).To(Succeed())
+			Expect(out.String()).To(ContainSubstring("Disable host override id-1 (app.example.com A 10.0.0.1)? [y/N]"))
+			Expect(bodies["POST /settings/setHostOverride/id-1"]).To(ContainSubstring(`"enabled":"0"`))
+			Expect(out.String()).To(ContainSubstring("Toggled host override: id-1, enabled: false"))
+		})
+
+		It("should only show the changes with dry run", func(ctx SpecContext) {
+			Expect(run(ctx, "", "--dry-run", "records", "delete", "id-1")).To(Succeed())
+			Expect(out.String()).To(ContainSubstring("- app.example.com A 10.0.0.1"))
+			Expect(out.String()).To(ContainSubstring("Dry run enabled, skipping: Delete host override(s) id-1"))
+			Expect(requests).To(Equal([]string{"POST /settings/search_host_override"}))
+		})
+
+		It("should reconfigure the service once", func(ctx SpecContext) {
+			Expect(run(ctx, "", "records", "reconfigure", "--yes")).To(Succeed())
+			Expect(requests).To(Equal([]string{"POST /service/reconfigure"}))
+		})
+	})
+})
@@ -1,26 +1,100 @@
 package config
 
 import (
+	"errors"
+	"fmt"
+	"time"
+
 	"github.com/cenk1cenk2/external-dns-webhook-opnsense/api"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/api/admin"
 	"github.com/cenk1cenk2/external-dns-webhook-opnsense/api/probes"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/health"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/notifier"
 	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/opnsense"
 	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/provider"
 )
 
 type Config struct {
-	LogLevel   string
-	LogEncoder string
+	ConfigFile          string
+	ConfigWatchInterval time.Duration `validate:"gte=0"`
+
+	LogLevel   string `validate:"oneof=debug info warn warning error dpanic panic fatal"`
+	LogEncoder string `validate:"oneof=console json"`
+
+	Port       uint16 `validate:"required"`
+	HealthPort uint16 `validate:"required,nefield=Port"`
+	AdminPort  uint16 `validate:"omitempty,nefield=Port,nefield=HealthPort"`
 
-	Port       uint16
-	HealthPort uint16
+	ListenAddress       string
+	HealthListenAddress string `validate:"omitempty,nefield=ListenAddress"`
+	AdminListenAddress  string `validate:"omitempty,nefield=ListenAddress,nefield=HealthListenAddress"`
 
 	Api    api.ApiConfig
 	Probes probes.ApiConfig
+	Admin  admin.ApiConfig
+	Health health.CheckerConfig
+
+	Tracing services.TracingConfig
+	Audit   services.AuditConfig
+
+	Notifier notifier.NotifierConfig
 
 	OpnsenseClient opnsense.ClientConfig
+	CircuitBreaker opnsense.BreakerConfig
 	Provider       provider.ProviderConfig
 }
 
 func NewConfig() *Config {
 	return &Config{}
 }
+
+// Validate checks the configuration with the tags of the fields and the checks that can not be expressed with them,
+// so that the startup, the configuration reload and the validate command accept the same configurations.
+func (c *Config) Validate(validator *services.Validator) error {
+	if err := validator.Validate(c); err != nil {
+		return err
+	}
+
+	if err := c.Provider.Validate(); err != nil {
+		return err
+	}
+
+	// the admin server can change the state of the service, so it is never served without authentication
+	if c.GetAdminListenAddress() != "" && c.Admin.AuthToken == "" {
+		return errors.New("admin server requires the admin auth token")
+	}
+
+	return nil
+}
+
+// GetListenAddress returns the address for the webhook server, falling back to all interfaces on the port.
+func (c *Config) GetListenAddress() string {
+	if c.ListenAddress != "" {
+		return c.ListenAddress
+	}
+
+	return fmt.Sprintf(":%d", c.Port)
+}
+
+// GetHealthListenAddress returns the address for the health server, falling back to all interfaces on the health port.
+func (c *Config) GetHealthListenAddress() string {
+	if c.HealthListenAddress != "" {
+		return c.HealthListenAddress
+	}
+
+	return fmt.Sprintf(":%d", c.HealthPort)
+}
+
+// GetAdminListenAddress returns the address for the admin server, which is empty when the admin server is disabled.
+func (c *Config) GetAdminListenAddress() string {
+	if c.AdminListenAddress != "" {
+		return c.AdminListenAddress
+	}
+
+	if c.AdminPort == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf(":%d", c.AdminPort)
+}
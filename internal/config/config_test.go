@@ -0,0 +1,34 @@
+package config_test
+
+import (
+	"slices"
+
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/config"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Config Validate", func() {
+	args := []string{"test", "--opnsense-url", "https://opnsense.invalid", "--opnsense-api-key", "key", "--opnsense-api-secret", "secret"}
+
+	DescribeTable("should validate the configuration as it is validated at startup", func(ctx SpecContext, extra []string, message string) {
+		conf, _, err := config.Load(ctx, slices.Concat(args, extra))
+		Expect(err).ToNot(HaveOccurred())
+
+		err = conf.Validate(services.NewValidator())
+		if message == "" {
+			Expect(err).ToNot(HaveOccurred())
+
+			return
+		}
+
+		Expect(err).To(MatchError(ContainSubstring(message)))
+	},
+		Entry("valid", []string{}, ""),
+		Entry("admin server without the auth token", []string{"--admin-port", "8081"}, "admin server requires the admin auth token"),
+		Entry("invalid name pattern", []string{"--protected-names", "[router"}, "invalid name pattern"),
+		Entry("approval domains without the directory", []string{"--approval-domains", "example.com"}, "approval directory is required"),
+		Entry("invalid change freeze window", []string{"--change-freeze-windows", "0 18 * * FRI"}, "invalid change freeze window"),
+	)
+})
@@ -0,0 +1,135 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	altsrc "github.com/urfave/cli-altsrc/v3"
+	altsrctoml "github.com/urfave/cli-altsrc/v3/toml"
+	altsrcyaml "github.com/urfave/cli-altsrc/v3/yaml"
+	"github.com/urfave/cli/v3"
+	"gopkg.in/yaml.v3"
+)
+
+type FileFormat string
+
+const (
+	FileFormatYaml FileFormat = "yaml"
+	FileFormatToml FileFormat = "toml"
+)
+
+// FileValueSource looks up the value of a flag from the configuration file, keyed with the flag name.
+type FileValueSource struct {
+	key  string
+	path *string
+}
+
+var _ cli.ValueSource = (*FileValueSource)(nil)
+
+// NewFileValueSource creates a value source that reads the given key from the configuration file in the path,
+// the path is resolved lazily so that it can be populated by a flag itself.
+func NewFileValueSource(key string, path *string) *FileValueSource {
+	return &FileValueSource{
+		key:  key,
+		path: path,
+	}
+}
+
+func (s *FileValueSource) Lookup() (string, bool) {
+	if *s.path == "" {
+		return "", false
+	}
+
+	format, err := GetFileFormat(*s.path)
+	if err != nil {
+		return "", false
+	}
+
+	switch format {
+	case FileFormatToml:
+		return altsrctoml.TOML(s.key, altsrc.NewStringPtrSourcer(s.path)).Lookup()
+	default:
+		return altsrcyaml.YAML(s.key, altsrc.NewStringPtrSourcer(s.path)).Lookup()
+	}
+}
+
+func (s *FileValueSource) String() string {
+	return fmt.Sprintf("config file %q at key %q", *s.path, s.key)
+}
+
+func (s *FileValueSource) GoString() string {
+	return fmt.Sprintf("FileValueSource{file:%q,key:%q}", *s.path, s.key)
+}
+
+// GetFileFormat determines the format of the configuration file from its extension.
+func GetFileFormat(path string) (FileFormat, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return FileFormatYaml, nil
+	case ".toml":
+		return FileFormatToml, nil
+	}
+
+	return "", fmt.Errorf("unsupported configuration file format, must be one of yaml or toml: %s", path)
+}
+
+// ReadFile reads and parses the configuration file.
+func ReadFile(path string) (map[string]any, error) {
+	format, err := GetFileFormat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read configuration file: %w", err)
+	}
+
+	values := map[string]any{}
+
+	switch format {
+	case FileFormatToml:
+		err = toml.Unmarshal(data, &values)
+	default:
+		err = yaml.Unmarshal(data, &values)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse configuration file %s: %w", path, err)
+	}
+
+	return values, nil
+}
+
+// ValidateFile checks that the configuration file can be parsed and only contains keys for the known flags,
+// since the value sources silently skip the unreadable files and the unknown keys.
+func ValidateFile(path string, flags []cli.Flag) error {
+	values, err := ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	names := []string{}
+	for _, flag := range flags {
+		names = append(names, flag.Names()...)
+	}
+
+	unknown := []string{}
+	for key := range values {
+		if !slices.Contains(names, key) || slices.Contains(ignoredFlags, key) {
+			unknown = append(unknown, key)
+		}
+	}
+
+	if len(unknown) > 0 {
+		slices.Sort(unknown)
+
+		return fmt.Errorf("unknown keys in configuration file %s: %s", path, strings.Join(unknown, ", "))
+	}
+
+	return nil
+}
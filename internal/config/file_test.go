@@ -0,0 +1,83 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/config"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Config File", func() {
+	write := func(name string, content string) string {
+		path := filepath.Join(GinkgoT().TempDir(), name)
+		Expect(os.WriteFile(path, []byte(content), 0o600)).To(Succeed())
+
+		return path
+	}
+
+	DescribeTable("should be able to lookup values from the configuration file", func(name string, content string) {
+		path := write(name, content)
+
+		value, ok := config.NewFileValueSource("log-level", &path).Lookup()
+		Expect(ok).To(BeTrue())
+		Expect(value).To(Equal("debug"))
+
+		value, ok = config.NewFileValueSource("domain-filter", &path).Lookup()
+		Expect(ok).To(BeTrue())
+		Expect(value).To(Equal("example.com,example.org"))
+
+		_, ok = config.NewFileValueSource("port", &path).Lookup()
+		Expect(ok).To(BeFalse())
+	},
+		Entry("yaml", "config.yaml", "log-level: debug\ndomain-filter:\n  - example.com\n  - example.org\n"),
+		Entry("toml", "config.toml", "log-level = \"debug\"\ndomain-filter = [\"example.com\", \"example.org\"]\n"),
+	)
+
+	It("should not lookup values when there is no configuration file", func() {
+		path := ""
+
+		_, ok := config.NewFileValueSource("log-level", &path).Lookup()
+		Expect(ok).To(BeFalse())
+	})
+
+	It("should validate the configuration file against the known flags", func() {
+		conf := config.NewConfig()
+		flags := config.BindFlags(conf)
+
+		Expect(config.ValidateFile(write("config.yaml", "log-level: debug\n"), flags)).To(Succeed())
+		Expect(config.ValidateFile(write("config.yaml", "log-levl: debug\n"), flags)).To(MatchError(ContainSubstring("log-levl")))
+		Expect(config.ValidateFile(write("config.yaml", "config: other.yaml\n"), flags)).To(MatchError(ContainSubstring("config")))
+		Expect(config.ValidateFile(write("config.yaml", "log-level: [\n"), flags)).To(HaveOccurred())
+		Expect(config.ValidateFile(write("config.json", "{}"), flags)).To(HaveOccurred())
+	})
+
+	It("should redact the sensitive values", func() {
+		conf := config.NewConfig()
+		flags := config.BindFlags(conf)
+		for _, flag := range flags {
+			Expect(flag.PreParse()).To(Succeed())
+		}
+
+		for _, name := range []string{"opnsense-api-key", "log-level", "notify-receivers", "domain-filter"} {
+			for _, flag := range flags {
+				if flag.Names()[0] == name {
+					Expect(flag.Set(name, "value")).To(Succeed())
+				}
+			}
+		}
+
+		values := config.Values(flags, true)
+		Expect(values).ToNot(HaveKey("config"))
+		Expect(values).To(HaveKeyWithValue("opnsense-api-key", config.RedactedValue))
+		Expect(values).To(HaveKeyWithValue("opnsense-api-secret", ""))
+		Expect(values).To(HaveKeyWithValue("log-level", "value"))
+		Expect(values).To(HaveKeyWithValue("opnsense-max-backoff", "30s"))
+		Expect(values).To(HaveKeyWithValue("notify-receivers", []string{config.RedactedValue}))
+		Expect(values).To(HaveKeyWithValue("domain-filter", []string{"value"}))
+
+		Expect(config.Values(flags, false)).To(HaveKeyWithValue("opnsense-api-key", "value"))
+		Expect(config.Values(flags, false)).To(HaveKeyWithValue("notify-receivers", []string{"value"}))
+	})
+})
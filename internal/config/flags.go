@@ -10,11 +10,34 @@ import (
 
 func BindFlags(c *Config) []cli.Flag {
 	return []cli.Flag{
+		// needs to be the first flag, so that it is resolved before the others that depend on it.
+		&cli.StringFlag{
+			Name:  "config",
+			Usage: "Path to the configuration file in yaml or toml format, keyed with the flag names.",
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("CONFIG_FILE"),
+			),
+			Required:    false,
+			Destination: &c.ConfigFile,
+		},
+
+		&cli.DurationFlag{
+			Name:  "config-watch-interval",
+			Usage: "Interval to check the configuration file for changes to reload it, disabled when zero.",
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("CONFIG_WATCH_INTERVAL"),
+			),
+			Required:    false,
+			Value:       0,
+			Destination: &c.ConfigWatchInterval,
+		},
+
 		&cli.StringFlag{
 			Name:  "log-level",
 			Usage: `Log level for the application. enum("debug", "info", "warning", "error", "fatal")`,
 			Sources: cli.NewValueSourceChain(
 				cli.EnvVar("LOG_LEVEL"),
+				NewFileValueSource("log-level", &c.ConfigFile),
 			),
 			Required:    false,
 			Value:       "info",
@@ -26,6 +49,7 @@ func BindFlags(c *Config) []cli.Flag {
 			Usage: `Log encoder format. enum("console", "json")`,
 			Sources: cli.NewValueSourceChain(
 				cli.EnvVar("LOG_ENCODER"),
+				NewFileValueSource("log-encoder", &c.ConfigFile),
 			),
 			Required:    false,
 			Value:       "json",
@@ -37,6 +61,7 @@ func BindFlags(c *Config) []cli.Flag {
 			Usage: "Port on which the server will listen.",
 			Sources: cli.NewValueSourceChain(
 				cli.EnvVar("PORT"),
+				NewFileValueSource("port", &c.ConfigFile),
 			),
 			Required:    false,
 			Value:       8888,
@@ -48,28 +73,353 @@ func BindFlags(c *Config) []cli.Flag {
 			Usage: "Port on which the health check server will listen.",
 			Sources: cli.NewValueSourceChain(
 				cli.EnvVar("HEALTH_PORT"),
+				NewFileValueSource("health-port", &c.ConfigFile),
 			),
 			Required:    false,
 			Value:       8080,
 			Destination: &c.HealthPort,
 		},
 
+		&cli.StringFlag{
+			Name:  "listen-address",
+			Usage: "Address on which the server will listen, either host:port or unix:///path.sock?mode=0660 for a Unix domain socket, takes precedence over the port.",
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("LISTEN_ADDRESS"),
+				NewFileValueSource("listen-address", &c.ConfigFile),
+			),
+			Required:    false,
+			Destination: &c.ListenAddress,
+		},
+
+		&cli.StringFlag{
+			Name:  "health-listen-address",
+			Usage: "Address on which the health check server will listen, either host:port or unix:///path.sock?mode=0660 for a Unix domain socket, takes precedence over the health port.",
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("HEALTH_LISTEN_ADDRESS"),
+				NewFileValueSource("health-listen-address", &c.ConfigFile),
+			),
+			Required:    false,
+			Destination: &c.HealthListenAddress,
+		},
+
+		&cli.Uint16Flag{
+			Name:  "admin-port",
+			Usage: "Port on which the admin server will listen, disabled when zero.",
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("ADMIN_PORT"),
+				NewFileValueSource("admin-port", &c.ConfigFile),
+			),
+			Required:    false,
+			Value:       0,
+			Destination: &c.AdminPort,
+		},
+
+		&cli.StringFlag{
+			Name:  "admin-listen-address",
+			Usage: "Address on which the admin server will listen, either host:port or unix:///path.sock?mode=0660 for a Unix domain socket, takes precedence over the admin port.",
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("ADMIN_LISTEN_ADDRESS"),
+				NewFileValueSource("admin-listen-address", &c.ConfigFile),
+			),
+			Required:    false,
+			Destination: &c.AdminListenAddress,
+		},
+
+		&cli.StringFlag{
+			Name:  "admin-auth-token",
+			Usage: "Bearer token that the requests to the admin server must present in the authorization header, required with the admin server.",
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("ADMIN_AUTH_TOKEN"),
+				NewFileValueSource("admin-auth-token", &c.ConfigFile),
+			),
+			Required:    false,
+			Destination: &c.Admin.AuthToken,
+		},
+
+		&cli.DurationFlag{
+			Name:  "health-check-interval",
+			Usage: "Interval to check the components in the background, the readiness probe is answered from the last results.",
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("HEALTH_CHECK_INTERVAL"),
+				NewFileValueSource("health-check-interval", &c.ConfigFile),
+			),
+			Required:    false,
+			Value:       30 * time.Second,
+			Destination: &c.Health.Interval,
+		},
+
+		&cli.DurationFlag{
+			Name:  "health-check-timeout",
+			Usage: "Timeout of checking the components in the background.",
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("HEALTH_CHECK_TIMEOUT"),
+				NewFileValueSource("health-check-timeout", &c.ConfigFile),
+			),
+			Required:    false,
+			Value:       10 * time.Second,
+			Destination: &c.Health.Timeout,
+		},
+
+		&cli.StringFlag{
+			Name:  "tracing-endpoint",
+			Usage: "OTLP HTTP endpoint to export the traces to like http://otel-collector:4318, tracing is disabled when not set.",
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("TRACING_ENDPOINT"),
+				NewFileValueSource("tracing-endpoint", &c.ConfigFile),
+			),
+			Required:    false,
+			Destination: &c.Tracing.Endpoint,
+		},
+
+		&cli.FloatFlag{
+			Name:  "tracing-sample-ratio",
+			Usage: "Ratio of the traces to sample between 0 and 1, the sampling decision of the incoming trace context is respected.",
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("TRACING_SAMPLE_RATIO"),
+				NewFileValueSource("tracing-sample-ratio", &c.ConfigFile),
+			),
+			Required:    false,
+			Value:       1,
+			Destination: &c.Tracing.SampleRatio,
+		},
+
+		&cli.StringFlag{
+			Name:  "audit-sink",
+			Usage: `Sink to write the audit events of the DNS changes as JSON lines. enum("none", "stdout", "file", "syslog")`,
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("AUDIT_SINK"),
+				NewFileValueSource("audit-sink", &c.ConfigFile),
+			),
+			Required:    false,
+			Value:       "none",
+			Destination: &c.Audit.Sink,
+		},
+
+		&cli.StringFlag{
+			Name:  "audit-file",
+			Usage: "Path of the file to append the audit events to, when the audit sink is file.",
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("AUDIT_FILE"),
+				NewFileValueSource("audit-file", &c.ConfigFile),
+			),
+			Required:    false,
+			Destination: &c.Audit.File,
+		},
+
+		&cli.StringFlag{
+			Name:  "audit-syslog-address",
+			Usage: "Address of the remote syslog server in the form of udp://host:514 or tcp://host:514, the local syslog daemon is used when not set.",
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("AUDIT_SYSLOG_ADDRESS"),
+				NewFileValueSource("audit-syslog-address", &c.ConfigFile),
+			),
+			Required:    false,
+			Destination: &c.Audit.SyslogAddress,
+		},
+
+		&cli.StringFlag{
+			Name:  "audit-syslog-tag",
+			Usage: "Tag of the audit events that are sent to syslog.",
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("AUDIT_SYSLOG_TAG"),
+				NewFileValueSource("audit-syslog-tag", &c.ConfigFile),
+			),
+			Required:    false,
+			Value:       "external-dns-webhook-opnsense",
+			Destination: &c.Audit.SyslogTag,
+		},
+
 		&cli.BoolFlag{
 			Name:  "dry-run",
-			Usage: "The application will not make any changes to the OPNsense DNS records, only log the intended actions.",
+			Usage: "The batches of changes are discarded without being applied like with --read-only-mode discard, while the intended changes are logged, audited and notified as a dry run.",
 			Sources: cli.NewValueSourceChain(
 				cli.EnvVar("DRY_RUN"),
+				NewFileValueSource("dry-run", &c.ConfigFile),
 			),
 			Required:    false,
 			Value:       false,
 			Destination: &c.OpnsenseClient.DryRun,
 		},
 
+		&cli.BoolFlag{
+			Name:  "fail-fast",
+			Usage: "Exit with an error when the startup checks fail, instead of retrying them while the service is not ready.",
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("FAIL_FAST"),
+				NewFileValueSource("fail-fast", &c.ConfigFile),
+			),
+			Required:    false,
+			Value:       false,
+			Destination: &c.Provider.Startup.FailFast,
+		},
+
+		&cli.BoolFlag{
+			Name:  "startup-check-privileges",
+			Usage: "Check whether the credentials are allowed to change the host overrides and to reconfigure the Unbound service during the startup checks, in addition to fetching the records.",
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("STARTUP_CHECK_PRIVILEGES"),
+				NewFileValueSource("startup-check-privileges", &c.ConfigFile),
+			),
+			Required:    false,
+			Value:       false,
+			Destination: &c.Provider.Startup.CheckPrivileges,
+		},
+
+		&cli.DurationFlag{
+			Name:  "startup-retry-interval",
+			Usage: "Interval to retry the failed startup checks.",
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("STARTUP_RETRY_INTERVAL"),
+				NewFileValueSource("startup-retry-interval", &c.ConfigFile),
+			),
+			Required:    false,
+			Value:       10 * time.Second,
+			Destination: &c.Provider.Startup.RetryInterval,
+		},
+
+		&cli.DurationFlag{
+			Name:  "drift-check-interval",
+			Usage: "Interval to compare the records on OPNsense with the desired state that is last received from external-dns, disabled when zero.",
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("DRIFT_CHECK_INTERVAL"),
+				NewFileValueSource("drift-check-interval", &c.ConfigFile),
+			),
+			Required:    false,
+			Value:       0,
+			Destination: &c.Provider.Drift.Interval,
+		},
+
+		&cli.BoolFlag{
+			Name:  "drift-self-heal",
+			Usage: "Bring the missing and modified records back to the desired state when drift is detected, without waiting for external-dns.",
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("DRIFT_SELF_HEAL"),
+				NewFileValueSource("drift-self-heal", &c.ConfigFile),
+			),
+			Required:    false,
+			Value:       false,
+			Destination: &c.Provider.Drift.SelfHeal,
+		},
+
+		&cli.StringFlag{
+			Name:  "tls-cert-file",
+			Usage: "Path to the PEM encoded certificate to serve the webhook over TLS, plain HTTP is served when not set.",
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("TLS_CERT_FILE"),
+				NewFileValueSource("tls-cert-file", &c.ConfigFile),
+			),
+			Required:    false,
+			Destination: &c.Api.TLSCertFile,
+		},
+
+		&cli.StringFlag{
+			Name:  "tls-key-file",
+			Usage: "Path to the PEM encoded key of the webhook certificate.",
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("TLS_KEY_FILE"),
+				NewFileValueSource("tls-key-file", &c.ConfigFile),
+			),
+			Required:    false,
+			Destination: &c.Api.TLSKeyFile,
+		},
+
+		&cli.StringFlag{
+			Name:  "tls-client-ca-file",
+			Usage: "Path to the PEM encoded CA bundle to verify the client certificates against, requires client certificates when set.",
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("TLS_CLIENT_CA_FILE"),
+				NewFileValueSource("tls-client-ca-file", &c.ConfigFile),
+			),
+			Required:    false,
+			Destination: &c.Api.TLSClientCAFile,
+		},
+
+		&cli.StringFlag{
+			Name:  "tls-min-version",
+			Usage: `Minimum TLS version for the webhook server. enum("1.0", "1.1", "1.2", "1.3")`,
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("TLS_MIN_VERSION"),
+				NewFileValueSource("tls-min-version", &c.ConfigFile),
+			),
+			Required:    false,
+			Value:       "1.2",
+			Destination: &c.Api.TLSMinVersion,
+		},
+
+		&cli.DurationFlag{
+			Name:  "tls-watch-interval",
+			Usage: "Interval to check the certificate files of the webhook server for changes, disabled when zero.",
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("TLS_WATCH_INTERVAL"),
+				NewFileValueSource("tls-watch-interval", &c.ConfigFile),
+			),
+			Required:    false,
+			Value:       30 * time.Second,
+			Destination: &c.Api.TLSWatchInterval,
+		},
+
+		&cli.StringFlag{
+			Name:  "auth-token",
+			Usage: "Bearer token that the requests to the webhook must present in the authorization header, disabled when not set.",
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("AUTH_TOKEN"),
+				NewFileValueSource("auth-token", &c.ConfigFile),
+			),
+			Required:    false,
+			Destination: &c.Api.AuthToken,
+		},
+
+		&cli.StringSliceFlag{
+			Name:  "cors-allow-origins",
+			Usage: "Origins that are allowed to access the webhook.",
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("CORS_ALLOW_ORIGINS"),
+				NewFileValueSource("cors-allow-origins", &c.ConfigFile),
+			),
+			Required:    false,
+			Value:       []string{"*"},
+			Destination: &c.Api.CORSAllowOrigins,
+		},
+
+		&cli.StringSliceFlag{
+			Name:  "cors-allow-methods",
+			Usage: "Methods that are allowed to access the webhook, defaults to the common methods when not set.",
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("CORS_ALLOW_METHODS"),
+				NewFileValueSource("cors-allow-methods", &c.ConfigFile),
+			),
+			Required:    false,
+			Destination: &c.Api.CORSAllowMethods,
+		},
+
+		&cli.StringSliceFlag{
+			Name:  "cors-allow-headers",
+			Usage: "Headers that are allowed in the requests to the webhook, defaults to the requested headers when not set.",
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("CORS_ALLOW_HEADERS"),
+				NewFileValueSource("cors-allow-headers", &c.ConfigFile),
+			),
+			Required:    false,
+			Destination: &c.Api.CORSAllowHeaders,
+		},
+
+		&cli.BoolFlag{
+			Name:  "cors-allow-credentials",
+			Usage: "Allow credentials in the cross-origin requests to the webhook.",
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("CORS_ALLOW_CREDENTIALS"),
+				NewFileValueSource("cors-allow-credentials", &c.ConfigFile),
+			),
+			Required:    false,
+			Destination: &c.Api.CORSAllowCredentials,
+		},
+
 		&cli.StringFlag{
 			Name:  "opnsense-url",
 			Usage: "The base URI of the OPNsense API endpoint.",
 			Sources: cli.NewValueSourceChain(
 				cli.EnvVar("OPNSENSE_URL"),
+				NewFileValueSource("opnsense-url", &c.ConfigFile),
 			),
 			Required:    true,
 			Destination: &c.OpnsenseClient.Uri,
@@ -80,37 +430,154 @@ func BindFlags(c *Config) []cli.Flag {
 			Usage: "The API key for authenticating with the OPNsense API.",
 			Sources: cli.NewValueSourceChain(
 				cli.EnvVar("OPNSENSE_API_KEY"),
+				NewFileValueSource("opnsense-api-key", &c.ConfigFile),
 			),
-			Required:    true,
+			Required:    false,
 			Destination: &c.OpnsenseClient.APIKey,
 		},
 
+		&cli.StringFlag{
+			Name:  "opnsense-api-key-file",
+			Usage: "Path to a file containing the API key for authenticating with the OPNsense API, takes precedence over the API key.",
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("OPNSENSE_API_KEY_FILE"),
+				NewFileValueSource("opnsense-api-key-file", &c.ConfigFile),
+			),
+			Required:    false,
+			Destination: &c.OpnsenseClient.APIKeyFile,
+		},
+
 		&cli.StringFlag{
 			Name:  "opnsense-api-secret",
 			Usage: "The API secret for authenticating with the OPNsense API.",
 			Sources: cli.NewValueSourceChain(
 				cli.EnvVar("OPNSENSE_API_SECRET"),
+				NewFileValueSource("opnsense-api-secret", &c.ConfigFile),
 			),
-			Required:    true,
+			Required:    false,
 			Destination: &c.OpnsenseClient.APISecret,
 		},
 
+		&cli.StringFlag{
+			Name:  "opnsense-api-secret-file",
+			Usage: "Path to a file containing the API secret for authenticating with the OPNsense API, takes precedence over the API secret.",
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("OPNSENSE_API_SECRET_FILE"),
+				NewFileValueSource("opnsense-api-secret-file", &c.ConfigFile),
+			),
+			Required:    false,
+			Destination: &c.OpnsenseClient.APISecretFile,
+		},
+
+		&cli.StringFlag{
+			Name:  "opnsense-credentials-file",
+			Usage: "Path to the API key file downloaded from OPNsense, containing the key=... and secret=... lines.",
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("OPNSENSE_CREDENTIALS_FILE"),
+				NewFileValueSource("opnsense-credentials-file", &c.ConfigFile),
+			),
+			Required:    false,
+			Destination: &c.OpnsenseClient.CredentialsFile,
+		},
+
+		&cli.DurationFlag{
+			Name:  "opnsense-credentials-watch-interval",
+			Usage: "Interval to check the credential files for rotated credentials, disabled when zero.",
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("OPNSENSE_CREDENTIALS_WATCH_INTERVAL"),
+				NewFileValueSource("opnsense-credentials-watch-interval", &c.ConfigFile),
+			),
+			Required:    false,
+			Value:       30 * time.Second,
+			Destination: &c.OpnsenseClient.CredentialsWatchInterval,
+		},
+
 		&cli.BoolFlag{
 			Name:  "opnsense-allow-insecure",
 			Usage: "Allow insecure TLS connections to the OPNsense API.",
 			Sources: cli.NewValueSourceChain(
 				cli.EnvVar("OPNSENSE_ALLOW_INSECURE"),
+				NewFileValueSource("opnsense-allow-insecure", &c.ConfigFile),
 			),
 			Required:    false,
 			Value:       false,
 			Destination: &c.OpnsenseClient.AllowInsecure,
 		},
 
+		&cli.StringFlag{
+			Name:  "opnsense-ca-file",
+			Usage: "Path to the PEM encoded CA bundle to verify the OPNsense API certificate, in addition to the system certificates.",
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("OPNSENSE_CA_FILE"),
+				NewFileValueSource("opnsense-ca-file", &c.ConfigFile),
+			),
+			Required:    false,
+			Destination: &c.OpnsenseClient.CAFile,
+		},
+
+		&cli.StringFlag{
+			Name:  "opnsense-client-cert-file",
+			Usage: "Path to the PEM encoded client certificate to authenticate against the OPNsense API.",
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("OPNSENSE_CLIENT_CERT_FILE"),
+				NewFileValueSource("opnsense-client-cert-file", &c.ConfigFile),
+			),
+			Required:    false,
+			Destination: &c.OpnsenseClient.ClientCertFile,
+		},
+
+		&cli.StringFlag{
+			Name:  "opnsense-client-key-file",
+			Usage: "Path to the PEM encoded key of the client certificate.",
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("OPNSENSE_CLIENT_KEY_FILE"),
+				NewFileValueSource("opnsense-client-key-file", &c.ConfigFile),
+			),
+			Required:    false,
+			Destination: &c.OpnsenseClient.ClientKeyFile,
+		},
+
+		&cli.StringFlag{
+			Name:  "opnsense-tls-server-name",
+			Usage: "Server name to verify the OPNsense API certificate against, instead of the host of the URL.",
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("OPNSENSE_TLS_SERVER_NAME"),
+				NewFileValueSource("opnsense-tls-server-name", &c.ConfigFile),
+			),
+			Required:    false,
+			Destination: &c.OpnsenseClient.TLSServerName,
+		},
+
+		&cli.StringFlag{
+			Name:  "opnsense-tls-min-version",
+			Usage: `Minimum TLS version for the connection to the OPNsense API. enum("1.0", "1.1", "1.2", "1.3")`,
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("OPNSENSE_TLS_MIN_VERSION"),
+				NewFileValueSource("opnsense-tls-min-version", &c.ConfigFile),
+			),
+			Required:    false,
+			Value:       "1.2",
+			Destination: &c.OpnsenseClient.TLSMinVersion,
+		},
+
+		&cli.DurationFlag{
+			Name:  "opnsense-tls-watch-interval",
+			Usage: "Interval to check the certificate files for changes, disabled when zero.",
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("OPNSENSE_TLS_WATCH_INTERVAL"),
+				NewFileValueSource("opnsense-tls-watch-interval", &c.ConfigFile),
+			),
+			Required:    false,
+			Value:       30 * time.Second,
+			Destination: &c.OpnsenseClient.TLSWatchInterval,
+		},
+
 		&cli.IntFlag{
 			Name:  "opnsense-max-retries",
 			Usage: "Maximum number of retries for OPNsense API requests.",
 			Sources: cli.NewValueSourceChain(
 				cli.EnvVar("OPNSENSE_MAX_RETRIES"),
+				NewFileValueSource("opnsense-max-retries", &c.ConfigFile),
 			),
 			Required:    false,
 			Value:       3,
@@ -122,6 +589,7 @@ func BindFlags(c *Config) []cli.Flag {
 			Usage: "Minimum backoff duration between retries for OPNsense API requests.",
 			Sources: cli.NewValueSourceChain(
 				cli.EnvVar("OPNSENSE_MIN_BACKOFF"),
+				NewFileValueSource("opnsense-min-backoff", &c.ConfigFile),
 			),
 			Required:    false,
 			Value:       3 * time.Second,
@@ -133,12 +601,85 @@ func BindFlags(c *Config) []cli.Flag {
 			Usage: "Maximum backoff duration between retries for OPNsense API requests.",
 			Sources: cli.NewValueSourceChain(
 				cli.EnvVar("OPNSENSE_MAX_BACKOFF"),
+				NewFileValueSource("opnsense-max-backoff", &c.ConfigFile),
 			),
 			Required:    false,
 			Value:       30 * time.Second,
 			Destination: &c.OpnsenseClient.MaxBackoff,
 		},
 
+		&cli.FloatFlag{
+			Name:  "opnsense-rate-limit",
+			Usage: "Maximum number of requests per second to the OPNsense API, where 0 disables the rate limiting.",
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("OPNSENSE_RATE_LIMIT"),
+				NewFileValueSource("opnsense-rate-limit", &c.ConfigFile),
+			),
+			Required:    false,
+			Value:       0,
+			Destination: &c.OpnsenseClient.RateLimit,
+		},
+
+		&cli.IntFlag{
+			Name:  "opnsense-rate-limit-burst",
+			Usage: "Number of requests to the OPNsense API that can be made at once before the rate limit applies.",
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("OPNSENSE_RATE_LIMIT_BURST"),
+				NewFileValueSource("opnsense-rate-limit-burst", &c.ConfigFile),
+			),
+			Required:    false,
+			Value:       1,
+			Destination: &c.OpnsenseClient.RateLimitBurst,
+		},
+
+		&cli.FloatFlag{
+			Name:  "opnsense-reconfigure-rate-limit",
+			Usage: "Maximum number of reconfigure requests per second to the OPNsense API, which is limited separately from the other requests, where 0 disables the rate limiting.",
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("OPNSENSE_RECONFIGURE_RATE_LIMIT"),
+				NewFileValueSource("opnsense-reconfigure-rate-limit", &c.ConfigFile),
+			),
+			Required:    false,
+			Value:       0,
+			Destination: &c.OpnsenseClient.ReconfigureRateLimit,
+		},
+
+		&cli.IntFlag{
+			Name:  "opnsense-reconfigure-rate-limit-burst",
+			Usage: "Number of reconfigure requests to the OPNsense API that can be made at once before the rate limit applies.",
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("OPNSENSE_RECONFIGURE_RATE_LIMIT_BURST"),
+				NewFileValueSource("opnsense-reconfigure-rate-limit-burst", &c.ConfigFile),
+			),
+			Required:    false,
+			Value:       1,
+			Destination: &c.OpnsenseClient.ReconfigureRateLimitBurst,
+		},
+
+		&cli.IntFlag{
+			Name:  "opnsense-circuit-breaker-threshold",
+			Usage: "Number of consecutive failures of the OPNsense API after which the requests fail fast until the circuit breaker timeout, where 0 disables the circuit breaker.",
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("OPNSENSE_CIRCUIT_BREAKER_THRESHOLD"),
+				NewFileValueSource("opnsense-circuit-breaker-threshold", &c.ConfigFile),
+			),
+			Required:    false,
+			Value:       5,
+			Destination: &c.CircuitBreaker.Threshold,
+		},
+
+		&cli.DurationFlag{
+			Name:  "opnsense-circuit-breaker-timeout",
+			Usage: "Duration for which the requests fail fast after the circuit breaker opens, before a single request probes the OPNsense API again.",
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("OPNSENSE_CIRCUIT_BREAKER_TIMEOUT"),
+				NewFileValueSource("opnsense-circuit-breaker-timeout", &c.ConfigFile),
+			),
+			Required:    false,
+			Value:       30 * time.Second,
+			Destination: &c.CircuitBreaker.OpenTimeout,
+		},
+
 		// match with upstream: https://github.com/kubernetes-sigs/external-dns/blob/master/docs/flags.md
 
 		&cli.StringSliceFlag{
@@ -146,6 +687,7 @@ func BindFlags(c *Config) []cli.Flag {
 			Usage: "List of domain include filters.",
 			Sources: cli.NewValueSourceChain(
 				cli.EnvVar("DOMAIN_FILTER"),
+				NewFileValueSource("domain-filter", &c.ConfigFile),
 			),
 			Required:    false,
 			Destination: &c.Provider.DomainFilter.DomainFilter,
@@ -156,6 +698,7 @@ func BindFlags(c *Config) []cli.Flag {
 			Usage: "List of domain exclude filters.",
 			Sources: cli.NewValueSourceChain(
 				cli.EnvVar("EXCLUDE_DOMAINS"),
+				NewFileValueSource("exclude-domains", &c.ConfigFile),
 			),
 			Required:    false,
 			Destination: &c.Provider.DomainFilter.ExcludeDomains,
@@ -166,6 +709,7 @@ func BindFlags(c *Config) []cli.Flag {
 			Usage: "List of domain exclude filters in regex form.",
 			Sources: cli.NewValueSourceChain(
 				cli.EnvVar("REGEX_DOMAIN_FILTER"),
+				NewFileValueSource("regex-domain-filter", &c.ConfigFile),
 			),
 			Required:    false,
 			Destination: &c.Provider.DomainFilter.RegexDomainFilter,
@@ -176,9 +720,263 @@ func BindFlags(c *Config) []cli.Flag {
 			Usage: "List of domain exclude filters in regex form.",
 			Sources: cli.NewValueSourceChain(
 				cli.EnvVar("REGEX_DOMAIN_EXCLUSION"),
+				NewFileValueSource("regex-domain-exclusion", &c.ConfigFile),
 			),
 			Required:    false,
 			Destination: &c.Provider.DomainFilter.RegexDomainExclusion,
 		},
+
+		&cli.StringFlag{
+			Name:  "txt-owner-id",
+			Usage: "Owner ID of the registry records of external-dns, only the host overrides that are owned by it are updated or deleted when set.",
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("TXT_OWNER_ID"),
+				NewFileValueSource("txt-owner-id", &c.ConfigFile),
+			),
+			Required:    false,
+			Destination: &c.Provider.Ownership.OwnerID,
+		},
+
+		&cli.StringFlag{
+			Name:  "managed-description-marker",
+			Usage: "Host overrides with a description that contains the marker are managed, only the managed host overrides are updated or deleted when set.",
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("MANAGED_DESCRIPTION_MARKER"),
+				NewFileValueSource("managed-description-marker", &c.ConfigFile),
+			),
+			Required:    false,
+			Destination: &c.Provider.Ownership.DescriptionMarker,
+		},
+
+		&cli.StringSliceFlag{
+			Name:  "managed-names",
+			Usage: "Name patterns of the host overrides that are managed, like *.apps.example.com, only the managed host overrides are updated or deleted when set.",
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("MANAGED_NAMES"),
+				NewFileValueSource("managed-names", &c.ConfigFile),
+			),
+			Required:    false,
+			Destination: &c.Provider.Ownership.ManagedNames,
+		},
+
+		&cli.StringSliceFlag{
+			Name:  "protected-names",
+			Usage: "Name patterns of the host overrides that are never created, updated or deleted, like router.example.com.",
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("PROTECTED_NAMES"),
+				NewFileValueSource("protected-names", &c.ConfigFile),
+			),
+			Required:    false,
+			Destination: &c.Provider.Ownership.ProtectedNames,
+		},
+
+		&cli.IntFlag{
+			Name:  "max-deletes",
+			Usage: "Maximum number of the records that a batch of changes can delete, disabled when zero.",
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("MAX_DELETES"),
+				NewFileValueSource("max-deletes", &c.ConfigFile),
+			),
+			Required:    false,
+			Value:       0,
+			Destination: &c.Provider.Deletion.MaxDeletes,
+		},
+
+		&cli.FloatFlag{
+			Name:  "max-delete-percentage",
+			Usage: "Maximum percentage of the managed records that a batch of changes can delete, disabled when zero.",
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("MAX_DELETE_PERCENTAGE"),
+				NewFileValueSource("max-delete-percentage", &c.ConfigFile),
+			),
+			Required:    false,
+			Value:       0,
+			Destination: &c.Provider.Deletion.MaxDeletePercentage,
+		},
+
+		&cli.IntFlag{
+			Name:  "min-records-per-domain",
+			Usage: "Minimum number of the A and AAAA records that must remain in a domain that a batch of changes deletes from, disabled when zero.",
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("MIN_RECORDS_PER_DOMAIN"),
+				NewFileValueSource("min-records-per-domain", &c.ConfigFile),
+			),
+			Required:    false,
+			Value:       0,
+			Destination: &c.Provider.Deletion.MinRecordsPerDomain,
+		},
+
+		&cli.BoolFlag{
+			Name:  "allow-mass-deletes",
+			Usage: "Skip the deletion thresholds for all the batches of changes, meant to be enabled temporarily for an intentional mass delete.",
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("ALLOW_MASS_DELETES"),
+				NewFileValueSource("allow-mass-deletes", &c.ConfigFile),
+			),
+			Required:    false,
+			Value:       false,
+			Destination: &c.Provider.Deletion.AllowMassDeletes,
+		},
+
+		&cli.DurationFlag{
+			Name:  "records-cache-ttl",
+			Usage: "Time to serve the host overrides from the cache for the record queries, flushed when a batch of changes is applied, disabled when zero.",
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("RECORDS_CACHE_TTL"),
+				NewFileValueSource("records-cache-ttl", &c.ConfigFile),
+			),
+			Required:    false,
+			Value:       0,
+			Destination: &c.Provider.Cache.TTL,
+		},
+
+		&cli.StringSliceFlag{
+			Name:  "approval-domains",
+			Usage: "Domains of the records that are held for approval instead of being applied, disabled when not set.",
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("APPROVAL_DOMAINS"),
+				NewFileValueSource("approval-domains", &c.ConfigFile),
+			),
+			Required:    false,
+			Destination: &c.Provider.Approval.Domains,
+		},
+
+		&cli.StringFlag{
+			Name:  "approval-directory",
+			Usage: "Directory to persist the batches of changes that are held for approval, required with the approval domains.",
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("APPROVAL_DIRECTORY"),
+				NewFileValueSource("approval-directory", &c.ConfigFile),
+			),
+			Required:    false,
+			Destination: &c.Provider.Approval.Directory,
+		},
+
+		&cli.BoolFlag{
+			Name:  "read-only",
+			Usage: "Serve the records while the batches of changes are rejected or discarded, like while OPNsense is in maintenance.",
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("READ_ONLY"),
+				NewFileValueSource("read-only", &c.ConfigFile),
+			),
+			Required:    false,
+			Value:       false,
+			Destination: &c.Provider.Freeze.ReadOnly,
+		},
+
+		&cli.StringFlag{
+			Name:  "read-only-mode",
+			Usage: `How the batches of changes are handled in the read-only mode and the change freeze windows, where reject makes external-dns retry them and discard acknowledges them without applying. enum("reject", "discard")`,
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("READ_ONLY_MODE"),
+				NewFileValueSource("read-only-mode", &c.ConfigFile),
+			),
+			Required:    false,
+			Value:       "reject",
+			Destination: &c.Provider.Freeze.Mode,
+		},
+
+		&cli.StringSliceFlag{
+			Name:  "change-freeze-windows",
+			Usage: `Recurring windows where the changes are frozen in the form of "<cron expression> for <duration>", like "0 18 * * FRI for 62h", where the cron expression can start with CRON_TZ= for the time zone.`,
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("CHANGE_FREEZE_WINDOWS"),
+				NewFileValueSource("change-freeze-windows", &c.ConfigFile),
+			),
+			Required:    false,
+			Destination: &c.Provider.Freeze.Windows,
+		},
+
+		&cli.StringSliceFlag{
+			Name:  "notify-receivers",
+			Usage: "Receivers to send the notifications of the DNS changes to in the form of format+https://host/path, where the format is one of json, slack, ntfy or gotify.",
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("NOTIFY_RECEIVERS"),
+				NewFileValueSource("notify-receivers", &c.ConfigFile),
+			),
+			Required:    false,
+			Destination: &c.Notifier.Receivers,
+		},
+
+		&cli.StringFlag{
+			Name:  "notify-template",
+			Usage: "Go template of the notification message that is rendered with the summary of the batch, a line per change is sent when not set.",
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("NOTIFY_TEMPLATE"),
+				NewFileValueSource("notify-template", &c.ConfigFile),
+			),
+			Required:    false,
+			Destination: &c.Notifier.Template,
+		},
+
+		&cli.StringSliceFlag{
+			Name:  "notify-domains",
+			Usage: "Only notify the changes of the records in these domains and their subdomains, all the changes are notified when not set.",
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("NOTIFY_DOMAINS"),
+				NewFileValueSource("notify-domains", &c.ConfigFile),
+			),
+			Required:    false,
+			Destination: &c.Notifier.Domains,
+		},
+
+		&cli.StringSliceFlag{
+			Name:  "notify-operations",
+			Usage: "Only notify the changes with these operations out of create, update and delete, all the changes are notified when not set.",
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("NOTIFY_OPERATIONS"),
+				NewFileValueSource("notify-operations", &c.ConfigFile),
+			),
+			Required:    false,
+			Destination: &c.Notifier.Operations,
+		},
+
+		&cli.IntFlag{
+			Name:  "notify-max-retries",
+			Usage: "Maximum number of retries for sending a notification.",
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("NOTIFY_MAX_RETRIES"),
+				NewFileValueSource("notify-max-retries", &c.ConfigFile),
+			),
+			Required:    false,
+			Value:       3,
+			Destination: &c.Notifier.MaxRetries,
+		},
+
+		&cli.DurationFlag{
+			Name:  "notify-min-backoff",
+			Usage: "Minimum backoff duration between retries for sending a notification.",
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("NOTIFY_MIN_BACKOFF"),
+				NewFileValueSource("notify-min-backoff", &c.ConfigFile),
+			),
+			Required:    false,
+			Value:       1 * time.Second,
+			Destination: &c.Notifier.MinBackoff,
+		},
+
+		&cli.DurationFlag{
+			Name:  "notify-max-backoff",
+			Usage: "Maximum backoff duration between retries for sending a notification.",
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("NOTIFY_MAX_BACKOFF"),
+				NewFileValueSource("notify-max-backoff", &c.ConfigFile),
+			),
+			Required:    false,
+			Value:       30 * time.Second,
+			Destination: &c.Notifier.MaxBackoff,
+		},
+
+		&cli.DurationFlag{
+			Name:  "notify-timeout",
+			Usage: "Timeout of a single attempt of sending a notification.",
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("NOTIFY_TIMEOUT"),
+				NewFileValueSource("notify-timeout", &c.ConfigFile),
+			),
+			Required:    false,
+			Value:       10 * time.Second,
+			Destination: &c.Notifier.Timeout,
+		},
 	}
 }
@@ -0,0 +1,67 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/urfave/cli/v3"
+)
+
+// Load resolves a fresh configuration from the given arguments, the environment variables and the configuration file,
+// alongside the values of the flags keyed with the flag names.
+func Load(ctx context.Context, args []string) (*Config, map[string]any, error) {
+	conf := NewConfig()
+
+	var values map[string]any
+
+	cmd := &cli.Command{
+		Name:      "load",
+		Flags:     BindFlags(conf),
+		HideHelp:  true,
+		Writer:    io.Discard,
+		ErrWriter: io.Discard,
+		Action: func(_ context.Context, cmd *cli.Command) error {
+			if conf.ConfigFile != "" {
+				if err := ValidateFile(conf.ConfigFile, cmd.Flags); err != nil {
+					return err
+				}
+			}
+
+			values = Values(cmd.Flags, false)
+
+			return nil
+		},
+	}
+
+	if err := cmd.Run(ctx, args); err != nil {
+		return nil, nil, fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	return conf, values, nil
+}
+
+// Diff returns the names of the flags that have different values, in order.
+func Diff(previous map[string]any, next map[string]any) []string {
+	changed := []string{}
+
+	for name, value := range next {
+		if fmt.Sprint(previous[name]) != fmt.Sprint(value) {
+			changed = append(changed, name)
+		}
+	}
+
+	slices.Sort(changed)
+
+	return changed
+}
+
+// FormatValue formats the value of the flag for logging, redacting the sensitive ones.
+func FormatValue(name string, value any) string {
+	if IsSensitive(name) {
+		return RedactedValue
+	}
+
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,44 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/config"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Config Load", func() {
+	It("should be able to load the configuration with the precedence of flags over the file", func(ctx SpecContext) {
+		path := filepath.Join(GinkgoT().TempDir(), "config.yaml")
+		Expect(os.WriteFile(path, []byte("log-level: debug\nport: 9999\nopnsense-url: https://opnsense.invalid\n"), 0o600)).To(Succeed())
+
+		conf, values, err := config.Load(ctx, []string{"test", "--config", path, "--log-level", "warn", "--opnsense-api-key", "key", "--opnsense-api-secret", "secret"})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(conf.LogLevel).To(Equal("warn"))
+		Expect(conf.Port).To(BeEquivalentTo(9999))
+		Expect(conf.OpnsenseClient.Uri).To(Equal("https://opnsense.invalid"))
+		Expect(values).To(HaveKeyWithValue("opnsense-api-key", "key"))
+	})
+
+	It("should not be able to load an invalid configuration file", func(ctx SpecContext) {
+		path := filepath.Join(GinkgoT().TempDir(), "config.yaml")
+		Expect(os.WriteFile(path, []byte("unknown: true\n"), 0o600)).To(Succeed())
+
+		_, _, err := config.Load(ctx, []string{"test", "--config", path})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should be able to diff the values", func() {
+		changed := config.Diff(
+			map[string]any{"log-level": "info", "port": 8888, "domain-filter": []string{"a"}},
+			map[string]any{"log-level": "debug", "port": 8888, "domain-filter": []string{"b"}},
+		)
+
+		Expect(changed).To(Equal([]string{"domain-filter", "log-level"}))
+		Expect(config.FormatValue("opnsense-api-secret", "secret")).To(Equal(config.RedactedValue))
+		Expect(config.FormatValue("log-level", "debug")).To(Equal("debug"))
+	})
+})
@@ -0,0 +1,221 @@
+package config
+
+import (
+	"context"
+	"maps"
+	"os"
+	"os/signal"
+	"slices"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/opnsense"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/provider"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// restartRequiredFlags can not be changed without restarting the application.
+var restartRequiredFlags = []string{
+	"port",
+	"health-port",
+	"listen-address",
+	"health-listen-address",
+	"admin-port",
+	"admin-listen-address",
+	"admin-auth-token",
+	"health-check-interval",
+	"health-check-timeout",
+	"fail-fast",
+	"startup-check-privileges",
+	"startup-retry-interval",
+	"drift-check-interval",
+	"drift-self-heal",
+	"txt-owner-id",
+	"managed-description-marker",
+	"managed-names",
+	"protected-names",
+	"approval-domains",
+	"approval-directory",
+	"records-cache-ttl",
+	"tracing-endpoint",
+	"tracing-sample-ratio",
+	"audit-sink",
+	"audit-file",
+	"audit-syslog-address",
+	"audit-syslog-tag",
+	"notify-receivers",
+	"notify-template",
+	"notify-domains",
+	"notify-operations",
+	"notify-max-retries",
+	"notify-min-backoff",
+	"notify-max-backoff",
+	"notify-timeout",
+	"log-encoder",
+	"opnsense-credentials-watch-interval",
+	"opnsense-tls-watch-interval",
+	"opnsense-circuit-breaker-threshold",
+	"opnsense-circuit-breaker-timeout",
+	"tls-cert-file",
+	"tls-key-file",
+	"tls-client-ca-file",
+	"tls-min-version",
+	"tls-watch-interval",
+	"auth-token",
+	"cors-allow-origins",
+	"cors-allow-methods",
+	"cors-allow-headers",
+	"cors-allow-credentials",
+}
+
+// Reloader re-reads the configuration on SIGHUP or on changes of the configuration file and applies it to the running services.
+type Reloader struct {
+	Config ReloaderConfig
+
+	log    services.ZapSugaredLogger
+	values map[string]any
+	mu     sync.Mutex
+
+	*ReloaderSvc
+}
+
+type ReloaderSvc struct {
+	Logger    *services.Logger
+	Validator *services.Validator
+
+	Client   *opnsense.Client
+	Provider *provider.Provider
+}
+
+type ReloaderConfig struct {
+	Args          []string
+	ConfigFile    string
+	WatchInterval time.Duration
+}
+
+// NewReloader creates a new reloader, starting from the values of the flags that the services are created with.
+func NewReloader(svc *ReloaderSvc, conf ReloaderConfig, values map[string]any) *Reloader {
+	return &Reloader{
+		Config:      conf,
+		ReloaderSvc: svc,
+		log:         svc.Logger.WithCaller().With(zap.String("service", "reloader")),
+		values:      values,
+	}
+}
+
+// Run listens for the reload triggers until the context is cancelled.
+func (r *Reloader) Run(ctx context.Context) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	defer signal.Stop(signals)
+
+	if r.Config.ConfigFile != "" && r.Config.WatchInterval > 0 {
+		watcher := services.NewFileWatcher(&services.FileWatcherSvc{
+			Logger: r.Logger,
+		}, services.FileWatcherConfig{
+			Interval: r.Config.WatchInterval,
+		})
+
+		go watcher.Watch(ctx, []string{r.Config.ConfigFile}, func() {
+			r.log.Infof("Configuration file has changed, reloading: %s", r.Config.ConfigFile)
+
+			_ = r.Reload(ctx)
+		})
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-signals:
+			r.log.Infof("Received SIGHUP, reloading the configuration.")
+
+			_ = r.Reload(ctx)
+		}
+	}
+}
+
+// Reload resolves the configuration again and applies it, keeping the current configuration if the new one is invalid.
+func (r *Reloader) Reload(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	conf, values, err := Load(ctx, r.Config.Args)
+	if err != nil {
+		r.log.Errorf("Rejected the configuration reload, keeping the current configuration: %v", err)
+
+		return err
+	}
+
+	if err := conf.Validate(r.Validator); err != nil {
+		r.log.Errorf("Rejected the configuration reload, keeping the current configuration: %v", err)
+
+		return err
+	}
+
+	if _, err := zapcore.ParseLevel(conf.LogLevel); err != nil {
+		r.log.Errorf("Rejected the configuration reload, keeping the current configuration: %v", err)
+
+		return err
+	}
+
+	changed := Diff(r.values, values)
+	if len(changed) == 0 {
+		r.log.Infof("Configuration reloaded, nothing has changed.")
+
+		return nil
+	}
+
+	// the reload is applied as a whole or not at all, so the log level and the change freeze windows are already validated above,
+	// while the OPNsense client is reconfigured first, since it only fails while loading the credentials before it has changed anything
+	if err := r.Client.Reconfigure(conf.OpnsenseClient); err != nil {
+		r.log.Errorf("Rejected the configuration reload, keeping the current configuration: %v", err)
+
+		return err
+	}
+
+	if err := r.Logger.SetLevel(conf.LogLevel); err != nil {
+		r.log.Errorf("Failed to set the log level: %v", err)
+
+		return err
+	}
+
+	r.Provider.SetDomainFilter(conf.Provider.DomainFilter)
+	r.Provider.SetDeletionSafety(conf.Provider.Deletion)
+	if err := r.Provider.SetFreeze(conf.Provider.Freeze); err != nil {
+		r.log.Errorf("Failed to set the change freeze: %v", err)
+
+		return err
+	}
+
+	applied := maps.Clone(values)
+
+	for _, name := range changed {
+		if slices.Contains(restartRequiredFlags, name) {
+			r.log.Warnf("Configuration changed, but requires a restart to take effect: %s", name)
+
+			// the live value is kept, so that the change is reported as pending on every reload until the restart
+			if value, ok := r.values[name]; ok {
+				applied[name] = value
+			} else {
+				delete(applied, name)
+			}
+
+			continue
+		}
+
+		r.log.Infof(
+			"Configuration changed: %s: %s -> %s",
+			name,
+			FormatValue(name, r.values[name]),
+			FormatValue(name, values[name]),
+		)
+	}
+
+	r.values = applied
+
+	return nil
+}
@@ -0,0 +1,75 @@
+package config_test
+
+import (
+	"slices"
+
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/config"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/opnsense"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/provider"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/test/fixtures"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Config Reloader", func() {
+	var (
+		logger *services.Logger
+		logs   *observer.ObservedLogs
+		p      *provider.Provider
+		r      *config.Reloader
+	)
+
+	args := []string{"test", "--opnsense-url", "https://opnsense.invalid", "--opnsense-api-key", "key", "--opnsense-api-secret", "secret"}
+
+	BeforeEach(func(ctx SpecContext) {
+		var core zapcore.Core
+		core, logs = observer.New(zapcore.DebugLevel)
+
+		logger = fixtures.NewTestLogger()
+		logger.Logger = logger.WithOptions(zap.WrapCore(func(c zapcore.Core) zapcore.Core {
+			return zapcore.NewTee(c, core)
+		}))
+
+		conf, values, err := config.Load(ctx, args)
+		Expect(err).ToNot(HaveOccurred())
+
+		client, err := opnsense.NewClient(&opnsense.ClientSvc{Logger: logger}, conf.OpnsenseClient)
+		Expect(err).ToNot(HaveOccurred())
+
+		p, err = provider.NewProvider(&provider.ProviderSvc{Client: client, Logger: logger}, conf.Provider)
+		Expect(err).ToNot(HaveOccurred())
+
+		r = config.NewReloader(&config.ReloaderSvc{
+			Logger:    logger,
+			Validator: services.NewValidator(),
+			Client:    client,
+			Provider:  p,
+		}, config.ReloaderConfig{
+			Args: args,
+		}, values)
+	})
+
+	It("should keep reporting the changes that require a restart until the restart", func(ctx SpecContext) {
+		r.Config.Args = slices.Concat(args, []string{"--port", "9999"})
+
+		Expect(r.Reload(ctx)).To(Succeed())
+		Expect(r.Reload(ctx)).To(Succeed())
+
+		Expect(logs.FilterMessage("Configuration changed, but requires a restart to take effect: port").Len()).To(Equal(2))
+		Expect(logs.FilterMessage("Configuration reloaded, nothing has changed.").Len()).To(BeZero())
+	})
+
+	It("should not apply any of the configuration when a part of it can not be applied", func(ctx SpecContext) {
+		r.Config.Args = slices.Concat(args, []string{"--log-level", "warn", "--domain-filter", "example.com", "--opnsense-ca-file", "/nonexistent/ca.pem"})
+
+		Expect(r.Reload(ctx)).ToNot(Succeed())
+
+		Expect(logger.GetLevel()).To(Equal(zapcore.DebugLevel.String()))
+		Expect(p.GetDomainFilter().Match("example.org")).To(BeTrue())
+	})
+})
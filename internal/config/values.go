@@ -0,0 +1,64 @@
+package config
+
+import (
+	"slices"
+	"time"
+
+	"github.com/urfave/cli/v3"
+)
+
+const RedactedValue = "<redacted>"
+
+// ignoredFlags are not part of the configuration itself.
+var ignoredFlags = []string{"help", "version", "config", "config-watch-interval"}
+
+// sensitiveFlags hold credentials and are redacted when the configuration is printed.
+var sensitiveFlags = []string{
+	"opnsense-api-key",
+	"opnsense-api-secret",
+	"auth-token",
+	"admin-auth-token",
+	"notify-receivers",
+}
+
+// IsSensitive checks whether the flag with the given name holds a secret.
+func IsSensitive(name string) bool {
+	return slices.Contains(sensitiveFlags, name)
+}
+
+// Values resolves the current values of the flags keyed with the flag name, in the same shape as the configuration file.
+func Values(flags []cli.Flag, redacted bool) map[string]any {
+	values := map[string]any{}
+
+	for _, flag := range flags {
+		name := flag.Names()[0]
+		if slices.Contains(ignoredFlags, name) {
+			continue
+		}
+
+		value := flag.Get()
+
+		switch v := value.(type) {
+		case time.Duration:
+			value = v.String()
+		case string:
+			if redacted && v != "" && IsSensitive(name) {
+				value = RedactedValue
+			}
+		case []string:
+			// every item is redacted, so that the number of the secrets can still be seen
+			if redacted && len(v) > 0 && IsSensitive(name) {
+				items := make([]string, len(v))
+				for i := range v {
+					items[i] = RedactedValue
+				}
+
+				value = items
+			}
+		}
+
+		values[name] = value
+	}
+
+	return values
+}
@@ -0,0 +1,50 @@
+package ctx
+
+import (
+	"context"
+
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services"
+	"go.uber.org/zap"
+)
+
+type logFieldsKey struct{}
+
+// WithLogFields returns a copy of the context that carries the given log fields in addition to the ones that are already in it,
+// so that the services deeper in the call chain can correlate their logs with the request that caused them.
+func WithLogFields(c context.Context, fields ...zap.Field) context.Context {
+	current := LogFields(c)
+
+	merged := make([]zap.Field, 0, len(current)+len(fields))
+	merged = append(merged, current...)
+	merged = append(merged, fields...)
+
+	return context.WithValue(c, logFieldsKey{}, merged)
+}
+
+// LogFields returns the log fields that are carried in the context.
+func LogFields(c context.Context) []zap.Field {
+	fields, _ := c.Value(logFieldsKey{}).([]zap.Field)
+
+	return fields
+}
+
+// Logger returns the logger of the service with the log fields of the context attached.
+func Logger(c context.Context, log services.ZapSugaredLogger) services.ZapSugaredLogger {
+	fields := LogFields(c)
+	if len(fields) == 0 {
+		return log
+	}
+
+	return log.Desugar().With(fields...).Sugar()
+}
+
+// LogField returns the value of the string log field with the given key that is carried in the context.
+func LogField(c context.Context, key string) string {
+	for _, field := range LogFields(c) {
+		if field.Key == key {
+			return field.String
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,54 @@
+package ctx_test
+
+import (
+	"context"
+
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/ctx"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Logger", func() {
+	var (
+		logs *observer.ObservedLogs
+		log  *zap.SugaredLogger
+	)
+
+	BeforeEach(func() {
+		var core zapcore.Core
+		core, logs = observer.New(zapcore.DebugLevel)
+		log = zap.New(core).Sugar()
+	})
+
+	It("should return the same logger when there are no log fields in the context", func() {
+		Expect(ctx.Logger(context.Background(), log)).To(BeIdenticalTo(log))
+	})
+
+	It("should attach the log fields of the context", func() {
+		c := ctx.WithLogFields(context.Background(), zap.String("request_id", "abc"), zap.String("route", "/records"))
+
+		ctx.Logger(c, log).Info("test")
+
+		Expect(logs.Len()).To(Equal(1))
+		Expect(logs.All()[0].ContextMap()).To(Equal(map[string]any{
+			"request_id": "abc",
+			"route":      "/records",
+		}))
+	})
+
+	It("should merge the log fields with the ones that are already in the context", func() {
+		c := ctx.WithLogFields(context.Background(), zap.String("request_id", "abc"))
+		c = ctx.WithLogFields(c, zap.String("batch_id", "123"))
+
+		ctx.Logger(c, log).Info("test")
+
+		Expect(logs.All()[0].ContextMap()).To(Equal(map[string]any{
+			"request_id": "abc",
+			"batch_id":   "123",
+		}))
+	})
+})
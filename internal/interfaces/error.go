@@ -1,20 +1,117 @@
 package interfaces
 
 import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/labstack/echo/v5"
 )
 
+type ApiErrorCode string
+
+const (
+	ApiErrorCodeInvalidEndpoint     ApiErrorCode = "invalid_endpoint"
+	ApiErrorCodeConflict            ApiErrorCode = "conflict"
+	ApiErrorCodeUnmanagedRecord     ApiErrorCode = "unmanaged_record"
+	ApiErrorCodeProtectedRecord     ApiErrorCode = "protected_record"
+	ApiErrorCodeDeletionThreshold   ApiErrorCode = "deletion_threshold"
+	ApiErrorCodeReadOnly            ApiErrorCode = "read_only"
+	ApiErrorCodeChangeFreeze        ApiErrorCode = "change_freeze"
+	ApiErrorCodeUpstreamUnavailable ApiErrorCode = "upstream_unavailable"
+	ApiErrorCodeUpstreamError       ApiErrorCode = "upstream_error"
+	ApiErrorCodeUpstreamRejected    ApiErrorCode = "upstream_rejected"
+	ApiErrorCodeInternal            ApiErrorCode = "internal_error"
+)
+
+// ApiError is the body of the failed responses, where the code is machine readable
+// and the retryable flag tells whether the same request is retried, which holds for every 5xx response.
 type ApiError struct {
-	Status  int    `json:"status"`
-	Message string `json:"message"`
+	Status    int              `json:"status"`
+	Code      ApiErrorCode     `json:"code"`
+	Message   string           `json:"message"`
+	Retryable bool             `json:"retryable"`
+	Details   []ApiErrorDetail `json:"details,omitempty"`
+
+	// RetryAfter is sent as the Retry-After header when it is set.
+	RetryAfter time.Duration `json:"-"`
+	Err        error         `json:"-"`
 }
 
-var _ error = (*ApiError)(nil)
+// ApiErrorDetail is the failure of a single endpoint.
+type ApiErrorDetail struct {
+	DNSName    string            `json:"dns_name,omitempty"`
+	RecordType string            `json:"record_type,omitempty"`
+	Message    string            `json:"message"`
+	Fields     map[string]string `json:"fields,omitempty"`
+}
+
+var (
+	_ error                = (*ApiError)(nil)
+	_ echo.HTTPStatusCoder = (*ApiError)(nil)
+)
 
 func (e *ApiError) Error() string {
 	return e.Message
 }
 
+func (e *ApiError) StatusCode() int {
+	return e.Status
+}
+
+func (e *ApiError) Unwrap() error {
+	return e.Err
+}
+
+// Render writes the error as the response, with the Retry-After header in seconds when it is set.
+func (e *ApiError) Render(c *echo.Context) error {
+	if e.RetryAfter > 0 {
+		c.Response().Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(e.RetryAfter.Seconds()))))
+	}
+
+	return c.JSON(e.Status, e)
+}
+
 func NewHttpError(code int, err error) error {
 	return echo.NewHTTPError(code, err.Error())
 }
+
+// ToApiError converts the errors that are returned by the handlers, keeping the ApiError as it is.
+func ToApiError(err error) *ApiError {
+	var apiErr *ApiError
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) {
+		return &ApiError{
+			Status:    httpErr.Code,
+			Code:      StatusCode(httpErr.Code),
+			Message:   httpErr.Message,
+			Retryable: httpErr.Code >= http.StatusInternalServerError,
+			Err:       err,
+		}
+	}
+
+	return &ApiError{
+		Status:    http.StatusInternalServerError,
+		Code:      ApiErrorCodeInternal,
+		Message:   err.Error(),
+		Retryable: true,
+		Err:       err,
+	}
+}
+
+// StatusCode returns the generic code of the status, like bad_request for 400.
+func StatusCode(status int) ApiErrorCode {
+	text := http.StatusText(status)
+	if text == "" {
+		return ApiErrorCodeInternal
+	}
+
+	return ApiErrorCode(strings.ReplaceAll(strings.ToLower(text), " ", "_"))
+}
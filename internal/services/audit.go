@@ -0,0 +1,151 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type AuditSink string
+
+const (
+	AuditSinkNone   AuditSink = "none"
+	AuditSinkStdout AuditSink = "stdout"
+	AuditSinkFile   AuditSink = "file"
+	AuditSinkSyslog AuditSink = "syslog"
+)
+
+const AuditLoggerName = "audit"
+
+const (
+	AuditOutcomeSuccess = "success"
+	AuditOutcomeFailure = "failure"
+)
+
+type AuditConfig struct {
+	Sink          string `validate:"omitempty,oneof=none stdout file syslog"`
+	File          string `validate:"required_if=Sink file"`
+	SyslogAddress string `validate:"omitempty,url"`
+	SyslogTag     string
+}
+
+// AuditEvent is a single mutation of the DNS records, or the reconfiguration of the service that applies them.
+type AuditEvent struct {
+	Timestamp time.Time
+	RequestID string
+	BatchID   string
+	Operation string
+	FQDN      string
+	Type      string
+	OldValue  []string
+	NewValue  []string
+	UUID      string
+	Owner     string
+	DryRun    bool
+	Error     error
+}
+
+// Audit writes the audit events as JSON lines to the configured sink, separately from the application logs.
+// All the methods are safe to call on a nil receiver, so that the services can be used without auditing.
+type Audit struct {
+	log   ZapLogger
+	close func() error
+}
+
+// NewAudit creates the audit sink, returning nil when auditing is disabled.
+func NewAudit(conf AuditConfig) (*Audit, error) {
+	var (
+		writer zapcore.WriteSyncer
+		closer = func() error { return nil }
+	)
+
+	switch AuditSink(conf.Sink) {
+	case "", AuditSinkNone:
+		return nil, nil
+	case AuditSinkStdout:
+		writer = zapcore.Lock(os.Stdout)
+	case AuditSinkFile:
+		file, err := os.OpenFile(conf.File, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open the audit file: %w", err)
+		}
+
+		writer = zapcore.Lock(file)
+		closer = file.Close
+	case AuditSinkSyslog:
+		w, err := dialSyslog(conf.SyslogAddress, conf.SyslogTag)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to syslog: %w", err)
+		}
+
+		writer = zapcore.AddSync(w)
+		closer = w.Close
+	default:
+		return nil, fmt.Errorf("unknown audit sink: %s", conf.Sink)
+	}
+
+	encoder := zapcore.NewJSONEncoder(zapcore.EncoderConfig{
+		MessageKey:     "message",
+		NameKey:        "logger",
+		LineEnding:     zapcore.DefaultLineEnding,
+		EncodeDuration: zapcore.StringDurationEncoder,
+		EncodeTime:     zapcore.RFC3339NanoTimeEncoder,
+		EncodeName:     zapcore.FullNameEncoder,
+	})
+
+	return &Audit{
+		log:   zap.New(zapcore.NewCore(encoder, writer, zapcore.DebugLevel)).Named(AuditLoggerName),
+		close: closer,
+	}, nil
+}
+
+// Record writes the audit event, where the outcome is derived from the error of the operation.
+func (a *Audit) Record(event AuditEvent) {
+	if a == nil {
+		return
+	}
+
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+
+	outcome := AuditOutcomeSuccess
+	if event.Error != nil {
+		outcome = AuditOutcomeFailure
+	}
+
+	fields := []zap.Field{
+		zap.Time("timestamp", event.Timestamp),
+		zap.String("request_id", event.RequestID),
+		zap.String("batch_id", event.BatchID),
+		zap.String("operation", event.Operation),
+		zap.String("fqdn", event.FQDN),
+		zap.String("type", event.Type),
+		zap.Strings("old_value", event.OldValue),
+		zap.Strings("new_value", event.NewValue),
+		zap.String("uuid", event.UUID),
+		zap.String("owner", event.Owner),
+		zap.Bool("dry_run", event.DryRun),
+		zap.String("outcome", outcome),
+	}
+
+	if event.Error != nil {
+		fields = append(fields, zap.String("error", event.Error.Error()))
+	}
+
+	a.log.Info(event.Operation, fields...)
+}
+
+// Close flushes and closes the audit sink.
+func (a *Audit) Close() error {
+	if a == nil {
+		return nil
+	}
+
+	_ = a.log.Sync()
+
+	return a.close()
+}
@@ -0,0 +1,28 @@
+//go:build !windows
+
+package services
+
+import (
+	"fmt"
+	"io"
+	"log/syslog"
+	"net/url"
+)
+
+// dialSyslog connects to the local syslog daemon, or to the remote one when the address is in the form of "udp://host:514" or "tcp://host:514".
+func dialSyslog(address string, tag string) (io.WriteCloser, error) {
+	if tag == "" {
+		tag = ServiceName
+	}
+
+	if address == "" {
+		return syslog.New(syslog.LOG_INFO|syslog.LOG_AUTHPRIV, tag)
+	}
+
+	u, err := url.Parse(address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the syslog address: %w", err)
+	}
+
+	return syslog.Dial(u.Scheme, u.Host, syslog.LOG_INFO|syslog.LOG_AUTHPRIV, tag)
+}
@@ -0,0 +1,12 @@
+//go:build windows
+
+package services
+
+import (
+	"errors"
+	"io"
+)
+
+func dialSyslog(string, string) (io.WriteCloser, error) {
+	return nil, errors.New("syslog is not supported on windows")
+}
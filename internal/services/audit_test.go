@@ -0,0 +1,95 @@
+package services_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Audit", func() {
+	readEvents := func(path string) []map[string]any {
+		file, err := os.Open(path)
+		Expect(err).ToNot(HaveOccurred())
+		defer file.Close()
+
+		events := []map[string]any{}
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			event := map[string]any{}
+			Expect(json.Unmarshal(scanner.Bytes(), &event)).To(Succeed())
+			events = append(events, event)
+		}
+
+		return events
+	}
+
+	It("should be disabled by default", func() {
+		audit, err := services.NewAudit(services.AuditConfig{Sink: "none"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(audit).To(BeNil())
+
+		Expect(func() { audit.Record(services.AuditEvent{Operation: "create"}) }).ToNot(Panic())
+		Expect(audit.Close()).To(Succeed())
+	})
+
+	It("should append the events as JSON lines to the file", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "audit.log")
+
+		audit, err := services.NewAudit(services.AuditConfig{Sink: "file", File: path})
+		Expect(err).ToNot(HaveOccurred())
+
+		audit.Record(services.AuditEvent{
+			Timestamp: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+			RequestID: "request-1",
+			BatchID:   "batch-1",
+			Operation: "update",
+			FQDN:      "test.example.com",
+			Type:      "A",
+			OldValue:  []string{"10.0.0.1"},
+			NewValue:  []string{"10.0.0.2"},
+			UUID:      "f47ac10b-58cc-4372-a567-0e02b2c3d479",
+			Owner:     "default",
+			DryRun:    true,
+		})
+		audit.Record(services.AuditEvent{
+			Operation: "reconfigure",
+			Error:     errors.New("service unavailable"),
+		})
+		Expect(audit.Close()).To(Succeed())
+
+		info, err := os.Stat(path)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(info.Mode().Perm()).To(Equal(os.FileMode(0o600)))
+
+		events := readEvents(path)
+		Expect(events).To(HaveLen(2))
+
+		Expect(events[0]).To(Equal(map[string]any{
+			"logger":     "audit",
+			"message":    "update",
+			"timestamp":  "2025-01-02T03:04:05Z",
+			"request_id": "request-1",
+			"batch_id":   "batch-1",
+			"operation":  "update",
+			"fqdn":       "test.example.com",
+			"type":       "A",
+			"old_value":  []any{"10.0.0.1"},
+			"new_value":  []any{"10.0.0.2"},
+			"uuid":       "f47ac10b-58cc-4372-a567-0e02b2c3d479",
+			"owner":      "default",
+			"dry_run":    true,
+			"outcome":    "success",
+		}))
+
+		Expect(events[1]).To(HaveKeyWithValue("operation", "reconfigure"))
+		Expect(events[1]).To(HaveKeyWithValue("outcome", "failure"))
+		Expect(events[1]).To(HaveKeyWithValue("error", "service unavailable"))
+	})
+})
@@ -0,0 +1,130 @@
+package services
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Certificates holds the server certificate and the optional client CA bundle in memory,
+// replacing them when the files change so that the renewed certificates are served without a restart.
+type Certificates struct {
+	Config CertificatesConfig
+
+	log         ZapSugaredLogger
+	minVersion  uint16
+	certificate *tls.Certificate
+	clientCAs   *x509.CertPool
+	mu          sync.RWMutex
+
+	*CertificatesSvc
+}
+
+type CertificatesSvc struct {
+	Logger *Logger
+}
+
+type CertificatesConfig struct {
+	CertFile      string
+	KeyFile       string
+	ClientCAFile  string
+	MinVersion    string
+	WatchInterval time.Duration
+}
+
+// NewCertificates loads the certificates from the files for the first time.
+func NewCertificates(svc *CertificatesSvc, conf CertificatesConfig) (*Certificates, error) {
+	version, err := ParseTLSVersion(conf.MinVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Certificates{
+		Config:          conf,
+		CertificatesSvc: svc,
+		log:             svc.Logger.WithCaller().With(zap.String("service", "certificates")),
+		minVersion:      version,
+	}
+
+	if err := c.Reload(); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Reload reads the certificates from the files again, keeping the current ones if any of them is invalid.
+func (c *Certificates) Reload() error {
+	certificate, err := LoadCertificate(c.Config.CertFile, c.Config.KeyFile)
+	if err != nil {
+		return err
+	}
+
+	var clientCAs *x509.CertPool
+	if c.Config.ClientCAFile != "" {
+		clientCAs, err = LoadCertPool(c.Config.ClientCAFile, false)
+		if err != nil {
+			return err
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.certificate = certificate
+	c.clientCAs = clientCAs
+
+	return nil
+}
+
+// Watch reloads the certificates whenever the files change, until the context is cancelled.
+func (c *Certificates) Watch(ctx context.Context) {
+	files := []string{c.Config.CertFile, c.Config.KeyFile}
+	if c.Config.ClientCAFile != "" {
+		files = append(files, c.Config.ClientCAFile)
+	}
+
+	watcher := NewFileWatcher(&FileWatcherSvc{
+		Logger: c.Logger,
+	}, FileWatcherConfig{
+		Interval: c.Config.WatchInterval,
+	})
+
+	watcher.Watch(ctx, files, func() {
+		if err := c.Reload(); err != nil {
+			c.log.Warnf("Failed to reload the TLS certificates, keeping the current ones: %v", err)
+
+			return
+		}
+
+		c.log.Infof("TLS certificates have been reloaded.")
+	})
+}
+
+// TLSConfig creates the server TLS configuration, which resolves the current certificates on every handshake.
+// Client certificates are required and verified when a client CA bundle is configured.
+func (c *Certificates) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: c.minVersion,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			c.mu.RLock()
+			defer c.mu.RUnlock()
+
+			conf := &tls.Config{
+				MinVersion:   c.minVersion,
+				Certificates: []tls.Certificate{*c.certificate},
+			}
+
+			if c.clientCAs != nil {
+				conf.ClientCAs = c.clientCAs
+				conf.ClientAuth = tls.RequireAndVerifyClientCert
+			}
+
+			return conf, nil
+		},
+	}
+}
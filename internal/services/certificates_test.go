@@ -0,0 +1,108 @@
+package services_test
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/test/fixtures"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Certificates", func() {
+	var dir, serverCert, serverKey, clientCert, clientKey string
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+		serverCert, serverKey = fixtures.WriteSelfSignedCertificate(dir, "server")
+		clientCert, clientKey = fixtures.WriteSelfSignedCertificate(dir, "client")
+	})
+
+	serve := func(conf services.CertificatesConfig) (*services.Certificates, *httptest.Server) {
+		certificates, err := services.NewCertificates(&services.CertificatesSvc{
+			Logger: fixtures.NewTestLogger(),
+		}, conf)
+		Expect(err).ToNot(HaveOccurred())
+
+		server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+		server.TLS = certificates.TLSConfig()
+		server.StartTLS()
+		DeferCleanup(server.Close)
+
+		return certificates, server
+	}
+
+	request := func(server *httptest.Server, ca string, certificates ...tls.Certificate) error {
+		pool, err := services.LoadCertPool(ca, false)
+		Expect(err).ToNot(HaveOccurred())
+
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+			RootCAs:      pool,
+			Certificates: certificates,
+			MinVersion:   tls.VersionTLS12,
+		}}}
+
+		res, err := client.Get(server.URL)
+		if err != nil {
+			return err
+		}
+
+		return res.Body.Close()
+	}
+
+	It("should serve the certificate and pick up the renewed one on reload", func() {
+		certificates, server := serve(services.CertificatesConfig{
+			CertFile: serverCert,
+			KeyFile:  serverKey,
+		})
+
+		Expect(request(server, serverCert)).To(Succeed())
+
+		renewedCert, renewedKey := fixtures.WriteSelfSignedCertificate(GinkgoT().TempDir(), "server")
+		for from, to := range map[string]string{renewedCert: serverCert, renewedKey: serverKey} {
+			data, err := os.ReadFile(from)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(os.WriteFile(to, data, 0o600)).To(Succeed())
+		}
+		Expect(certificates.Reload()).To(Succeed())
+
+		Expect(request(server, renewedCert)).To(Succeed())
+	})
+
+	It("should require the client certificates when a client CA is configured", func() {
+		_, server := serve(services.CertificatesConfig{
+			CertFile:     serverCert,
+			KeyFile:      serverKey,
+			ClientCAFile: clientCert,
+		})
+
+		Expect(request(server, serverCert)).ToNot(Succeed())
+
+		certificate, err := services.LoadCertificate(clientCert, clientKey)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(request(server, serverCert, *certificate)).To(Succeed())
+	})
+
+	It("should keep the current certificates when the files are invalid", func() {
+		certificates, server := serve(services.CertificatesConfig{
+			CertFile: serverCert,
+			KeyFile:  serverKey,
+		})
+
+		data, err := os.ReadFile(serverCert)
+		Expect(err).ToNot(HaveOccurred())
+		ca := filepath.Join(dir, "ca.crt")
+		Expect(os.WriteFile(ca, data, 0o600)).To(Succeed())
+
+		Expect(os.WriteFile(serverCert, []byte("invalid"), 0o600)).To(Succeed())
+		Expect(certificates.Reload()).ToNot(Succeed())
+
+		Expect(request(server, ca)).To(Succeed())
+	})
+})
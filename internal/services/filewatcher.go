@@ -0,0 +1,78 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"os"
+	"time"
+)
+
+// FileWatcher polls the files for content changes, which also covers the atomic symlink swaps of the mounted Kubernetes secrets and config maps.
+type FileWatcher struct {
+	Config FileWatcherConfig
+	log    ZapSugaredLogger
+}
+
+type FileWatcherSvc struct {
+	Logger *Logger
+}
+
+type FileWatcherConfig struct {
+	Interval time.Duration
+}
+
+func NewFileWatcher(svc *FileWatcherSvc, conf FileWatcherConfig) *FileWatcher {
+	return &FileWatcher{
+		Config: conf,
+		log:    svc.Logger.WithCaller(),
+	}
+}
+
+// Watch calls the callback whenever the content of any of the given files changes, until the context is cancelled.
+func (w *FileWatcher) Watch(ctx context.Context, paths []string, onChange func()) {
+	if len(paths) == 0 || w.Config.Interval <= 0 {
+		return
+	}
+
+	checksums := make(map[string][32]byte, len(paths))
+	for _, path := range paths {
+		checksums[path] = w.checksum(path)
+	}
+
+	ticker := time.NewTicker(w.Config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			changed := false
+
+			for _, path := range paths {
+				checksum := w.checksum(path)
+				if checksum != checksums[path] {
+					w.log.Debugf("Watched file has changed: %s", path)
+
+					checksums[path] = checksum
+					changed = true
+				}
+			}
+
+			if changed {
+				onChange()
+			}
+		}
+	}
+}
+
+func (w *FileWatcher) checksum(path string) [32]byte {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		w.log.Debugf("Can not read the watched file: %s -> %v", path, err)
+
+		return [32]byte{}
+	}
+
+	return sha256.Sum256(data)
+}
@@ -0,0 +1,40 @@
+package services_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/test/fixtures"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("FileWatcher", func() {
+	It("should notify when the content of a watched file changes", func(ctx SpecContext) {
+		path := filepath.Join(GinkgoT().TempDir(), "watched")
+		Expect(os.WriteFile(path, []byte("initial"), 0o600)).To(Succeed())
+
+		watcher := services.NewFileWatcher(&services.FileWatcherSvc{
+			Logger: fixtures.NewTestLogger(),
+		}, services.FileWatcherConfig{
+			Interval: 10 * time.Millisecond,
+		})
+
+		c, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		changed := make(chan struct{}, 1)
+		go watcher.Watch(c, []string{path}, func() {
+			changed <- struct{}{}
+		})
+
+		Consistently(changed, 50*time.Millisecond).ShouldNot(Receive())
+
+		Expect(os.WriteFile(path, []byte("changed"), 0o600)).To(Succeed())
+
+		Eventually(changed).Should(Receive())
+	})
+})
@@ -0,0 +1,209 @@
+package health
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services"
+	"go.uber.org/zap"
+)
+
+type Status string
+
+const (
+	StatusUnknown Status = "unknown"
+	StatusUp      Status = "up"
+	StatusDown    Status = "down"
+)
+
+const (
+	ComponentListener       = "listener"
+	ComponentOpnsense       = "opnsense"
+	ComponentCredentials    = "credentials"
+	ComponentUnbound        = "unbound"
+	ComponentReconfigure    = "reconfigure"
+	ComponentStartup        = "startup"
+	ComponentCircuitBreaker = "circuit-breaker"
+	ComponentChangeFreeze   = "change-freeze"
+)
+
+// ComponentState is the cached result of the last check of a component.
+type ComponentState struct {
+	Name      string    `json:"name"`
+	Status    Status    `json:"status"`
+	Critical  bool      `json:"critical"`
+	LastCheck time.Time `json:"last_check,omitzero"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// ReportFunc reports the result of checking a component, where a nil error marks it as up.
+type ReportFunc func(component string, err error)
+
+// CheckFunc checks one or more components, reporting each of them.
+type CheckFunc func(ctx context.Context, report ReportFunc)
+
+// Checker checks the components in the background and caches their states,
+// so that the readiness probes are answered without hitting the firewall on every request.
+// All the methods are safe to call on a nil receiver, so that the services can be used without health checks.
+type Checker struct {
+	Config CheckerConfig
+
+	log    services.ZapSugaredLogger
+	checks []CheckFunc
+	states map[string]*ComponentState
+	mu     sync.RWMutex
+
+	*CheckerSvc
+}
+
+type CheckerSvc struct {
+	Logger *services.Logger
+}
+
+type CheckerConfig struct {
+	Interval time.Duration `validate:"gt=0"`
+	Timeout  time.Duration `validate:"gt=0"`
+}
+
+func NewChecker(svc *CheckerSvc, conf CheckerConfig) *Checker {
+	return &Checker{
+		Config:     conf,
+		CheckerSvc: svc,
+		log:        svc.Logger.WithCaller().With(zap.String("service", "health")),
+		states:     map[string]*ComponentState{},
+	}
+}
+
+// Register adds a check for the given components, which are unknown until they are checked for the first time.
+// The check can be nil for the components that are only reported with Set.
+// The service is not ready while any of the critical components is not up.
+func (c *Checker) Register(fn CheckFunc, critical bool, components ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if fn != nil {
+		c.checks = append(c.checks, fn)
+	}
+
+	for _, name := range components {
+		c.states[name] = &ComponentState{
+			Name:     name,
+			Status:   StatusUnknown,
+			Critical: critical,
+		}
+	}
+}
+
+// Set reports the state of a component that is not checked periodically, like the result of the last reconfigure.
+func (c *Checker) Set(component string, err error) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	state, ok := c.states[component]
+	if !ok {
+		state = &ComponentState{Name: component}
+		c.states[component] = state
+	}
+
+	previous := state.Status
+
+	state.LastCheck = time.Now()
+	state.Status = StatusUp
+	state.Error = ""
+
+	if err != nil {
+		state.Status = StatusDown
+		state.Error = err.Error()
+	}
+
+	if previous == state.Status {
+		return
+	}
+
+	if err != nil {
+		c.log.Warnf("Component is down: %s: %v", component, err)
+	} else if previous == StatusDown {
+		c.log.Infof("Component has recovered: %s", component)
+	}
+}
+
+// Check runs all the checks once concurrently, each of them with the configured timeout.
+func (c *Checker) Check(ctx context.Context) {
+	c.mu.RLock()
+	checks := slices.Clone(c.checks)
+	c.mu.RUnlock()
+
+	var wg sync.WaitGroup
+	for _, check := range checks {
+		wg.Go(func() {
+			ctx, cancel := context.WithTimeout(ctx, c.Config.Timeout)
+			defer cancel()
+
+			check(ctx, c.Set)
+		})
+	}
+
+	wg.Wait()
+}
+
+// Run checks the components immediately and then on every interval, until the context is cancelled.
+func (c *Checker) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.Config.Interval)
+	defer ticker.Stop()
+
+	for {
+		c.Check(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// IsReady returns whether all the critical components are up, from the cached states.
+func (c *Checker) IsReady() bool {
+	if c == nil {
+		return true
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, state := range c.states {
+		if state.Critical && state.Status != StatusUp {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Components returns a copy of the cached states of all the components sorted by name.
+func (c *Checker) Components() []ComponentState {
+	if c == nil {
+		return []ComponentState{}
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	components := make([]ComponentState, 0, len(c.states))
+	for _, state := range c.states {
+		components = append(components, *state)
+	}
+
+	slices.SortFunc(components, func(a, b ComponentState) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return components
+}
@@ -0,0 +1,123 @@
+package health_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/health"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/test/fixtures"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Checker", func() {
+	var checker *health.Checker
+
+	BeforeEach(func() {
+		checker = health.NewChecker(&health.CheckerSvc{
+			Logger: fixtures.NewTestLogger(),
+		}, health.CheckerConfig{
+			Interval: 10 * time.Millisecond,
+			Timeout:  time.Second,
+		})
+	})
+
+	It("should be ready without a checker", func() {
+		var c *health.Checker
+
+		Expect(c.IsReady()).To(BeTrue())
+		Expect(c.Components()).To(BeEmpty())
+		Expect(func() { c.Set(health.ComponentReconfigure, nil) }).ToNot(Panic())
+	})
+
+	It("should not be ready until the critical components are checked", func() {
+		checker.Register(func(_ context.Context, report health.ReportFunc) {
+			report(health.ComponentOpnsense, nil)
+		}, true, health.ComponentOpnsense)
+
+		Expect(checker.IsReady()).To(BeFalse())
+		Expect(checker.Components()).To(ConsistOf(
+			HaveField("Status", health.StatusUnknown),
+		))
+
+		checker.Check(GinkgoT().Context())
+
+		Expect(checker.IsReady()).To(BeTrue())
+		Expect(checker.Components()).To(ConsistOf(
+			HaveField("Status", health.StatusUp),
+		))
+	})
+
+	It("should report multiple components from a single check", func() {
+		checker.Register(func(_ context.Context, report health.ReportFunc) {
+			report(health.ComponentOpnsense, nil)
+			report(health.ComponentCredentials, errors.New("status code non-200; status code 401"))
+		}, true, health.ComponentOpnsense, health.ComponentCredentials)
+
+		checker.Check(GinkgoT().Context())
+
+		Expect(checker.IsReady()).To(BeFalse())
+
+		components := checker.Components()
+		Expect(components).To(HaveLen(2))
+		Expect(components[0].Name).To(Equal(health.ComponentCredentials))
+		Expect(components[0].Status).To(Equal(health.StatusDown))
+		Expect(components[0].Error).To(Equal("status code non-200; status code 401"))
+		Expect(components[1].Name).To(Equal(health.ComponentOpnsense))
+		Expect(components[1].Status).To(Equal(health.StatusUp))
+	})
+
+	It("should not depend on the components that are not critical", func() {
+		checker.Register(nil, false, health.ComponentReconfigure)
+
+		Expect(checker.IsReady()).To(BeTrue())
+
+		checker.Set(health.ComponentReconfigure, errors.New("failed"))
+
+		Expect(checker.IsReady()).To(BeTrue())
+		Expect(checker.Components()).To(ConsistOf(
+			HaveField("Status", health.StatusDown),
+		))
+	})
+
+	It("should cancel the checks that take longer than the timeout", func() {
+		checker.Config.Timeout = 10 * time.Millisecond
+
+		checker.Register(func(ctx context.Context, report health.ReportFunc) {
+			<-ctx.Done()
+
+			report(health.ComponentOpnsense, ctx.Err())
+		}, true, health.ComponentOpnsense)
+
+		checker.Check(GinkgoT().Context())
+
+		Expect(checker.Components()).To(ConsistOf(
+			HaveField("Error", context.DeadlineExceeded.Error()),
+		))
+	})
+
+	It("should check the components periodically until it is stopped", func() {
+		var checks atomic.Int32
+
+		checker.Register(func(_ context.Context, report health.ReportFunc) {
+			checks.Add(1)
+
+			report(health.ComponentOpnsense, nil)
+		}, true, health.ComponentOpnsense)
+
+		ctx, cancel := context.WithCancel(GinkgoT().Context())
+		done := make(chan struct{})
+		go func() {
+			checker.Run(ctx)
+			close(done)
+		}()
+
+		Eventually(checks.Load).Should(BeNumerically(">=", 3))
+
+		cancel()
+		Eventually(done).Should(BeClosed())
+	})
+})
@@ -0,0 +1,132 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	ListenerSchemeUnix = "unix"
+
+	DefaultSocketMode os.FileMode = 0o660
+)
+
+// Listen listens on the TCP address, or on the Unix domain socket when the address is in the form of "unix:///path.sock".
+// The permissions of the socket can be set with the mode query parameter in octal form like "unix:///path.sock?mode=0600".
+func Listen(address string) (net.Listener, error) {
+	if !strings.HasPrefix(address, ListenerSchemeUnix+"://") {
+		return net.Listen("tcp", address)
+	}
+
+	u, err := url.Parse(address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the socket address: %w", err)
+	}
+
+	path := u.Path
+	if path == "" {
+		return nil, fmt.Errorf("socket path is required: %s", address)
+	}
+
+	mode := DefaultSocketMode
+	if m := u.Query().Get("mode"); m != "" {
+		parsed, err := strconv.ParseUint(m, 8, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid socket mode: %s", m)
+		}
+
+		mode = os.FileMode(parsed)
+	}
+
+	if err := removeStaleSocket(path); err != nil {
+		return nil, err
+	}
+
+	return listenUnix(path, mode)
+}
+
+// listenUnix creates the socket in a private directory next to the path, where it can not be reached before its permissions are set,
+// and moves it in place afterwards, since the socket is otherwise created with the permissions of the umask of the process.
+func listenUnix(path string, mode os.FileMode) (net.Listener, error) {
+	dir, err := os.MkdirTemp(filepath.Dir(path), ".sock-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the directory of the socket: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	tmp := filepath.Join(dir, "s")
+
+	l, err := net.ListenUnix(ListenerSchemeUnix, &net.UnixAddr{Name: tmp, Net: ListenerSchemeUnix})
+	if err != nil {
+		return nil, err
+	}
+
+	// the socket is removed from its final path on close instead
+	l.SetUnlinkOnClose(false)
+
+	if err := os.Chmod(tmp, mode); err != nil {
+		_ = l.Close()
+
+		return nil, fmt.Errorf("failed to set the socket permissions: %w", err)
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		_ = l.Close()
+
+		return nil, fmt.Errorf("failed to move the socket in place: %w", err)
+	}
+
+	return &unixListener{UnixListener: l, path: path}, nil
+}
+
+// unixListener reports and removes the socket at the path that it has been moved to.
+type unixListener struct {
+	*net.UnixListener
+
+	path string
+}
+
+func (l *unixListener) Addr() net.Addr {
+	return &net.UnixAddr{Name: l.path, Net: ListenerSchemeUnix}
+}
+
+func (l *unixListener) Close() error {
+	err := l.UnixListener.Close()
+
+	if rmErr := os.Remove(l.path); rmErr != nil && !errors.Is(rmErr, os.ErrNotExist) {
+		err = errors.Join(err, rmErr)
+	}
+
+	return err
+}
+
+// removeStaleSocket removes the socket that is left behind by a previous process that did not shut down cleanly,
+// while refusing to touch a socket that is still being served or a file that is not a socket.
+func removeStaleSocket(path string) error {
+	info, err := os.Lstat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if info.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("refusing to remove the file that is not a socket: %s", path)
+	}
+
+	conn, err := net.DialTimeout(ListenerSchemeUnix, path, time.Second)
+	if err == nil {
+		_ = conn.Close()
+
+		return fmt.Errorf("socket is already in use: %s", path)
+	}
+
+	return os.Remove(path)
+}
@@ -0,0 +1,94 @@
+package services_test
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Listener", func() {
+	var path string
+
+	BeforeEach(func() {
+		// the socket paths are limited in length, so the temporary directory of the test is not used
+		dir, err := os.MkdirTemp("", "edw")
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(os.RemoveAll, dir)
+
+		path = filepath.Join(dir, "webhook.sock")
+	})
+
+	It("should listen on a TCP address", func() {
+		listener, err := services.Listen("127.0.0.1:0")
+		Expect(err).ToNot(HaveOccurred())
+		defer listener.Close()
+
+		Expect(listener.Addr().Network()).To(Equal("tcp"))
+	})
+
+	It("should listen on a Unix domain socket with the given permissions", func() {
+		listener, err := services.Listen("unix://" + path + "?mode=0600")
+		Expect(err).ToNot(HaveOccurred())
+
+		info, err := os.Stat(path)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(info.Mode() & os.ModeSocket).ToNot(BeZero())
+		Expect(info.Mode().Perm()).To(Equal(os.FileMode(0o600)))
+
+		conn, err := net.Dial("unix", path)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(conn.Close()).To(Succeed())
+
+		Expect(listener.Addr().String()).To(Equal(path))
+
+		Expect(listener.Close()).To(Succeed())
+		Expect(path).ToNot(BeAnExistingFile())
+
+		// the private directory that the socket is created in is not left behind
+		entries, err := os.ReadDir(filepath.Dir(path))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(entries).To(BeEmpty())
+	})
+
+	It("should remove a stale socket", func() {
+		listener, err := net.Listen("unix", path)
+		Expect(err).ToNot(HaveOccurred())
+		listener.(*net.UnixListener).SetUnlinkOnClose(false)
+		Expect(listener.Close()).To(Succeed())
+		Expect(path).To(BeAnExistingFile())
+
+		listener, err = services.Listen("unix://" + path)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(listener.Close()).To(Succeed())
+	})
+
+	It("should not take over a socket that is in use", func() {
+		listener, err := services.Listen("unix://" + path)
+		Expect(err).ToNot(HaveOccurred())
+		defer listener.Close()
+
+		_, err = services.Listen("unix://" + path)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should not remove a file that is not a socket", func() {
+		Expect(os.WriteFile(path, []byte{}, 0o600)).To(Succeed())
+
+		_, err := services.Listen("unix://" + path)
+		Expect(err).To(HaveOccurred())
+		Expect(path).To(BeAnExistingFile())
+	})
+
+	DescribeTable("should reject invalid socket addresses", func(address string) {
+		_, err := services.Listen(address)
+
+		Expect(err).To(HaveOccurred())
+	},
+		Entry("missing path", "unix://"),
+		Entry("invalid mode", "unix:///tmp/edw.sock?mode=abc"),
+	)
+})
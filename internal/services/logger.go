@@ -4,6 +4,7 @@ import (
 	"os"
 
 	"github.com/labstack/echo/v5"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 
@@ -19,6 +20,8 @@ const (
 
 type Logger struct {
 	*zap.Logger
+
+	level zap.AtomicLevel
 }
 
 type LoggerConfig struct {
@@ -89,30 +92,60 @@ func NewLogger(conf *LoggerConfig) (*Logger, error) {
 	}()
 
 	logger := &Logger{
-		z,
+		Logger: z,
+		level:  level,
 	}
 
 	return logger, nil
 }
 
+// SetLevel changes the level of the logger and all the loggers derived from it at runtime.
+func (l *Logger) SetLevel(level string) error {
+	parsed, err := zapcore.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+
+	l.level.SetLevel(parsed)
+
+	return nil
+}
+
+// GetLevel returns the current level of the logger.
+func (l *Logger) GetLevel() string {
+	return l.level.Level().String()
+}
+
+// GetRequestID returns the request ID of the incoming request, or the one that is generated for the response.
+func GetRequestID(c *echo.Context) string {
+	requestID := c.Request().Header.Get(echo.HeaderXRequestID)
+	if requestID == "" {
+		requestID = c.Response().Header().Get(echo.HeaderXRequestID)
+	}
+
+	return requestID
+}
+
 func (l *Logger) WithEchoContext(c *echo.Context) ZapSugaredLogger {
 	req := c.Request()
-	res := c.Response()
+	requestID := GetRequestID(c)
+
+	fields := []zap.Field{
+		zap.String("protocol", req.Proto),
+		zap.String("host", req.Host),
+		zap.String("method", req.Method),
+		zap.String("client_ip", c.RealIP()),
+		zap.String("request_id", requestID),
+		zap.String("path", req.RequestURI),
+	}
 
-	requestID := req.Header.Get(echo.HeaderXRequestID)
-	if requestID == "" {
-		requestID = res.Header().Get(echo.HeaderXRequestID)
+	if span := trace.SpanContextFromContext(req.Context()); span.HasTraceID() {
+		fields = append(fields, zap.String("trace_id", span.TraceID().String()))
 	}
 
 	return l.
-		With(
-			zap.String("protocol", req.Proto),
-			zap.String("host", req.Host),
-			zap.String("method", req.Method),
-			zap.String("client_ip", c.RealIP()),
-			zap.String("request_id", requestID),
-			zap.String("path", req.RequestURI),
-		).Sugar()
+		With(fields...).
+		Sugar()
 }
 
 func (l *Logger) WithCaller() ZapSugaredLogger {
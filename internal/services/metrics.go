@@ -0,0 +1,313 @@
+package services
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const MetricsNamespace = "external_dns_opnsense"
+
+const (
+	MetricsResultSuccess = "success"
+	MetricsResultFailure = "failure"
+)
+
+// ManagedRecordsKey groups the managed records for the metrics.
+type ManagedRecordsKey struct {
+	Domain string
+	Type   string
+}
+
+// Metrics holds the Prometheus collectors of the application.
+// All the methods are safe to call on a nil receiver, so that the services can be used without metrics, like in the CLI commands.
+type Metrics struct {
+	Registry *prometheus.Registry
+
+	webhookRequests        *prometheus.CounterVec
+	webhookRequestDuration *prometheus.HistogramVec
+
+	opnsenseRequests        *prometheus.CounterVec
+	opnsenseRequestDuration *prometheus.HistogramVec
+	opnsenseRequestErrors   *prometheus.CounterVec
+	opnsenseRetries         *prometheus.CounterVec
+	opnsenseRateLimitWait   *prometheus.HistogramVec
+
+	changes             *prometheus.CounterVec
+	reconfigureDuration prometheus.Histogram
+	managedRecords      *prometheus.GaugeVec
+	lastSuccessfulSync  prometheus.Gauge
+	driftRecords        *prometheus.GaugeVec
+	driftChecks         *prometheus.CounterVec
+	pendingBatches      prometheus.Gauge
+
+	notifications *prometheus.CounterVec
+}
+
+func NewMetrics() *Metrics {
+	m := &Metrics{
+		Registry: prometheus.NewRegistry(),
+
+		webhookRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: MetricsNamespace,
+			Subsystem: "webhook",
+			Name:      "requests_total",
+			Help:      "Number of the requests to the webhook by route, method and status.",
+		}, []string{"route", "method", "status"}),
+		webhookRequestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: MetricsNamespace,
+			Subsystem: "webhook",
+			Name:      "request_duration_seconds",
+			Help:      "Duration of the requests to the webhook by route, method and status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "method", "status"}),
+
+		opnsenseRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: MetricsNamespace,
+			Subsystem: "opnsense",
+			Name:      "requests_total",
+			Help:      "Number of the requests to the OPNsense API by endpoint, method and status.",
+		}, []string{"endpoint", "method", "status"}),
+		opnsenseRequestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: MetricsNamespace,
+			Subsystem: "opnsense",
+			Name:      "request_duration_seconds",
+			Help:      "Duration of the requests to the OPNsense API including the retries by endpoint and method.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"endpoint", "method"}),
+		opnsenseRequestErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: MetricsNamespace,
+			Subsystem: "opnsense",
+			Name:      "request_errors_total",
+			Help:      "Number of the failed requests to the OPNsense API by endpoint and method.",
+		}, []string{"endpoint", "method"}),
+		opnsenseRetries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: MetricsNamespace,
+			Subsystem: "opnsense",
+			Name:      "retries_total",
+			Help:      "Number of the retried requests to the OPNsense API by endpoint and method.",
+		}, []string{"endpoint", "method"}),
+		opnsenseRateLimitWait: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: MetricsNamespace,
+			Subsystem: "opnsense",
+			Name:      "rate_limit_wait_seconds",
+			Help:      "Duration of waiting for the rate limiter before the requests to the OPNsense API by limiter.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"limiter"}),
+
+		changes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: MetricsNamespace,
+			Subsystem: "provider",
+			Name:      "changes_total",
+			Help:      "Number of the applied record changes by operation and result.",
+		}, []string{"operation", "result"}),
+		reconfigureDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: MetricsNamespace,
+			Subsystem: "provider",
+			Name:      "reconfigure_duration_seconds",
+			Help:      "Duration of reconfiguring the Unbound service after applying the changes.",
+			Buckets:   prometheus.DefBuckets,
+		}),
+		managedRecords: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: MetricsNamespace,
+			Subsystem: "provider",
+			Name:      "managed_records",
+			Help:      "Number of the records that are proven to be managed by the provider by domain and type, without the registry records.",
+		}, []string{"domain", "type"}),
+		lastSuccessfulSync: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: MetricsNamespace,
+			Subsystem: "provider",
+			Name:      "last_successful_sync_timestamp_seconds",
+			Help:      "Unix timestamp of the last successfully applied batch of changes.",
+		}),
+		driftRecords: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: MetricsNamespace,
+			Subsystem: "provider",
+			Name:      "drift_records",
+			Help:      "Number of the records that have drifted from the desired state in the last drift check by kind.",
+		}, []string{"kind"}),
+		driftChecks: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: MetricsNamespace,
+			Subsystem: "provider",
+			Name:      "drift_checks_total",
+			Help:      "Number of the drift checks against the desired state by result.",
+		}, []string{"result"}),
+		pendingBatches: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: MetricsNamespace,
+			Subsystem: "provider",
+			Name:      "pending_batches",
+			Help:      "Number of the batches of changes that are held for approval.",
+		}),
+
+		notifications: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: MetricsNamespace,
+			Subsystem: "notifier",
+			Name:      "notifications_total",
+			Help:      "Number of the change notifications that are sent by receiver format and result.",
+		}, []string{"format", "result"}),
+	}
+
+	m.Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.webhookRequests,
+		m.webhookRequestDuration,
+		m.opnsenseRequests,
+		m.opnsenseRequestDuration,
+		m.opnsenseRequestErrors,
+		m.opnsenseRetries,
+		m.opnsenseRateLimitWait,
+		m.changes,
+		m.reconfigureDuration,
+		m.managedRecords,
+		m.lastSuccessfulSync,
+		m.driftRecords,
+		m.driftChecks,
+		m.pendingBatches,
+		m.notifications,
+	)
+
+	return m
+}
+
+// Handler returns the HTTP handler that serves the metrics in the Prometheus exposition format.
+func (m *Metrics) Handler() http.Handler {
+	if m == nil {
+		return http.NotFoundHandler()
+	}
+
+	return promhttp.HandlerFor(m.Registry, promhttp.HandlerOpts{})
+}
+
+func (m *Metrics) ObserveWebhookRequest(route string, method string, status int, duration time.Duration) {
+	if m == nil {
+		return
+	}
+
+	labels := prometheus.Labels{"route": route, "method": method, "status": strconv.Itoa(status)}
+	m.webhookRequests.With(labels).Inc()
+	m.webhookRequestDuration.With(labels).Observe(duration.Seconds())
+}
+
+// ObserveOpnsenseRequest records a request to the OPNsense API, where the status is zero when no response is received.
+func (m *Metrics) ObserveOpnsenseRequest(endpoint string, method string, status int, retries int, duration time.Duration, err error) {
+	if m == nil {
+		return
+	}
+
+	code := "none"
+	if status > 0 {
+		code = strconv.Itoa(status)
+	}
+
+	m.opnsenseRequests.WithLabelValues(endpoint, method, code).Inc()
+	m.opnsenseRequestDuration.WithLabelValues(endpoint, method).Observe(duration.Seconds())
+
+	if retries > 0 {
+		m.opnsenseRetries.WithLabelValues(endpoint, method).Add(float64(retries))
+	}
+
+	if err != nil {
+		m.opnsenseRequestErrors.WithLabelValues(endpoint, method).Inc()
+	}
+}
+
+// ObserveRateLimitWait records the time that a request to the OPNsense API has waited for the given rate limiter.
+func (m *Metrics) ObserveRateLimitWait(limiter string, duration time.Duration) {
+	if m == nil {
+		return
+	}
+
+	m.opnsenseRateLimitWait.WithLabelValues(limiter).Observe(duration.Seconds())
+}
+
+func (m *Metrics) ObserveChange(operation string, err error) {
+	if m == nil {
+		return
+	}
+
+	result := MetricsResultSuccess
+	if err != nil {
+		result = MetricsResultFailure
+	}
+
+	m.changes.WithLabelValues(operation, result).Inc()
+}
+
+func (m *Metrics) ObserveReconfigure(duration time.Duration) {
+	if m == nil {
+		return
+	}
+
+	m.reconfigureDuration.Observe(duration.Seconds())
+}
+
+// SetManagedRecords replaces the number of the managed records.
+func (m *Metrics) SetManagedRecords(records map[ManagedRecordsKey]int) {
+	if m == nil {
+		return
+	}
+
+	m.managedRecords.Reset()
+	for key, count := range records {
+		m.managedRecords.WithLabelValues(key.Domain, key.Type).Set(float64(count))
+	}
+}
+
+func (m *Metrics) SetLastSuccessfulSync(t time.Time) {
+	if m == nil {
+		return
+	}
+
+	m.lastSuccessfulSync.Set(float64(t.Unix()))
+}
+
+// SetDrift replaces the number of the drifted records by kind.
+func (m *Metrics) SetDrift(records map[string]int) {
+	if m == nil {
+		return
+	}
+
+	for kind, count := range records {
+		m.driftRecords.WithLabelValues(kind).Set(float64(count))
+	}
+}
+
+func (m *Metrics) ObserveDriftCheck(err error) {
+	if m == nil {
+		return
+	}
+
+	result := MetricsResultSuccess
+	if err != nil {
+		result = MetricsResultFailure
+	}
+
+	m.driftChecks.WithLabelValues(result).Inc()
+}
+
+func (m *Metrics) SetPendingBatches(count int) {
+	if m == nil {
+		return
+	}
+
+	m.pendingBatches.Set(float64(count))
+}
+
+// ObserveNotification records a notification that is sent to a receiver, after all the retries.
+func (m *Metrics) ObserveNotification(format string, err error) {
+	if m == nil {
+		return
+	}
+
+	result := MetricsResultSuccess
+	if err != nil {
+		result = MetricsResultFailure
+	}
+
+	m.notifications.WithLabelValues(format, result).Inc()
+}
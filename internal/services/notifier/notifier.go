@@ -0,0 +1,208 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services"
+	"github.com/hashicorp/go-retryablehttp"
+	"go.uber.org/zap"
+)
+
+// DefaultTemplate renders a line for every change in the batch.
+const DefaultTemplate = `{{ if .DryRun }}[dry-run] {{ end }}DNS records {{ if .Error }}failed to change{{ else }}changed{{ end }} on OPNsense with {{ len .Changes }} change(s) in batch {{ .BatchID }}:
+{{ range .Changes }}- {{ .Operation }} {{ .FQDN }} ({{ .Type }}){{ with .OldValue }} {{ join . ", " }}{{ end }}{{ if and .OldValue .NewValue }} ->{{ end }}{{ with .NewValue }} {{ join . ", " }}{{ end }}{{ if eq .Outcome "failure" }} [failed]{{ end }}
+{{ end }}{{ with .Error }}Error: {{ . }}{{ end }}`
+
+// Notifier sends the summaries of the applied changes to the configured receivers in the background,
+// so that a receiver that is down never delays or fails applying the changes.
+// All the methods are safe to call on a nil receiver, so that the services can be used without notifications.
+type Notifier struct {
+	Config NotifierConfig
+
+	log       services.ZapSugaredLogger
+	client    *retryablehttp.Client
+	receivers []*Receiver
+	template  *template.Template
+	wg        sync.WaitGroup
+
+	*NotifierSvc
+}
+
+type NotifierSvc struct {
+	Logger  *services.Logger
+	Metrics *services.Metrics
+}
+
+type NotifierConfig struct {
+	Receivers  []string `validate:"dive,url"`
+	Template   string
+	Domains    []string
+	Operations []string      `validate:"dive,oneof=create update delete"`
+	MaxRetries int           `validate:"gte=0"`
+	MinBackoff time.Duration `validate:"gte=0"`
+	MaxBackoff time.Duration `validate:"gtefield=MinBackoff"`
+	Timeout    time.Duration `validate:"gte=0"`
+}
+
+// Summary is the batch of changes that is sent to the receivers.
+type Summary struct {
+	Timestamp time.Time `json:"timestamp"`
+	RequestID string    `json:"request_id"`
+	BatchID   string    `json:"batch_id"`
+	DryRun    bool      `json:"dry_run"`
+	Changes   []Change  `json:"changes"`
+	Error     string    `json:"error,omitempty"`
+}
+
+type Change struct {
+	Operation string   `json:"operation"`
+	FQDN      string   `json:"fqdn"`
+	Type      string   `json:"type"`
+	OldValue  []string `json:"old_value,omitempty"`
+	NewValue  []string `json:"new_value,omitempty"`
+	UUID      string   `json:"uuid,omitempty"`
+	Owner     string   `json:"owner,omitempty"`
+	Outcome   string   `json:"outcome"`
+}
+
+// NewNotifier creates the notifier, returning nil when there are no receivers configured.
+func NewNotifier(svc *NotifierSvc, conf NotifierConfig) (*Notifier, error) {
+	if len(conf.Receivers) == 0 {
+		return nil, nil
+	}
+
+	receivers := make([]*Receiver, 0, len(conf.Receivers))
+	for _, r := range conf.Receivers {
+		receiver, err := ParseReceiver(r)
+		if err != nil {
+			return nil, err
+		}
+
+		receivers = append(receivers, receiver)
+	}
+
+	text := conf.Template
+	if text == "" {
+		text = DefaultTemplate
+	}
+
+	tmpl, err := template.New("notification").Funcs(template.FuncMap{
+		"join":  strings.Join,
+		"upper": strings.ToUpper,
+		"lower": strings.ToLower,
+	}).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the notification template: %w", err)
+	}
+
+	client := retryablehttp.NewClient()
+	client.Logger = nil
+	client.RetryMax = conf.MaxRetries
+	client.RetryWaitMin = conf.MinBackoff
+	client.RetryWaitMax = conf.MaxBackoff
+	client.HTTPClient.Timeout = conf.Timeout
+
+	return &Notifier{
+		Config:      conf,
+		NotifierSvc: svc,
+		log:         svc.Logger.WithCaller().With(zap.String("service", "notifier")),
+		client:      client,
+		receivers:   receivers,
+		template:    tmpl,
+	}, nil
+}
+
+// Notify sends the changes of the summary that match the filters to all the receivers in the background.
+// Failures are only logged, and nothing is sent when none of the changes match.
+func (n *Notifier) Notify(ctx context.Context, summary Summary) {
+	if n == nil {
+		return
+	}
+
+	summary.Changes = n.filter(summary.Changes)
+	if len(summary.Changes) == 0 {
+		return
+	}
+
+	if summary.Timestamp.IsZero() {
+		summary.Timestamp = time.Now()
+	}
+
+	message, err := n.render(summary)
+	if err != nil {
+		n.log.Warnf("Failed to render the notification for batch %s: %v", summary.BatchID, err)
+
+		return
+	}
+
+	// the notifications outlive the request, while keeping its values like the trace
+	ctx = context.WithoutCancel(ctx)
+
+	for _, receiver := range n.receivers {
+		n.wg.Go(func() {
+			err := receiver.Send(ctx, n.client, summary, message)
+			n.Metrics.ObserveNotification(string(receiver.Format), err)
+			if err != nil {
+				n.log.Warnf("Failed to send the notification for batch %s to the %s receiver %s: %v", summary.BatchID, receiver.Format, receiver.Redacted(), err)
+
+				return
+			}
+
+			n.log.Debugf("Sent the notification for batch %s to the %s receiver %s.", summary.BatchID, receiver.Format, receiver.Redacted())
+		})
+	}
+}
+
+// Wait blocks until the notifications that are in flight are sent, so that they are not lost on shutdown.
+func (n *Notifier) Wait() {
+	if n == nil {
+		return
+	}
+
+	n.wg.Wait()
+}
+
+// filter drops the changes that are not in the configured domains or operations, where an empty filter matches everything.
+func (n *Notifier) filter(changes []Change) []Change {
+	filtered := make([]Change, 0, len(changes))
+
+	for _, change := range changes {
+		if len(n.Config.Operations) > 0 && !slices.Contains(n.Config.Operations, change.Operation) {
+			continue
+		}
+
+		if len(n.Config.Domains) > 0 && !slices.ContainsFunc(n.Config.Domains, func(domain string) bool {
+			return matchDomain(change.FQDN, domain)
+		}) {
+			continue
+		}
+
+		filtered = append(filtered, change)
+	}
+
+	return filtered
+}
+
+func (n *Notifier) render(summary Summary) (string, error) {
+	var buf bytes.Buffer
+	if err := n.template.Execute(&buf, summary); err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// matchDomain returns whether the name is the domain itself or a subdomain of it.
+func matchDomain(name string, domain string) bool {
+	name = strings.TrimSuffix(strings.ToLower(name), ".")
+	domain = strings.TrimSuffix(strings.ToLower(strings.TrimPrefix(domain, ".")), ".")
+
+	return name == domain || strings.HasSuffix(name, "."+domain)
+}
@@ -0,0 +1,204 @@
+package notifier_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/notifier"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/test/fixtures"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type receivedRequest struct {
+	Header http.Header
+	Body   string
+}
+
+var _ = Describe("Notifier", func() {
+	var (
+		server   *httptest.Server
+		received []receivedRequest
+		failures int
+		mu       sync.Mutex
+	)
+
+	summary := notifier.Summary{
+		Timestamp: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+		RequestID: "request-1",
+		BatchID:   "batch-1",
+		Changes: []notifier.Change{
+			{Operation: "create", FQDN: "app.example.com", Type: "A", NewValue: []string{"10.0.0.1"}, Outcome: "success"},
+			{Operation: "update", FQDN: "db.example.org", Type: "A", OldValue: []string{"10.0.0.2"}, NewValue: []string{"10.0.0.3"}, Outcome: "success"},
+			{Operation: "delete", FQDN: "old.example.com", Type: "AAAA", OldValue: []string{"::1"}, Outcome: "success"},
+		},
+	}
+
+	newNotifier := func(conf notifier.NotifierConfig) *notifier.Notifier {
+		conf.MinBackoff = time.Millisecond
+		conf.MaxBackoff = time.Millisecond
+		conf.Timeout = time.Second
+
+		n, err := notifier.NewNotifier(&notifier.NotifierSvc{
+			Logger: fixtures.NewTestLogger(),
+		}, conf)
+		Expect(err).ToNot(HaveOccurred())
+
+		return n
+	}
+
+	BeforeEach(func() {
+		received = nil
+		failures = 0
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			if failures > 0 {
+				failures--
+				w.WriteHeader(http.StatusServiceUnavailable)
+
+				return
+			}
+
+			body, _ := io.ReadAll(r.Body)
+			received = append(received, receivedRequest{Header: r.Header, Body: string(body)})
+		}))
+		DeferCleanup(server.Close)
+	})
+
+	It("should be disabled without receivers", func() {
+		n, err := notifier.NewNotifier(&notifier.NotifierSvc{
+			Logger: fixtures.NewTestLogger(),
+		}, notifier.NotifierConfig{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(n).To(BeNil())
+
+		Expect(func() {
+			n.Notify(GinkgoT().Context(), summary)
+			n.Wait()
+		}).ToNot(Panic())
+	})
+
+	It("should reject the unknown receiver formats", func() {
+		_, err := notifier.NewNotifier(&notifier.NotifierSvc{
+			Logger: fixtures.NewTestLogger(),
+		}, notifier.NotifierConfig{Receivers: []string{"teams+https://example.com"}})
+		Expect(err).To(MatchError(ContainSubstring("unknown notification receiver format")))
+	})
+
+	It("should reject the invalid templates", func() {
+		_, err := notifier.NewNotifier(&notifier.NotifierSvc{
+			Logger: fixtures.NewTestLogger(),
+		}, notifier.NotifierConfig{Receivers: []string{server.URL}, Template: "{{ .Missing"})
+		Expect(err).To(MatchError(ContainSubstring("failed to parse the notification template")))
+	})
+
+	It("should send the summary with the rendered message to the generic JSON receiver", func() {
+		n := newNotifier(notifier.NotifierConfig{Receivers: []string{"json+" + server.URL}})
+
+		n.Notify(GinkgoT().Context(), summary)
+		n.Wait()
+
+		Expect(received).To(HaveLen(1))
+		Expect(received[0].Header.Get("Content-Type")).To(Equal("application/json"))
+
+		payload := map[string]any{}
+		Expect(json.Unmarshal([]byte(received[0].Body), &payload)).To(Succeed())
+		Expect(payload).To(HaveKeyWithValue("batch_id", "batch-1"))
+		Expect(payload).To(HaveKeyWithValue("request_id", "request-1"))
+		Expect(payload["changes"]).To(HaveLen(3))
+		Expect(payload["message"]).To(Equal(
+			"DNS records changed on OPNsense with 3 change(s) in batch batch-1:\n" +
+				"- create app.example.com (A) 10.0.0.1\n" +
+				"- update db.example.org (A) 10.0.0.2 -> 10.0.0.3\n" +
+				"- delete old.example.com (AAAA) ::1",
+		))
+	})
+
+	DescribeTable("should format the message for the receiver", func(format string, contentType string, expected func(body string)) {
+		n := newNotifier(notifier.NotifierConfig{
+			Receivers: []string{format + "+" + server.URL},
+			Template:  `{{ len .Changes }} change(s) in {{ .BatchID }}`,
+		})
+
+		n.Notify(GinkgoT().Context(), summary)
+		n.Wait()
+
+		Expect(received).To(HaveLen(1))
+		Expect(received[0].Header.Get("Content-Type")).To(Equal(contentType))
+		expected(received[0].Body)
+	},
+		Entry("slack", "slack", "application/json", func(body string) {
+			Expect(body).To(MatchJSON(`{"text":"3 change(s) in batch-1"}`))
+		}),
+		Entry("ntfy", "ntfy", "text/plain; charset=utf-8", func(body string) {
+			Expect(body).To(Equal("3 change(s) in batch-1"))
+		}),
+		Entry("gotify", "gotify", "application/json", func(body string) {
+			Expect(body).To(MatchJSON(`{"title":"DNS changes on OPNsense","message":"3 change(s) in batch-1","priority":5}`))
+		}),
+	)
+
+	It("should filter the changes by the domain and the operation", func() {
+		n := newNotifier(notifier.NotifierConfig{
+			Receivers:  []string{server.URL},
+			Template:   `{{ range .Changes }}{{ .FQDN }} {{ end }}`,
+			Domains:    []string{"example.com"},
+			Operations: []string{"create", "update"},
+		})
+
+		n.Notify(GinkgoT().Context(), summary)
+		n.Wait()
+
+		Expect(received).To(HaveLen(1))
+		Expect(fixtures.MustJsonUnmarshal(map[string]any{}, received[0].Body)).To(HaveKeyWithValue("message", "app.example.com"))
+	})
+
+	It("should not send anything when none of the changes match the filters", func() {
+		n := newNotifier(notifier.NotifierConfig{
+			Receivers: []string{server.URL},
+			Domains:   []string{"example.net"},
+		})
+
+		n.Notify(GinkgoT().Context(), summary)
+		n.Wait()
+
+		Expect(received).To(BeEmpty())
+	})
+
+	It("should retry the failed notifications", func() {
+		failures = 2
+
+		n := newNotifier(notifier.NotifierConfig{
+			Receivers:  []string{server.URL},
+			MaxRetries: 3,
+		})
+
+		n.Notify(GinkgoT().Context(), summary)
+		n.Wait()
+
+		Expect(received).To(HaveLen(1))
+	})
+
+	It("should give up after the retries without failing", func() {
+		failures = 10
+
+		n := newNotifier(notifier.NotifierConfig{
+			Receivers:  []string{server.URL},
+			MaxRetries: 1,
+		})
+
+		n.Notify(GinkgoT().Context(), summary)
+		n.Wait()
+
+		Expect(received).To(BeEmpty())
+		Expect(failures).To(Equal(8))
+	})
+})
@@ -0,0 +1,141 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/hashicorp/go-retryablehttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+type ReceiverFormat string
+
+const (
+	ReceiverFormatJSON   ReceiverFormat = "json"
+	ReceiverFormatSlack  ReceiverFormat = "slack"
+	ReceiverFormatNtfy   ReceiverFormat = "ntfy"
+	ReceiverFormatGotify ReceiverFormat = "gotify"
+)
+
+const notificationTitle = "DNS changes on OPNsense"
+
+// Receiver is an HTTP endpoint that accepts the notifications in one of the supported formats.
+type Receiver struct {
+	Format ReceiverFormat
+	URL    *url.URL
+}
+
+// ParseReceiver parses the receiver in the form of "format+https://host/path", where the format is one of json, slack, ntfy or gotify.
+// The generic JSON format is used when the format is omitted.
+func ParseReceiver(receiver string) (*Receiver, error) {
+	u, err := url.Parse(receiver)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the notification receiver: %w", err)
+	}
+
+	format := ReceiverFormatJSON
+	if prefix, scheme, found := strings.Cut(u.Scheme, "+"); found {
+		format = ReceiverFormat(prefix)
+		u.Scheme = scheme
+	}
+
+	switch format {
+	case ReceiverFormatJSON, ReceiverFormatSlack, ReceiverFormatNtfy, ReceiverFormatGotify:
+	default:
+		return nil, fmt.Errorf("unknown notification receiver format: %s", format)
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("notification receiver must be an http or https URL: %s", u.Redacted())
+	}
+
+	return &Receiver{
+		Format: format,
+		URL:    u,
+	}, nil
+}
+
+// Redacted returns the URL of the receiver without the credentials and the query, which may contain tokens.
+func (r *Receiver) Redacted() string {
+	u := *r.URL
+	u.User = nil
+	u.RawQuery = ""
+
+	return u.String()
+}
+
+// Send posts the notification to the receiver, retrying on the connection errors and the server errors.
+func (r *Receiver) Send(ctx context.Context, client *retryablehttp.Client, summary Summary, message string) error {
+	body, contentType, err := r.payload(summary, message)
+	if err != nil {
+		return err
+	}
+
+	req, err := retryablehttp.NewRequestWithContext(ctx, http.MethodPost, r.URL.String(), body)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", contentType)
+	if r.Format == ReceiverFormatNtfy {
+		req.Header.Set("Title", notificationTitle)
+		req.Header.Set("Tags", "globe_with_meridians")
+	}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	_, _ = io.Copy(io.Discard, res.Body)
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("status code non-2xx; status code %d", res.StatusCode)
+	}
+
+	return nil
+}
+
+func (r *Receiver) payload(summary Summary, message string) ([]byte, string, error) {
+	var payload any
+
+	switch r.Format {
+	case ReceiverFormatSlack:
+		payload = map[string]any{
+			"text": message,
+		}
+	case ReceiverFormatNtfy:
+		return []byte(message), "text/plain; charset=utf-8", nil
+	case ReceiverFormatGotify:
+		payload = map[string]any{
+			"title":    notificationTitle,
+			"message":  message,
+			"priority": 5,
+		}
+	default:
+		payload = struct {
+			Summary
+
+			Message string `json:"message"`
+		}{
+			Summary: summary,
+			Message: message,
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(payload); err != nil {
+		return nil, "", fmt.Errorf("failed to encode the notification: %w", err)
+	}
+
+	return buf.Bytes(), "application/json", nil
+}
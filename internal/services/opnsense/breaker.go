@@ -0,0 +1,252 @@
+package opnsense
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/health"
+	"go.uber.org/zap"
+)
+
+// ErrCircuitOpen is returned without calling the OPNsense API while the circuit breaker is open.
+var ErrCircuitOpen = errors.New("circuit breaker is open, OPNsense API is not called")
+
+// CircuitOpenError is returned while the circuit breaker is open, with the time until the OPNsense API is probed again.
+type CircuitOpenError struct {
+	RetryAfter time.Duration
+}
+
+func (e *CircuitOpenError) Error() string {
+	return ErrCircuitOpen.Error()
+}
+
+func (e *CircuitOpenError) Unwrap() error {
+	return ErrCircuitOpen
+}
+
+type CircuitState string
+
+const (
+	CircuitClosed   CircuitState = "closed"
+	CircuitOpen     CircuitState = "open"
+	CircuitHalfOpen CircuitState = "half-open"
+)
+
+// Breaker wraps a client to stop calling the OPNsense API after consecutive failures,
+// so that the webhook requests fail fast instead of waiting through all the retries while the firewall is down.
+// After the open timeout a single request is let through to probe the API, which closes the circuit again when it succeeds.
+type Breaker struct {
+	Config BreakerConfig
+
+	log      services.ZapSugaredLogger
+	state    CircuitState
+	failures int
+	openedAt time.Time
+	probing  bool
+	mu       sync.Mutex
+
+	*BreakerSvc
+}
+
+type BreakerSvc struct {
+	Client ClientAdapter
+	Logger *services.Logger
+	Health *health.Checker
+}
+
+type BreakerConfig struct {
+	Threshold   int           `validate:"gte=0"`
+	OpenTimeout time.Duration `validate:"gt=0"`
+}
+
+var (
+	_ ClientAdapter    = (*Breaker)(nil)
+	_ DryRunner        = (*Breaker)(nil)
+	_ PrivilegeChecker = (*Breaker)(nil)
+)
+
+func NewBreaker(svc *BreakerSvc, conf BreakerConfig) *Breaker {
+	b := &Breaker{
+		Config:     conf,
+		BreakerSvc: svc,
+		log:        svc.Logger.WithCaller().With(zap.String("service", "circuit-breaker")),
+		state:      CircuitClosed,
+	}
+
+	b.Health.Set(health.ComponentCircuitBreaker, nil)
+
+	return b
+}
+
+// State returns the current state of the circuit.
+func (b *Breaker) State() CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state
+}
+
+// allow returns whether the request can be made, letting a single request through once the open timeout has passed.
+func (b *Breaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case CircuitOpen:
+		if remaining := b.Config.OpenTimeout - time.Since(b.openedAt); remaining > 0 {
+			return &CircuitOpenError{RetryAfter: remaining}
+		}
+
+		b.transition(CircuitHalfOpen, nil)
+		b.probing = true
+
+		return nil
+	case CircuitHalfOpen:
+		if b.probing {
+			return &CircuitOpenError{}
+		}
+
+		b.probing = true
+
+		return nil
+	default:
+		return nil
+	}
+}
+
+// done records the result of a request that was allowed.
+func (b *Breaker) done(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+
+	// a cancelled request tells nothing about the API, so the next one probes it again
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+
+	if !IsBreakerFailure(err) {
+		b.failures = 0
+		if b.state != CircuitClosed {
+			b.transition(CircuitClosed, nil)
+		}
+
+		return
+	}
+
+	b.failures++
+
+	if b.state == CircuitHalfOpen || b.failures >= b.Config.Threshold {
+		b.openedAt = time.Now()
+		b.transition(CircuitOpen, err)
+	}
+}
+
+func (b *Breaker) transition(state CircuitState, err error) {
+	if b.state == state {
+		return
+	}
+
+	previous := b.state
+	b.state = state
+
+	switch state {
+	case CircuitOpen:
+		b.log.Warnf("Circuit breaker has opened after %d consecutive failure(s), failing fast for %s: %v", b.failures, b.Config.OpenTimeout, err)
+		b.Health.Set(health.ComponentCircuitBreaker, fmt.Errorf("%w: %w", ErrCircuitOpen, err))
+	case CircuitHalfOpen:
+		b.log.Infof("Circuit breaker is half-open, probing the OPNsense API.")
+	case CircuitClosed:
+		b.log.Infof("Circuit breaker has closed after being %s.", previous)
+		b.Health.Set(health.ComponentCircuitBreaker, nil)
+	}
+}
+
+func (b *Breaker) call(fn func() error) error {
+	if err := b.allow(); err != nil {
+		return err
+	}
+
+	err := fn()
+	b.done(err)
+
+	return err
+}
+
+// IsBreakerFailure returns whether the error means that the OPNsense API is failing,
+// where the rejected requests and the cancelled contexts do not count against the API.
+func IsBreakerFailure(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	return errors.Is(err, ErrTransport) || errors.Is(err, ErrServer)
+}
+
+func (b *Breaker) CheckUnboundService(ctx context.Context) error {
+	return b.call(func() error {
+		return b.Client.CheckUnboundService(ctx)
+	})
+}
+
+func (b *Breaker) UnboundSearchHostOverrides(ctx context.Context, req *UnboundSearchHostOverrideRequest) (res *UnboundSearchHostOverrideResponse, err error) {
+	err = b.call(func() error {
+		res, err = b.Client.UnboundSearchHostOverrides(ctx, req)
+
+		return err
+	})
+
+	return res, err
+}
+
+func (b *Breaker) UnboundCreateHostOverride(ctx context.Context, req *UnboundHostOverride) (uuid string, err error) {
+	err = b.call(func() error {
+		uuid, err = b.Client.UnboundCreateHostOverride(ctx, req)
+
+		return err
+	})
+
+	return uuid, err
+}
+
+func (b *Breaker) UnboundUpdateHostOverride(ctx context.Context, uuid string, req *UnboundHostOverride) error {
+	return b.call(func() error {
+		return b.Client.UnboundUpdateHostOverride(ctx, uuid, req)
+	})
+}
+
+func (b *Breaker) UnboundDeleteHostOverride(ctx context.Context, uuid string) error {
+	return b.call(func() error {
+		return b.Client.UnboundDeleteHostOverride(ctx, uuid)
+	})
+}
+
+func (b *Breaker) ReconfigureService(ctx context.Context) error {
+	return b.call(func() error {
+		return b.Client.ReconfigureService(ctx)
+	})
+}
+
+// IsDryRun forwards to the wrapped client, so that the breaker is transparent to the callers.
+func (b *Breaker) IsDryRun() bool {
+	client, ok := b.Client.(DryRunner)
+
+	return ok && client.IsDryRun()
+}
+
+// CheckPrivileges forwards to the wrapped client, which passes when the wrapped client can not check the privileges.
+func (b *Breaker) CheckPrivileges(ctx context.Context) error {
+	client, ok := b.Client.(PrivilegeChecker)
+	if !ok {
+		return nil
+	}
+
+	return b.call(func() error {
+		return client.CheckPrivileges(ctx)
+	})
+}
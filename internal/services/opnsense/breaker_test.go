@@ -0,0 +1,172 @@
+package opnsense_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/health"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/opnsense"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/test/fixtures"
+	mockservices "github.com/cenk1cenk2/external-dns-webhook-opnsense/test/mocks/services"
+	"github.com/stretchr/testify/mock"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Opnsense Circuit Breaker", func() {
+	var (
+		client  *mockservices.MockClientAdapter
+		checker *health.Checker
+		breaker *opnsense.Breaker
+	)
+
+	failure := &opnsense.APIError{Kind: opnsense.ErrTransport, Err: errors.New("connection refused")}
+
+	BeforeEach(func() {
+		client = mockservices.NewMockClientAdapter(GinkgoT())
+		checker = health.NewChecker(&health.CheckerSvc{
+			Logger: fixtures.NewTestLogger(),
+		}, health.CheckerConfig{})
+		checker.Register(nil, false, health.ComponentCircuitBreaker)
+
+		breaker = opnsense.NewBreaker(
+			&opnsense.BreakerSvc{
+				Client: client,
+				Logger: fixtures.NewTestLogger(),
+				Health: checker,
+			},
+			opnsense.BreakerConfig{
+				Threshold:   2,
+				OpenTimeout: 50 * time.Millisecond,
+			},
+		)
+	})
+
+	circuitBreakerState := func() health.ComponentState {
+		for _, state := range checker.Components() {
+			if state.Name == health.ComponentCircuitBreaker {
+				return state
+			}
+		}
+
+		return health.ComponentState{}
+	}
+
+	It("should open after the consecutive failures and fail fast", func(ctx SpecContext) {
+		client.EXPECT().ReconfigureService(mock.Anything).Return(failure).Twice()
+
+		Expect(breaker.ReconfigureService(ctx)).To(MatchError(failure))
+		Expect(breaker.State()).To(Equal(opnsense.CircuitClosed))
+		Expect(breaker.ReconfigureService(ctx)).To(MatchError(failure))
+		Expect(breaker.State()).To(Equal(opnsense.CircuitOpen))
+
+		Expect(breaker.ReconfigureService(ctx)).To(MatchError(opnsense.ErrCircuitOpen))
+		Expect(circuitBreakerState().Status).To(Equal(health.StatusDown))
+		Expect(checker.IsReady()).To(BeTrue())
+	})
+
+	It("should reset the failures after a success", func(ctx SpecContext) {
+		client.EXPECT().ReconfigureService(mock.Anything).Return(failure).Once()
+		client.EXPECT().ReconfigureService(mock.Anything).Return(nil).Once()
+		client.EXPECT().ReconfigureService(mock.Anything).Return(failure).Once()
+
+		Expect(breaker.ReconfigureService(ctx)).To(MatchError(failure))
+		Expect(breaker.ReconfigureService(ctx)).To(Succeed())
+		Expect(breaker.ReconfigureService(ctx)).To(MatchError(failure))
+		Expect(breaker.State()).To(Equal(opnsense.CircuitClosed))
+	})
+
+	It("should not count the rejected requests as failures", func(ctx SpecContext) {
+		client.EXPECT().UnboundDeleteHostOverride(mock.Anything, mock.Anything).Return(&opnsense.APIError{Kind: opnsense.ErrNotFound, StatusCode: http.StatusNotFound}).Times(3)
+
+		for range 3 {
+			Expect(breaker.UnboundDeleteHostOverride(ctx, "uuid")).ToNot(Succeed())
+		}
+
+		Expect(breaker.State()).To(Equal(opnsense.CircuitClosed))
+	})
+
+	It("should close after a successful probe once the timeout has passed", func(ctx SpecContext) {
+		client.EXPECT().ReconfigureService(mock.Anything).Return(failure).Twice()
+		client.EXPECT().ReconfigureService(mock.Anything).Return(nil).Once()
+
+		Expect(breaker.ReconfigureService(ctx)).ToNot(Succeed())
+		Expect(breaker.ReconfigureService(ctx)).ToNot(Succeed())
+		Expect(breaker.State()).To(Equal(opnsense.CircuitOpen))
+
+		Eventually(func() error {
+			return breaker.ReconfigureService(ctx)
+		}).WithTimeout(time.Second).WithPolling(10 * time.Millisecond).Should(Succeed())
+
+		Expect(breaker.State()).To(Equal(opnsense.CircuitClosed))
+		Expect(circuitBreakerState().Status).To(Equal(health.StatusUp))
+	})
+
+	It("should open again when the probe fails", func(ctx SpecContext) {
+		client.EXPECT().ReconfigureService(mock.Anything).Return(failure).Times(3)
+
+		Expect(breaker.ReconfigureService(ctx)).ToNot(Succeed())
+		Expect(breaker.ReconfigureService(ctx)).ToNot(Succeed())
+
+		Eventually(func() error {
+			return breaker.ReconfigureService(ctx)
+		}).WithTimeout(time.Second).WithPolling(10 * time.Millisecond).Should(MatchError(failure))
+
+		Expect(breaker.State()).To(Equal(opnsense.CircuitOpen))
+		Expect(breaker.ReconfigureService(ctx)).To(MatchError(opnsense.ErrCircuitOpen))
+	})
+
+	It("should let a single probe through while half-open", func(ctx SpecContext) {
+		client.EXPECT().ReconfigureService(mock.Anything).Return(failure).Twice()
+
+		release := make(chan struct{})
+		probing := make(chan struct{})
+		client.EXPECT().ReconfigureService(mock.Anything).RunAndReturn(func(context.Context) error {
+			close(probing)
+			<-release
+
+			return nil
+		}).Once()
+
+		Expect(breaker.ReconfigureService(ctx)).ToNot(Succeed())
+		Expect(breaker.ReconfigureService(ctx)).ToNot(Succeed())
+
+		time.Sleep(60 * time.Millisecond)
+
+		done := make(chan error)
+		go func() {
+			done <- breaker.ReconfigureService(ctx)
+		}()
+
+		Eventually(probing).Should(BeClosed())
+		Expect(breaker.State()).To(Equal(opnsense.CircuitHalfOpen))
+		Expect(breaker.ReconfigureService(ctx)).To(MatchError(opnsense.ErrCircuitOpen))
+
+		close(release)
+		Eventually(done).Should(Receive(BeNil()))
+		Expect(breaker.State()).To(Equal(opnsense.CircuitClosed))
+	})
+
+	It("should forward the dry run to the wrapped client", func() {
+		Expect(breaker.IsDryRun()).To(BeFalse())
+
+		dryRun, err := opnsense.NewClient(
+			&opnsense.ClientSvc{
+				Logger: fixtures.NewTestLogger(),
+			},
+			opnsense.ClientConfig{
+				Uri:       "opnsense.invalid",
+				APIKey:    "key",
+				APISecret: "secret",
+				DryRun:    true,
+			},
+		)
+		Expect(err).ToNot(HaveOccurred())
+
+		breaker.Client = dryRun
+		Expect(breaker.IsDryRun()).To(BeTrue())
+	})
+})
@@ -3,21 +3,28 @@ package opnsense
 import (
 	"bytes"
 	"context"
-	"crypto/tls"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"regexp"
 	"strings"
+	"sync"
 	"time"
 
+	appctx "github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/ctx"
 	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services"
 	"github.com/hashicorp/go-retryablehttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	"golang.org/x/text/cases"
 	"golang.org/x/text/language"
+	"golang.org/x/time/rate"
 )
 
 type ClientAdapter interface {
@@ -29,61 +36,282 @@ type ClientAdapter interface {
 	ReconfigureService(ctx context.Context) error
 }
 
+// DryRunner is implemented by the clients that can skip the mutating requests, so that the callers can tell apart the changes that are not applied.
+type DryRunner interface {
+	IsDryRun() bool
+}
+
+// PrivilegeChecker is implemented by the clients that can check the privileges of the credentials without making any changes.
+type PrivilegeChecker interface {
+	CheckPrivileges(ctx context.Context) error
+}
+
+var (
+	_ DryRunner        = (*Client)(nil)
+	_ PrivilegeChecker = (*Client)(nil)
+)
+
 type Client struct {
-	client        *retryablehttp.Client
-	url           string
-	apiKey        string
-	apiSecret     string
-	allowInsecure bool
-	isDryRun      bool
-	log           *zap.SugaredLogger
+	client             *retryablehttp.Client
+	url                string
+	credentials        *Credentials
+	isDryRun           bool
+	conf               ClientConfig
+	limiter            *rate.Limiter
+	reconfigureLimiter *rate.Limiter
+	logger             *services.Logger
+	metrics            *services.Metrics
+	log                *zap.SugaredLogger
+	mu                 sync.RWMutex
 }
 
 type ClientSvc struct {
-	Logger *services.Logger
+	Logger  *services.Logger
+	Metrics *services.Metrics
 }
 
 type ClientConfig struct {
-	Uri           string
-	APIKey        string
-	APISecret     string
-	AllowInsecure bool
-	DryRun        bool
-	MaxRetries    int
-	MinBackoff    time.Duration
-	MaxBackoff    time.Duration
+	Uri                       string        `validate:"required,url"`
+	APIKey                    string        `validate:"required_without_all=APIKeyFile CredentialsFile"`
+	APISecret                 string        `validate:"required_without_all=APISecretFile CredentialsFile"`
+	APIKeyFile                string        `validate:"omitempty,file"`
+	APISecretFile             string        `validate:"omitempty,file"`
+	CredentialsFile           string        `validate:"omitempty,file"`
+	CredentialsWatchInterval  time.Duration `validate:"gte=0"`
+	AllowInsecure             bool
+	CAFile                    string `validate:"omitempty,file"`
+	ClientCertFile            string `validate:"required_with=ClientKeyFile,omitempty,file"`
+	ClientKeyFile             string `validate:"required_with=ClientCertFile,omitempty,file"`
+	TLSServerName             string
+	TLSMinVersion             string        `validate:"omitempty,oneof=1.0 1.1 1.2 1.3"`
+	TLSWatchInterval          time.Duration `validate:"gte=0"`
+	DryRun                    bool
+	MaxRetries                int           `validate:"gte=0"`
+	MinBackoff                time.Duration `validate:"gte=0"`
+	MaxBackoff                time.Duration `validate:"gtefield=MinBackoff"`
+	RateLimit                 float64       `validate:"gte=0"`
+	RateLimitBurst            int           `validate:"gte=0"`
+	ReconfigureRateLimit      float64       `validate:"gte=0"`
+	ReconfigureRateLimitBurst int           `validate:"gte=0"`
 }
 
 var _ ClientAdapter = (*Client)(nil)
 
 func NewClient(svc *ClientSvc, conf ClientConfig) (*Client, error) {
+	c := &Client{
+		logger:  svc.Logger,
+		metrics: svc.Metrics,
+		log:     svc.Logger.Sugar(),
+	}
+
+	if err := c.Reconfigure(conf); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Reconfigure replaces the connection, credentials, retry and rate limit settings of the client at once,
+// the requests that are already in flight finish with the previous settings.
+func (c *Client) Reconfigure(conf ClientConfig) error {
+	credentials, err := LoadCredentials(conf)
+	if err != nil {
+		return err
+	}
+
+	tlsConfig, err := NewTLSConfig(conf)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	httpClient := retryablehttp.NewClient()
 	httpClient.Logger = nil
 
+	if c.client != nil {
+		c.client.HTTPClient.CloseIdleConnections()
+	}
+
 	httpClient.HTTPClient.Transport = &http.Transport{
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: conf.AllowInsecure},
+		TLSClientConfig: tlsConfig,
 	}
 
 	httpClient.RetryWaitMax = conf.MaxBackoff
 	httpClient.RetryWaitMin = conf.MinBackoff
 	httpClient.RetryMax = conf.MaxRetries
+	// keep the last response after the retries, so that the errors have the status code and the body
+	httpClient.ErrorHandler = retryablehttp.PassthroughErrorHandler
+	httpClient.CheckRetry = checkRetry
+	httpClient.RequestLogHook = func(_ retryablehttp.Logger, req *http.Request, attempt int) {
+		if attempts, ok := req.Context().Value(attemptsContextKey{}).(*int); ok {
+			*attempts = attempt
+		}
+	}
+
+	c.client = httpClient
+	c.url = conf.Uri
+	c.credentials = credentials
+	c.isDryRun = conf.DryRun
+	c.conf = conf
+	c.limiter = NewRateLimiter(conf.RateLimit, conf.RateLimitBurst)
+	c.reconfigureLimiter = NewRateLimiter(conf.ReconfigureRateLimit, conf.ReconfigureRateLimitBurst)
 
-	return &Client{
-		client:        httpClient,
-		url:           conf.Uri,
-		apiKey:        conf.APIKey,
-		apiSecret:     conf.APISecret,
-		allowInsecure: conf.AllowInsecure,
-		isDryRun:      conf.DryRun,
-		log:           svc.Logger.Sugar(),
-	}, nil
+	return nil
+}
+
+// ReloadCredentials reads the credentials from the configured files again and starts using them if they have changed.
+func (c *Client) ReloadCredentials() error {
+	// the credentials are compared and swapped under the same lock, so that a reconfiguration in the meantime
+	// is not overwritten with the credentials of the previous configuration
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	credentials, err := LoadCredentials(c.conf)
+	if err != nil {
+		return err
+	}
+
+	if *credentials == *c.credentials {
+		return nil
+	}
+
+	c.credentials = credentials
+
+	c.log.Infof("OPNsense API credentials have been rotated.")
+
+	return nil
+}
+
+// WatchCredentials periodically reloads the credentials from the files, so that the rotated secrets take effect without a restart.
+func (c *Client) WatchCredentials(ctx context.Context) {
+	c.mu.RLock()
+	interval := c.conf.CredentialsWatchInterval
+	c.mu.RUnlock()
+
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := c.ReloadCredentials(); err != nil {
+				c.log.Warnf("Failed to reload the OPNsense API credentials, keeping the current ones: %v", err)
+			}
+		}
+	}
+}
+
+// ReloadTLS reads the certificates from the configured files again and replaces the connection with them.
+func (c *Client) ReloadTLS() error {
+	c.mu.RLock()
+	conf := c.conf
+	c.mu.RUnlock()
+
+	if err := c.Reconfigure(conf); err != nil {
+		return err
+	}
+
+	c.log.Infof("OPNsense TLS certificates have been reloaded.")
+
+	return nil
+}
+
+// WatchTLS reloads the certificates when the configured certificate files change, so that the renewed certificates take effect without a restart.
+func (c *Client) WatchTLS(ctx context.Context) {
+	c.mu.RLock()
+	conf := c.conf
+	c.mu.RUnlock()
+
+	files := tlsFiles(conf)
+	if conf.TLSWatchInterval <= 0 || len(files) == 0 {
+		return
+	}
+
+	watcher := services.NewFileWatcher(&services.FileWatcherSvc{
+		Logger: c.logger,
+	}, services.FileWatcherConfig{
+		Interval: conf.TLSWatchInterval,
+	})
+
+	watcher.Watch(ctx, files, func() {
+		if err := c.ReloadTLS(); err != nil {
+			c.log.Warnf("Failed to reload the OPNsense TLS certificates, keeping the current ones: %v", err)
+		}
+	})
+}
+
+// requestLog returns the logger of the client with the log fields of the request in the context.
+func (c *Client) requestLog(ctx context.Context) services.ZapSugaredLogger {
+	return appctx.Logger(ctx, c.log)
 }
 
 func (c *Client) auth() string {
-	return base64.StdEncoding.EncodeToString([]byte(c.apiKey + ":" + c.apiSecret))
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return base64.StdEncoding.EncodeToString([]byte(c.credentials.APIKey + ":" + c.credentials.APISecret))
 }
 
-func (c *Client) do(ctx context.Context, method string, endpoint string, body any, res any) error {
+// IsDryRun returns whether the mutating requests are skipped.
+func (c *Client) IsDryRun() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.isDryRun
+}
+
+// attemptsContextKey keeps the number of the retries of a request in the context, which is set by the request hook of the retrying client.
+type attemptsContextKey struct{}
+
+var uuidPattern = regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`)
+
+// endpointLabel replaces the identifiers in the endpoint, so that it can be used as a label with a bounded set of values.
+func endpointLabel(endpoint string) string {
+	return uuidPattern.ReplaceAllString(endpoint, "{uuid}")
+}
+
+func (c *Client) do(ctx context.Context, method string, endpoint string, body any, res any) (err error) {
+	retries := 0
+	status := 0
+	start := time.Now()
+	ctx = context.WithValue(ctx, attemptsContextKey{}, &retries)
+	ctx = context.WithValue(ctx, retryPolicyContextKey{}, retryPolicyOf(method, endpoint))
+
+	ctx, span := services.Tracer().Start(
+		ctx,
+		fmt.Sprintf("OPNsense %s %s", method, endpointLabel(endpoint)),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.request.method", method),
+			attribute.String("url.path", endpoint),
+		),
+	)
+
+	defer func() {
+		span.SetAttributes(
+			attribute.Int("http.response.status_code", status),
+			attribute.Int("http.request.resend_count", retries),
+		)
+		services.RecordSpanError(span, err)
+		span.End()
+
+		c.metrics.ObserveOpnsenseRequest(endpointLabel(endpoint), method, status, retries, time.Since(start), err)
+	}()
+
+	wait, err := c.waitRateLimit(ctx, endpoint)
+	span.SetAttributes(attribute.Float64("opnsense.rate_limit.wait", wait.Seconds()))
+	if err != nil {
+		return err
+	}
+
 	var reader io.Reader
 	if body != nil {
 		data, err := json.Marshal(body)
@@ -94,7 +322,12 @@ func (c *Client) do(ctx context.Context, method string, endpoint string, body an
 		reader = bytes.NewReader(data)
 	}
 
-	path, err := url.JoinPath(c.url, "/api", endpoint)
+	c.mu.RLock()
+	client := c.client
+	base := c.url
+	c.mu.RUnlock()
+
+	path, err := url.JoinPath(base, "/api", endpoint)
 	if err != nil {
 		return fmt.Errorf("failed to build URL path: %w", err)
 	}
@@ -105,24 +338,33 @@ func (c *Client) do(ctx context.Context, method string, endpoint string, body an
 	}
 
 	req.Header.Add("Authorization", fmt.Sprintf("Basic %s", c.auth()))
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
 	if body != nil {
 		req.Header.Add("Content-Type", "application/json")
 	}
 
-	r, err := c.client.Do(req)
+	r, err := client.Do(req)
 	if err != nil {
-		return err
+		if r != nil {
+			r.Body.Close()
+		}
+
+		return &APIError{Kind: ErrTransport, Method: method, Endpoint: endpoint, Err: err}
 	}
 	defer r.Body.Close()
 
+	status = r.StatusCode
+
 	if r.StatusCode != http.StatusOK {
-		return fmt.Errorf("status code non-200; status code %d", r.StatusCode)
+		data, _ := io.ReadAll(io.LimitReader(r.Body, maxErrorBodyLength*2))
+
+		return newStatusError(method, endpoint, r.StatusCode, data)
 	}
 
 	if res != nil {
 		err = json.NewDecoder(r.Body).Decode(res)
 		if err != nil {
-			return err
+			return &APIError{Kind: ErrServer, Method: method, Endpoint: endpoint, StatusCode: r.StatusCode, Err: fmt.Errorf("failed to decode the response: %w", err)}
 		}
 	}
 
@@ -130,7 +372,9 @@ func (c *Client) do(ctx context.Context, method string, endpoint string, body an
 }
 
 func (c *Client) CheckUnboundService(ctx context.Context) error {
-	c.log.Debug("Checking Unbound service status")
+	log := c.requestLog(ctx)
+
+	log.Debug("Checking Unbound service status")
 
 	req := &ServiceSearchRequest{
 		SearchPhrase: "unbound",
@@ -144,17 +388,19 @@ func (c *Client) CheckUnboundService(ctx context.Context) error {
 
 	for _, service := range res.Rows {
 		if service.Name == "unbound" && service.Running == 1 {
-			c.log.Debug("Unbound service is running.")
+			log.Debug("Unbound service is running.")
 
 			return nil
 		}
 	}
 
-	return fmt.Errorf("unbound service is not running")
+	return ErrUnboundNotRunning
 }
 
 func (c *Client) UnboundSearchHostOverrides(ctx context.Context, req *UnboundSearchHostOverrideRequest) (*UnboundSearchHostOverrideResponse, error) {
-	c.log.Debug("Searching host overrides.")
+	log := c.requestLog(ctx)
+
+	log.Debug("Searching host overrides.")
 
 	if req == nil {
 		req = &UnboundSearchHostOverrideRequest{RowCount: -1}
@@ -166,44 +412,57 @@ func (c *Client) UnboundSearchHostOverrides(ctx context.Context, req *UnboundSea
 		return nil, err
 	}
 
-	c.log.Debugf("Found host overrides: %d", res.Total)
+	log.Debugf("Found host overrides: %d", res.Total)
 
 	return res, nil
 }
 
 func (c *Client) UnboundCreateHostOverride(ctx context.Context, override *UnboundHostOverride) (string, error) {
-	c.log.Debugf("Creating host override: %+v", override)
+	log := c.requestLog(ctx)
+
+	log.Debugf("Creating host override: %+v", override)
 
-	if c.isDryRun {
-		c.log.Warn("Dry run enabled, skipping create.")
+	if c.IsDryRun() {
+		log.Warn("Dry run enabled, skipping create.")
 
 		return "", nil
 	}
 
+	uuid, err := c.createHostOverrideIdempotent(ctx, override)
+	if err != nil {
+		return "", err
+	}
+
+	log.Debugf("Created host override: %+v -> %s", override, uuid)
+
+	return uuid, nil
+}
+
+func (c *Client) createHostOverride(ctx context.Context, override *UnboundHostOverride) (string, error) {
 	wrapped := map[string]*UnboundHostOverride{
 		"host": override,
 	}
 
 	res := &UnboundAddHostOverrideResponse{}
-	err := c.do(ctx, "POST", "/unbound/settings/addHostOverride", wrapped, res)
+	err := c.do(ctx, http.MethodPost, endpointAddHostOverride, wrapped, res)
 	if err != nil {
 		return "", err
 	}
 
 	if res.Result != "saved" {
-		return "", fmt.Errorf("resource not changed. result: %s. errors: %v", res.Result, res.Validations)
+		return "", newResultError(http.MethodPost, endpointAddHostOverride, res.Result, res.Validations)
 	}
 
-	c.log.Debug("Created host override: %+v -> %+v", override, res)
-
 	return res.UUID, nil
 }
 
 func (c *Client) UnboundUpdateHostOverride(ctx context.Context, uuid string, override *UnboundHostOverride) error {
-	c.log.Debugf("Updating host override: %+v", override)
+	log := c.requestLog(ctx)
+
+	log.Debugf("Updating host override: %+v", override)
 
-	if c.isDryRun {
-		c.log.Warn("Dry run enabled, skipping update.")
+	if c.IsDryRun() {
+		log.Warn("Dry run enabled, skipping update.")
 
 		return nil
 	}
@@ -212,56 +471,82 @@ func (c *Client) UnboundUpdateHostOverride(ctx context.Context, uuid string, ove
 		"host": override,
 	}
 
+	endpoint := fmt.Sprintf("/unbound/settings/setHostOverride/%s", uuid)
 	res := &UnboundAddHostOverrideResponse{}
-	err := c.do(ctx, "POST", fmt.Sprintf("/unbound/settings/setHostOverride/%s", uuid), wrapped, res)
+	err := c.do(ctx, http.MethodPost, endpoint, wrapped, res)
 	if err != nil {
 		return err
 	}
 
 	if res.Result != "saved" {
-		return fmt.Errorf("resource not changed. result: %s. errors: %v", res.Result, res.Validations)
+		return newResultError(http.MethodPost, endpoint, res.Result, res.Validations)
 	}
 
-	c.log.Debugf("Updated host override: %+v -> %+v", override, res)
+	log.Debugf("Updated host override: %+v -> %+v", override, res)
 
 	return nil
 }
 
 func (c *Client) UnboundDeleteHostOverride(ctx context.Context, uuid string) error {
-	c.log.Debugf("Deleting host override: %s", uuid)
+	log := c.requestLog(ctx)
 
-	if c.isDryRun {
-		c.log.Warn("Dry run enabled, skipping delete.")
+	log.Debugf("Deleting host override: %s", uuid)
+
+	if c.IsDryRun() {
+		log.Warn("Dry run enabled, skipping delete.")
 
 		return nil
 	}
 
+	endpoint := fmt.Sprintf("/unbound/settings/delHostOverride/%s", uuid)
 	res := &UnboundDeleteHostOverrideResponse{}
-	err := c.do(ctx, "POST", fmt.Sprintf("/unbound/settings/delHostOverride/%s", uuid), nil, res)
+	err := c.do(ctx, http.MethodPost, endpoint, nil, res)
 	if err != nil {
 		return err
 	}
 
 	if res.Result != "deleted" {
-		return fmt.Errorf("resource not deleted. result: %s", res.Result)
+		return newResultError(http.MethodPost, endpoint, res.Result, nil)
 	}
 
-	c.log.Debugf("Deleted host override: %s", uuid)
+	log.Debugf("Deleted host override: %s", uuid)
+
+	return nil
+}
+
+// CheckPrivileges checks whether the credentials are allowed to change the host overrides and to reconfigure the Unbound service,
+// which are required to apply the changes. The endpoints of the changes are requested with GET, since OPNsense checks the privileges
+// of the credentials on the path before the action, while the actions only change anything for POST and answer GET with a failed result.
+// The updates and the deletes of the host overrides are covered by the same privilege as adding them.
+func (c *Client) CheckPrivileges(ctx context.Context) error {
+	log := c.requestLog(ctx)
+
+	log.Debug("Checking the privileges to change the host overrides and to reconfigure the Unbound service.")
+
+	if err := c.do(ctx, http.MethodGet, endpointAddHostOverride, nil, nil); err != nil {
+		return fmt.Errorf("failed to check the privileges to change the host overrides: %w", err)
+	}
+
+	if err := c.do(ctx, http.MethodGet, endpointReconfigure, nil, nil); err != nil {
+		return fmt.Errorf("failed to check the privileges to reconfigure the Unbound service: %w", err)
+	}
 
 	return nil
 }
 
 func (c *Client) ReconfigureService(ctx context.Context) error {
-	c.log.Debug("Reconfiguring Unbound service.")
+	log := c.requestLog(ctx)
+
+	log.Debug("Reconfiguring Unbound service.")
 
-	if c.isDryRun {
-		c.log.Warn("Dry run enabled, skipping reconfigure.")
+	if c.IsDryRun() {
+		log.Warn("Dry run enabled, skipping reconfigure.")
 
 		return nil
 	}
 
 	resp := &ServiceResponse{}
-	err := c.do(ctx, "POST", "/unbound/service/reconfigure", nil, resp)
+	err := c.do(ctx, "POST", endpointReconfigure, nil, resp)
 	if err != nil {
 		return err
 	}
@@ -280,7 +565,7 @@ func (c *Client) ReconfigureService(ctx context.Context) error {
 		return fmt.Errorf("reconfigure failed. status: %s", status)
 	}
 
-	c.log.Debug("Reconfigured Unbound service.")
+	log.Debug("Reconfigured Unbound service.")
 
 	return nil
 }
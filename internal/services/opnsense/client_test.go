@@ -1,6 +1,11 @@
 package opnsense_test
 
 import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
 	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/opnsense"
 	"github.com/cenk1cenk2/external-dns-webhook-opnsense/test/fixtures"
 	. "github.com/onsi/ginkgo/v2"
@@ -26,6 +31,58 @@ var _ = Describe("Opnsense Client", func() {
 		Expect(client).ToNot(BeNil())
 	})
 
+	Context("privileges", func() {
+		newClient := func(handler http.HandlerFunc) *opnsense.Client {
+			server := httptest.NewServer(handler)
+			DeferCleanup(server.Close)
+
+			client, err := opnsense.NewClient(
+				&opnsense.ClientSvc{
+					Logger: fixtures.NewTestLogger(),
+				},
+				opnsense.ClientConfig{
+					Uri:        server.URL,
+					APIKey:     "key",
+					APISecret:  "secret",
+					MaxRetries: 1,
+					MinBackoff: time.Millisecond,
+					MaxBackoff: time.Millisecond,
+				},
+			)
+			Expect(err).ToNot(HaveOccurred())
+
+			return client
+		}
+
+		It("should check the endpoints of the changes without changing anything", func(ctx SpecContext) {
+			requests := []string{}
+			client := newClient(func(w http.ResponseWriter, r *http.Request) {
+				requests = append(requests, r.Method+" "+r.URL.Path)
+				_, _ = w.Write([]byte(`{"result":"failed"}`))
+			})
+
+			Expect(client.CheckPrivileges(ctx)).To(Succeed())
+			Expect(requests).To(Equal([]string{
+				"GET /api/unbound/settings/addHostOverride",
+				"GET /api/unbound/service/reconfigure",
+			}))
+		})
+
+		It("should fail when the credentials are not allowed to reconfigure the service", func(ctx SpecContext) {
+			client := newClient(func(w http.ResponseWriter, r *http.Request) {
+				if strings.HasSuffix(r.URL.Path, "/reconfigure") {
+					w.WriteHeader(http.StatusForbidden)
+				}
+
+				_, _ = w.Write([]byte(`{}`))
+			})
+
+			err := client.CheckPrivileges(ctx)
+			Expect(err).To(MatchError(opnsense.ErrForbidden))
+			Expect(err.Error()).To(ContainSubstring("privileges to reconfigure the Unbound service"))
+		})
+	})
+
 	Context("dry run client", func() {
 		var (
 			client *opnsense.Client
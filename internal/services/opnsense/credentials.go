@@ -0,0 +1,110 @@
+package opnsense
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+)
+
+type Credentials struct {
+	APIKey    string
+	APISecret string
+}
+
+// ParseCredentials parses the API key file that is downloaded from OPNsense, in the format of "key=..." and "secret=..." lines.
+func ParseCredentials(data []byte) (*Credentials, error) {
+	credentials := &Credentials{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid line in credentials file, expected key=value")
+		}
+
+		switch strings.TrimSpace(key) {
+		case "key":
+			credentials.APIKey = strings.TrimSpace(value)
+		case "secret":
+			credentials.APISecret = strings.TrimSpace(value)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if credentials.APIKey == "" || credentials.APISecret == "" {
+		return nil, fmt.Errorf("credentials file must contain both key and secret")
+	}
+
+	return credentials, nil
+}
+
+// LoadCredentials resolves the credentials from the configuration,
+// where the dedicated files take precedence over the credentials file and the credentials file takes precedence over the plain values.
+func LoadCredentials(conf ClientConfig) (*Credentials, error) {
+	credentials := &Credentials{
+		APIKey:    conf.APIKey,
+		APISecret: conf.APISecret,
+	}
+
+	if conf.CredentialsFile != "" {
+		data, err := os.ReadFile(conf.CredentialsFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read credentials file: %w", err)
+		}
+
+		parsed, err := ParseCredentials(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse credentials file %s: %w", conf.CredentialsFile, err)
+		}
+
+		credentials = parsed
+	}
+
+	if conf.APIKeyFile != "" {
+		key, err := readSecretFile(conf.APIKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read API key file: %w", err)
+		}
+
+		credentials.APIKey = key
+	}
+
+	if conf.APISecretFile != "" {
+		secret, err := readSecretFile(conf.APISecretFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read API secret file: %w", err)
+		}
+
+		credentials.APISecret = secret
+	}
+
+	if credentials.APIKey == "" || credentials.APISecret == "" {
+		return nil, fmt.Errorf("both API key and API secret are required")
+	}
+
+	return credentials, nil
+}
+
+func readSecretFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	value := strings.TrimSpace(string(data))
+	if value == "" {
+		return "", fmt.Errorf("file is empty: %s", path)
+	}
+
+	return value, nil
+}
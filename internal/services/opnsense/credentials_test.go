@@ -0,0 +1,144 @@
+package opnsense_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/opnsense"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/test/fixtures"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Opnsense Credentials", func() {
+	write := func(name string, content string) string {
+		path := filepath.Join(GinkgoT().TempDir(), name)
+		Expect(os.WriteFile(path, []byte(content), 0o600)).To(Succeed())
+
+		return path
+	}
+
+	It("should be able to parse the downloaded API key file", func() {
+		credentials, err := opnsense.ParseCredentials([]byte("key=abc\nsecret=def+/=\n"))
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(credentials.APIKey).To(Equal("abc"))
+		Expect(credentials.APISecret).To(Equal("def+/="))
+	})
+
+	DescribeTable("should not be able to parse an invalid API key file", func(content string) {
+		_, err := opnsense.ParseCredentials([]byte(content))
+
+		Expect(err).To(HaveOccurred())
+	},
+		Entry("empty", ""),
+		Entry("missing secret", "key=abc\n"),
+		Entry("invalid line", "key=abc\nsecret\n"),
+	)
+
+	It("should resolve the credentials with the files taking precedence", func() {
+		credentials, err := opnsense.LoadCredentials(opnsense.ClientConfig{
+			APIKey:          "plain-key",
+			APISecret:       "plain-secret",
+			CredentialsFile: write("apikey.txt", "key=file-key\nsecret=file-secret\n"),
+			APISecretFile:   write("secret", "dedicated-secret\n"),
+		})
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(credentials.APIKey).To(Equal("file-key"))
+		Expect(credentials.APISecret).To(Equal("dedicated-secret"))
+	})
+
+	It("should not resolve incomplete credentials", func() {
+		_, err := opnsense.LoadCredentials(opnsense.ClientConfig{
+			APIKeyFile: write("key", "key"),
+		})
+
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should authenticate with the rotated credentials from the files", func(ctx SpecContext) {
+		path := write("apikey.txt", "key=old\nsecret=old\n")
+
+		var username string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			username, _, _ = r.BasicAuth()
+
+			_, _ = w.Write([]byte(`{"rows":[{"name":"unbound","running":1}]}`))
+		}))
+		defer server.Close()
+
+		client, err := opnsense.NewClient(
+			&opnsense.ClientSvc{
+				Logger: fixtures.NewTestLogger(),
+			},
+			opnsense.ClientConfig{
+				Uri:             server.URL,
+				CredentialsFile: path,
+			},
+		)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(client.CheckUnboundService(ctx)).To(Succeed())
+		Expect(username).To(Equal("old"))
+
+		Expect(os.WriteFile(path, []byte("key=new\nsecret=new\n"), 0o600)).To(Succeed())
+		Expect(client.ReloadCredentials()).To(Succeed())
+
+		Expect(client.CheckUnboundService(ctx)).To(Succeed())
+		Expect(username).To(Equal("new"))
+
+		Expect(os.Remove(path)).To(Succeed())
+		Expect(client.ReloadCredentials()).ToNot(Succeed())
+
+		Expect(client.CheckUnboundService(ctx)).To(Succeed())
+		Expect(username).To(Equal("new"))
+	})
+
+	It("should not overwrite the credentials of a reconfiguration while reloading them", func(ctx SpecContext) {
+		path := write("apikey.txt", "key=old\nsecret=old\n")
+
+		var username string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			username, _, _ = r.BasicAuth()
+
+			_, _ = w.Write([]byte(`{"rows":[{"name":"unbound","running":1}]}`))
+		}))
+		defer server.Close()
+
+		client, err := opnsense.NewClient(
+			&opnsense.ClientSvc{
+				Logger: fixtures.NewTestLogger(),
+			},
+			opnsense.ClientConfig{
+				Uri:             server.URL,
+				CredentialsFile: path,
+			},
+		)
+		Expect(err).ToNot(HaveOccurred())
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+
+			// the credentials keep rotating in the file of the previous configuration
+			for i := range 100 {
+				_ = os.WriteFile(path, fmt.Appendf(nil, "key=rotated-%d\nsecret=rotated\n", i), 0o600)
+				_ = client.ReloadCredentials()
+			}
+		}()
+
+		Expect(client.Reconfigure(opnsense.ClientConfig{
+			Uri:       server.URL,
+			APIKey:    "new",
+			APISecret: "new",
+		})).To(Succeed())
+		Eventually(done).Should(BeClosed())
+
+		Expect(client.CheckUnboundService(ctx)).To(Succeed())
+		Expect(username).To(Equal("new"))
+	})
+})
@@ -0,0 +1,139 @@
+package opnsense
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// ErrUnboundNotRunning is returned when the Unbound service is not running on OPNsense.
+var ErrUnboundNotRunning = errors.New("unbound service is not running")
+
+// The kinds of the failures of the OPNsense API, which the APIError unwraps to, so that they can be checked with errors.Is.
+var (
+	ErrUnauthorized = errors.New("OPNsense API has rejected the credentials")
+	ErrForbidden    = errors.New("OPNsense API credentials are not allowed to access the endpoint")
+	ErrNotFound     = errors.New("OPNsense API has not found the resource")
+	ErrValidation   = errors.New("OPNsense API has rejected the request")
+	ErrServer       = errors.New("OPNsense API has failed")
+	ErrTransport    = errors.New("OPNsense API is not reachable")
+)
+
+// maxErrorBodyLength is the length of the response body that is kept in the errors.
+const maxErrorBodyLength = 512
+
+// APIError is returned for every failed request to the OPNsense API with the details of the failure,
+// which can be retrieved with errors.As while its kind is checked with errors.Is.
+type APIError struct {
+	// Kind is one of the ErrUnauthorized, ErrForbidden, ErrNotFound, ErrValidation, ErrServer or ErrTransport.
+	Kind     error
+	Method   string
+	Endpoint string
+	// StatusCode is zero when no response is received.
+	StatusCode int
+	// Body is the truncated response body of the unexpected status codes.
+	Body string
+	// Result is the result that OPNsense has responded with instead of the expected one.
+	Result string
+	// Fields are the validation messages of OPNsense by field.
+	Fields map[string]string
+	// Err is the underlying error, like the connection error.
+	Err error
+}
+
+func (e *APIError) Error() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%v: %s %s", e.Kind, e.Method, e.Endpoint)
+
+	if e.StatusCode > 0 && e.StatusCode != http.StatusOK {
+		fmt.Fprintf(&b, ": status code %d", e.StatusCode)
+	}
+
+	if e.Result != "" {
+		fmt.Fprintf(&b, ": result %s", e.Result)
+	}
+
+	if len(e.Fields) > 0 {
+		fields := make([]string, 0, len(e.Fields))
+		for _, field := range slices.Sorted(maps.Keys(e.Fields)) {
+			fields = append(fields, fmt.Sprintf("%s: %s", field, e.Fields[field]))
+		}
+
+		fmt.Fprintf(&b, ": %s", strings.Join(fields, "; "))
+	}
+
+	if e.Body != "" {
+		fmt.Fprintf(&b, ": %s", e.Body)
+	}
+
+	if e.Err != nil {
+		fmt.Fprintf(&b, ": %v", e.Err)
+	}
+
+	return b.String()
+}
+
+func (e *APIError) Unwrap() []error {
+	if e.Err == nil {
+		return []error{e.Kind}
+	}
+
+	return []error{e.Kind, e.Err}
+}
+
+// newStatusError creates the error for a response with an unexpected status code.
+func newStatusError(method string, endpoint string, status int, body []byte) *APIError {
+	return &APIError{
+		Kind:       statusKind(status),
+		Method:     method,
+		Endpoint:   endpoint,
+		StatusCode: status,
+		Body:       truncateBody(body),
+	}
+}
+
+// newResultError creates the error for a response that does not have the expected result, like the failed validations.
+func newResultError(method string, endpoint string, result string, fields map[string]string) *APIError {
+	kind := ErrValidation
+	if result == "not found" {
+		kind = ErrNotFound
+	}
+
+	return &APIError{
+		Kind:       kind,
+		Method:     method,
+		Endpoint:   endpoint,
+		StatusCode: http.StatusOK,
+		Result:     result,
+		Fields:     fields,
+	}
+}
+
+// statusKind returns the kind of the failure for the status code.
+func statusKind(status int) error {
+	switch {
+	case status == http.StatusUnauthorized:
+		return ErrUnauthorized
+	case status == http.StatusForbidden:
+		return ErrForbidden
+	case status == http.StatusNotFound:
+		return ErrNotFound
+	case status >= http.StatusInternalServerError:
+		return ErrServer
+	default:
+		return ErrValidation
+	}
+}
+
+func truncateBody(body []byte) string {
+	text := strings.Join(strings.Fields(string(body)), " ")
+	if len(text) <= maxErrorBodyLength {
+		return text
+	}
+
+	return strings.ToValidUTF8(text[:maxErrorBodyLength], "") + "..."
+}
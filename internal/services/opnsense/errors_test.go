@@ -0,0 +1,124 @@
+package opnsense_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/opnsense"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/test/fixtures"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Opnsense Errors", func() {
+	newClient := func(handler http.HandlerFunc) *opnsense.Client {
+		server := httptest.NewServer(handler)
+		DeferCleanup(server.Close)
+
+		client, err := opnsense.NewClient(
+			&opnsense.ClientSvc{
+				Logger: fixtures.NewTestLogger(),
+			},
+			opnsense.ClientConfig{
+				Uri:        server.URL,
+				APIKey:     "key",
+				APISecret:  "secret",
+				MaxRetries: 1,
+				MinBackoff: time.Millisecond,
+				MaxBackoff: time.Millisecond,
+			},
+		)
+		Expect(err).ToNot(HaveOccurred())
+
+		return client
+	}
+
+	DescribeTable("should return the kind of the failure with the response body", func(status int, kind error) {
+		client := newClient(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte(`{"message": "something went wrong"}`))
+		})
+
+		err := client.UnboundDeleteHostOverride(GinkgoT().Context(), "3f2504e0-4f89-11d3-9a0c-0305e82c3301")
+		Expect(err).To(MatchError(kind))
+
+		var apiErr *opnsense.APIError
+		Expect(errors.As(err, &apiErr)).To(BeTrue())
+		Expect(apiErr.StatusCode).To(Equal(status))
+		Expect(apiErr.Method).To(Equal(http.MethodPost))
+		Expect(apiErr.Endpoint).To(Equal("/unbound/settings/delHostOverride/3f2504e0-4f89-11d3-9a0c-0305e82c3301"))
+		Expect(apiErr.Body).To(Equal(`{"message": "something went wrong"}`))
+		Expect(err.Error()).To(ContainSubstring("something went wrong"))
+	},
+		Entry("unauthorized", http.StatusUnauthorized, opnsense.ErrUnauthorized),
+		Entry("forbidden", http.StatusForbidden, opnsense.ErrForbidden),
+		Entry("not found", http.StatusNotFound, opnsense.ErrNotFound),
+		Entry("bad request", http.StatusBadRequest, opnsense.ErrValidation),
+		Entry("server error after the retries", http.StatusInternalServerError, opnsense.ErrServer),
+	)
+
+	It("should return the validation messages by field", func(ctx SpecContext) {
+		client := newClient(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"result":"failed","validations":{"host.server":"A valid IP address is required.","host.hostname":"Invalid hostname."}}`))
+		})
+
+		_, err := client.UnboundCreateHostOverride(ctx, &opnsense.UnboundHostOverride{})
+		Expect(err).To(MatchError(opnsense.ErrValidation))
+
+		var apiErr *opnsense.APIError
+		Expect(errors.As(err, &apiErr)).To(BeTrue())
+		Expect(apiErr.Result).To(Equal("failed"))
+		Expect(apiErr.Fields).To(HaveKeyWithValue("host.server", "A valid IP address is required."))
+		Expect(err.Error()).To(Equal("OPNsense API has rejected the request: POST /unbound/settings/addHostOverride: result failed: host.hostname: Invalid hostname.; host.server: A valid IP address is required."))
+	})
+
+	It("should return not found when the record to delete is missing", func(ctx SpecContext) {
+		client := newClient(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"result":"not found"}`))
+		})
+
+		Expect(client.UnboundDeleteHostOverride(ctx, "3f2504e0-4f89-11d3-9a0c-0305e82c3301")).To(MatchError(opnsense.ErrNotFound))
+	})
+
+	It("should truncate the long response bodies", func(ctx SpecContext) {
+		client := newClient(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+			_, _ = w.Write([]byte(strings.Repeat("a", 4096)))
+		})
+
+		err := client.ReconfigureService(ctx)
+
+		var apiErr *opnsense.APIError
+		Expect(errors.As(err, &apiErr)).To(BeTrue())
+		Expect(len(apiErr.Body)).To(BeNumerically("<", 1024))
+		Expect(apiErr.Body).To(HaveSuffix("..."))
+	})
+
+	It("should return a transport error when the API is not reachable", func(ctx SpecContext) {
+		server := httptest.NewServer(http.NotFoundHandler())
+		server.Close()
+
+		client, err := opnsense.NewClient(
+			&opnsense.ClientSvc{
+				Logger: fixtures.NewTestLogger(),
+			},
+			opnsense.ClientConfig{
+				Uri:       server.URL,
+				APIKey:    "key",
+				APISecret: "secret",
+			},
+		)
+		Expect(err).ToNot(HaveOccurred())
+
+		err = client.CheckUnboundService(ctx)
+		Expect(err).To(MatchError(opnsense.ErrTransport))
+
+		var apiErr *opnsense.APIError
+		Expect(errors.As(err, &apiErr)).To(BeTrue())
+		Expect(apiErr.StatusCode).To(BeZero())
+		Expect(apiErr.Err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,43 @@
+package opnsense
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/health"
+)
+
+// HealthCheck checks with a single request whether OPNsense is reachable, the credentials are accepted and the Unbound service is running.
+func HealthCheck(client ClientAdapter) health.CheckFunc {
+	return func(ctx context.Context, report health.ReportFunc) {
+		err := client.CheckUnboundService(ctx)
+
+		switch {
+		case err == nil:
+			report(health.ComponentOpnsense, nil)
+			report(health.ComponentCredentials, nil)
+			report(health.ComponentUnbound, nil)
+		case errors.Is(err, ErrUnboundNotRunning):
+			report(health.ComponentOpnsense, nil)
+			report(health.ComponentCredentials, nil)
+			report(health.ComponentUnbound, err)
+		case errors.Is(err, ErrUnauthorized), errors.Is(err, ErrForbidden):
+			report(health.ComponentOpnsense, nil)
+			report(health.ComponentCredentials, err)
+			report(health.ComponentUnbound, fmt.Errorf("can not be checked with the rejected credentials: %w", err))
+		case errors.As(err, new(*APIError)) && !errors.Is(err, ErrTransport):
+			report(health.ComponentOpnsense, err)
+			report(health.ComponentCredentials, nil)
+			report(health.ComponentUnbound, fmt.Errorf("can not be checked while the OPNsense API is failing: %w", err))
+		default:
+			if !errors.Is(err, ErrTransport) {
+				err = fmt.Errorf("%w: %w", ErrTransport, err)
+			}
+
+			report(health.ComponentOpnsense, err)
+			report(health.ComponentCredentials, err)
+			report(health.ComponentUnbound, err)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package opnsense_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/health"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/opnsense"
+	mockservices "github.com/cenk1cenk2/external-dns-webhook-opnsense/test/mocks/services"
+	"github.com/stretchr/testify/mock"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("HealthCheck", func() {
+	DescribeTable("should report the components from the state of the Unbound service", func(err error, expected map[string]bool) {
+		client := mockservices.NewMockClientAdapter(GinkgoT())
+		client.EXPECT().CheckUnboundService(mock.Anything).Return(err).Once()
+
+		reported := map[string]bool{}
+		opnsense.HealthCheck(client)(context.Background(), func(component string, err error) {
+			reported[component] = err == nil
+		})
+
+		Expect(reported).To(Equal(expected))
+	},
+		Entry("running", nil, map[string]bool{
+			health.ComponentOpnsense:    true,
+			health.ComponentCredentials: true,
+			health.ComponentUnbound:     true,
+		}),
+		Entry("not running", opnsense.ErrUnboundNotRunning, map[string]bool{
+			health.ComponentOpnsense:    true,
+			health.ComponentCredentials: true,
+			health.ComponentUnbound:     false,
+		}),
+		Entry("unauthorized", &opnsense.APIError{Kind: opnsense.ErrUnauthorized, StatusCode: http.StatusUnauthorized}, map[string]bool{
+			health.ComponentOpnsense:    true,
+			health.ComponentCredentials: false,
+			health.ComponentUnbound:     false,
+		}),
+		Entry("server error", &opnsense.APIError{Kind: opnsense.ErrServer, StatusCode: http.StatusBadGateway}, map[string]bool{
+			health.ComponentOpnsense:    false,
+			health.ComponentCredentials: true,
+			health.ComponentUnbound:     false,
+		}),
+		Entry("not found", &opnsense.APIError{Kind: opnsense.ErrNotFound, StatusCode: http.StatusNotFound}, map[string]bool{
+			health.ComponentOpnsense:    false,
+			health.ComponentCredentials: true,
+			health.ComponentUnbound:     false,
+		}),
+		Entry("unreachable", &opnsense.APIError{Kind: opnsense.ErrTransport, Err: errors.New("connection refused")}, map[string]bool{
+			health.ComponentOpnsense:    false,
+			health.ComponentCredentials: false,
+			health.ComponentUnbound:     false,
+		}),
+		Entry("unknown", errors.New("connection refused"), map[string]bool{
+			health.ComponentOpnsense:    false,
+			health.ComponentCredentials: false,
+			health.ComponentUnbound:     false,
+		}),
+	)
+})
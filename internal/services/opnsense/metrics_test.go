@@ -0,0 +1,63 @@
+package opnsense_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/opnsense"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/test/fixtures"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+var _ = Describe("Opnsense Metrics", func() {
+	It("should record the requests and the retries per endpoint", func(ctx SpecContext) {
+		var calls atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if calls.Add(1) == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+
+				return
+			}
+
+			_, _ = w.Write([]byte(`{"result":"deleted"}`))
+		}))
+		defer server.Close()
+
+		metrics := services.NewMetrics()
+		client, err := opnsense.NewClient(
+			&opnsense.ClientSvc{
+				Logger:  fixtures.NewTestLogger(),
+				Metrics: metrics,
+			},
+			opnsense.ClientConfig{
+				Uri:        server.URL,
+				APIKey:     "key",
+				APISecret:  "secret",
+				MaxRetries: 2,
+				MinBackoff: time.Millisecond,
+				MaxBackoff: time.Millisecond,
+			},
+		)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(client.UnboundDeleteHostOverride(ctx, "3f2504e0-4f89-11d3-9a0c-0305e82c3301")).To(Succeed())
+
+		Expect(testutil.GatherAndCompare(metrics.Registry, strings.NewReader(`
+# HELP external_dns_opnsense_opnsense_requests_total Number of the requests to the OPNsense API by endpoint, method and status.
+# TYPE external_dns_opnsense_opnsense_requests_total counter
+external_dns_opnsense_opnsense_requests_total{endpoint="/unbound/settings/delHostOverride/{uuid}",method="POST",status="200"} 1
+# HELP external_dns_opnsense_opnsense_retries_total Number of the retried requests to the OPNsense API by endpoint and method.
+# TYPE external_dns_opnsense_opnsense_retries_total counter
+external_dns_opnsense_opnsense_retries_total{endpoint="/unbound/settings/delHostOverride/{uuid}",method="POST"} 1
+`),
+			"external_dns_opnsense_opnsense_requests_total",
+			"external_dns_opnsense_opnsense_retries_total",
+		)).To(Succeed())
+	})
+})
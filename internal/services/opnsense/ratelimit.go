@@ -0,0 +1,58 @@
+package opnsense
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+)
+
+const (
+	RateLimiterDefault     = "default"
+	RateLimiterReconfigure = "reconfigure"
+)
+
+const endpointReconfigure = "/unbound/service/reconfigure"
+
+// NewRateLimiter creates a token bucket that allows the given requests per second with the burst,
+// where a zero limit does not limit the requests at all.
+func NewRateLimiter(limit float64, burst int) *rate.Limiter {
+	if limit <= 0 {
+		return rate.NewLimiter(rate.Inf, 0)
+	}
+
+	return rate.NewLimiter(rate.Limit(limit), max(burst, 1))
+}
+
+// waitRateLimit blocks until the rate limiter of the endpoint allows the request, or the context is cancelled.
+// Reconfiguring the service is limited separately, since it is much heavier on the firewall than the other requests.
+// The retries of a request are not limited again, since they are already spaced by the backoff.
+func (c *Client) waitRateLimit(ctx context.Context, endpoint string) (time.Duration, error) {
+	name := RateLimiterDefault
+	c.mu.RLock()
+	limiter := c.limiter
+	if endpoint == endpointReconfigure {
+		name = RateLimiterReconfigure
+		limiter = c.reconfigureLimiter
+	}
+	c.mu.RUnlock()
+
+	start := time.Now()
+	err := limiter.Wait(ctx)
+	wait := time.Since(start)
+
+	c.metrics.ObserveRateLimitWait(name, wait)
+
+	if err != nil {
+		return wait, fmt.Errorf("failed to wait for the %s rate limiter: %w", name, err)
+	}
+
+	if wait >= time.Millisecond {
+		c.requestLog(ctx).With(zap.Duration("rate_limit_wait", wait)).
+			Debugf("Waited for the %s rate limiter before the request: %s", name, endpointLabel(endpoint))
+	}
+
+	return wait, nil
+}
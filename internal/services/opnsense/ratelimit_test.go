@@ -0,0 +1,131 @@
+package opnsense_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"time"
+
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/opnsense"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/test/fixtures"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+var _ = Describe("Opnsense Rate Limit", func() {
+	var (
+		server  *httptest.Server
+		calls   atomic.Int32
+		metrics *services.Metrics
+	)
+
+	BeforeEach(func() {
+		calls.Store(0)
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+
+			_, _ = w.Write([]byte(`{"result":"deleted","status":"ok"}`))
+		}))
+		DeferCleanup(server.Close)
+
+		metrics = services.NewMetrics()
+	})
+
+	newClient := func(conf opnsense.ClientConfig) *opnsense.Client {
+		conf.Uri = server.URL
+		conf.APIKey = "key"
+		conf.APISecret = "secret"
+
+		client, err := opnsense.NewClient(
+			&opnsense.ClientSvc{
+				Logger:  fixtures.NewTestLogger(),
+				Metrics: metrics,
+			},
+			conf,
+		)
+		Expect(err).ToNot(HaveOccurred())
+
+		return client
+	}
+
+	It("should not limit the requests without a rate limit", func(ctx SpecContext) {
+		client := newClient(opnsense.ClientConfig{})
+
+		start := time.Now()
+		for range 5 {
+			Expect(client.UnboundDeleteHostOverride(ctx, "3f2504e0-4f89-11d3-9a0c-0305e82c3301")).To(Succeed())
+		}
+
+		Expect(time.Since(start)).To(BeNumerically("<", 500*time.Millisecond))
+		Expect(calls.Load()).To(BeEquivalentTo(5))
+	})
+
+	It("should space the requests after the burst", func(ctx SpecContext) {
+		client := newClient(opnsense.ClientConfig{
+			RateLimit:      20,
+			RateLimitBurst: 2,
+		})
+
+		start := time.Now()
+		for range 4 {
+			Expect(client.UnboundDeleteHostOverride(ctx, "3f2504e0-4f89-11d3-9a0c-0305e82c3301")).To(Succeed())
+		}
+
+		// the first two requests use the burst, the rest wait 50ms each
+		Expect(time.Since(start)).To(BeNumerically(">=", 90*time.Millisecond))
+		Expect(testutil.CollectAndCount(metrics.Registry, "external_dns_opnsense_opnsense_rate_limit_wait_seconds")).To(Equal(1))
+	})
+
+	It("should limit the reconfigure requests separately", func(ctx SpecContext) {
+		client := newClient(opnsense.ClientConfig{
+			RateLimit:                 1,
+			RateLimitBurst:            1,
+			ReconfigureRateLimit:      1000,
+			ReconfigureRateLimitBurst: 1,
+		})
+
+		Expect(client.UnboundDeleteHostOverride(ctx, "3f2504e0-4f89-11d3-9a0c-0305e82c3301")).To(Succeed())
+
+		start := time.Now()
+		Expect(client.ReconfigureService(ctx)).To(Succeed())
+		Expect(time.Since(start)).To(BeNumerically("<", 500*time.Millisecond))
+	})
+
+	It("should cancel the waiting requests with the context", func(ctx SpecContext) {
+		client := newClient(opnsense.ClientConfig{
+			RateLimit:      0.1,
+			RateLimitBurst: 1,
+		})
+
+		Expect(client.UnboundDeleteHostOverride(ctx, "3f2504e0-4f89-11d3-9a0c-0305e82c3301")).To(Succeed())
+
+		cctx, cancel := context.WithCancel(ctx)
+		time.AfterFunc(50*time.Millisecond, cancel)
+
+		err := client.UnboundDeleteHostOverride(cctx, "3f2504e0-4f89-11d3-9a0c-0305e82c3301")
+		Expect(err).To(MatchError(context.Canceled))
+		Expect(calls.Load()).To(BeEquivalentTo(1))
+	})
+
+	It("should apply the new rate limit on reconfigure", func(ctx SpecContext) {
+		client := newClient(opnsense.ClientConfig{
+			RateLimit:      0.1,
+			RateLimitBurst: 1,
+		})
+
+		Expect(client.UnboundDeleteHostOverride(ctx, "3f2504e0-4f89-11d3-9a0c-0305e82c3301")).To(Succeed())
+
+		Expect(client.Reconfigure(opnsense.ClientConfig{
+			Uri:       server.URL,
+			APIKey:    "key",
+			APISecret: "secret",
+		})).To(Succeed())
+
+		start := time.Now()
+		Expect(client.UnboundDeleteHostOverride(ctx, "3f2504e0-4f89-11d3-9a0c-0305e82c3301")).To(Succeed())
+		Expect(time.Since(start)).To(BeNumerically("<", 500*time.Millisecond))
+	})
+})
@@ -0,0 +1,151 @@
+package opnsense
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/hashicorp/go-retryablehttp"
+	"go.uber.org/zap"
+)
+
+const endpointAddHostOverride = "/unbound/settings/addHostOverride"
+
+type retryPolicy int
+
+const (
+	// retryPolicyIdempotent retries every failure that can be temporary, since sending the request again has the same effect.
+	retryPolicyIdempotent retryPolicy = iota
+	// retryPolicyUnsent only retries the failures where the request has provably not been processed by OPNsense,
+	// since sending the request again would apply it twice.
+	retryPolicyUnsent
+)
+
+// retryPolicyContextKey keeps the retry policy of a request in the context, which is read by the retry check of the retrying client.
+type retryPolicyContextKey struct{}
+
+// retryPolicyOf returns the retry policy of the endpoint, where creating a host override is the only request that is not idempotent,
+// the updates and the deletes address the host override with its UUID and reconfiguring the service applies the same configuration again.
+func retryPolicyOf(method string, endpoint string) retryPolicy {
+	if method == http.MethodPost && endpoint == endpointAddHostOverride {
+		return retryPolicyUnsent
+	}
+
+	return retryPolicyIdempotent
+}
+
+// checkRetry decides whether the request is retried by the retrying client with the retry policy of the request.
+func checkRetry(ctx context.Context, resp *http.Response, err error) (bool, error) {
+	if policy, _ := ctx.Value(retryPolicyContextKey{}).(retryPolicy); policy == retryPolicyUnsent {
+		return retryUnsent(ctx, resp, err)
+	}
+
+	return retryablehttp.DefaultRetryPolicy(ctx, resp, err)
+}
+
+// retryUnsent retries the request when the connection could not be established or OPNsense has rejected it
+// before processing, a timeout or a server error is not retried since the request may have been applied already.
+func retryUnsent(ctx context.Context, resp *http.Response, err error) (bool, error) {
+	if ctx.Err() != nil {
+		return false, ctx.Err()
+	}
+
+	if err != nil {
+		var opErr *net.OpError
+		if errors.As(err, &opErr) && opErr.Op == "dial" {
+			return true, nil
+		}
+
+		return false, nil
+	}
+
+	return resp.StatusCode == http.StatusTooManyRequests, nil
+}
+
+// isAmbiguousFailure returns whether the request may have been applied by OPNsense even though it has failed,
+// like the connection dropping or timing out before the response, or a server error after saving the configuration.
+func isAmbiguousFailure(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	return errors.Is(err, ErrTransport) || errors.Is(err, ErrServer)
+}
+
+// createHostOverrideIdempotent creates the host override, and when the create fails in a way that it may have been saved anyway,
+// it looks up an identical host override and adopts its UUID before trying again, so that the retries can not duplicate the host override.
+func (c *Client) createHostOverrideIdempotent(ctx context.Context, override *UnboundHostOverride) (string, error) {
+	log := c.requestLog(ctx)
+
+	c.mu.RLock()
+	client := c.client
+	conf := c.conf
+	c.mu.RUnlock()
+
+	for attempt := 0; ; attempt++ {
+		uuid, err := c.createHostOverride(ctx, override)
+		if err == nil || !isAmbiguousFailure(err) || ctx.Err() != nil {
+			return uuid, err
+		}
+
+		uuid, searchErr := c.findHostOverride(ctx, override)
+		if searchErr != nil {
+			log.Warnf("Failed to look up the host override after a failed create, not retrying the create: %v", searchErr)
+
+			return "", err
+		}
+
+		if uuid != "" {
+			log.With(zap.String("uuid", uuid)).
+				Warnf("Adopted the host override that has been saved by a failed create: %s.%s", override.Hostname, override.Domain)
+
+			return uuid, nil
+		}
+
+		if attempt >= conf.MaxRetries {
+			return "", err
+		}
+
+		wait := client.Backoff(conf.MinBackoff, conf.MaxBackoff, attempt, nil)
+		log.Warnf("Create has failed without saving the host override, retrying in %s: %v", wait, err)
+
+		select {
+		case <-ctx.Done():
+			return "", err
+		case <-time.After(wait):
+		}
+	}
+}
+
+// findHostOverride returns the UUID of the host override that is identical to the given one, or an empty string if there is none.
+func (c *Client) findHostOverride(ctx context.Context, override *UnboundHostOverride) (string, error) {
+	phrase := override.Hostname
+	if phrase == "" {
+		phrase = override.Domain
+	}
+
+	res, err := c.UnboundSearchHostOverrides(ctx, &UnboundSearchHostOverrideRequest{
+		RowCount:     -1,
+		SearchPhrase: phrase,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	for _, row := range res.Rows {
+		if row.Hostname == override.Hostname &&
+			row.Domain == override.Domain &&
+			row.Type == override.Type &&
+			row.Server == override.Server &&
+			row.MXPriority == override.MXPriority &&
+			row.MXDomain == override.MXDomain &&
+			row.TxtData == override.TxtData &&
+			row.Description == override.Description {
+			return row.Id, nil
+		}
+	}
+
+	return "", nil
+}
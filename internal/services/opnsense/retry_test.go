@@ -0,0 +1,160 @@
+package opnsense_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/opnsense"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/test/fixtures"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Opnsense Retry", func() {
+	const uuid = "3f2504e0-4f89-11d3-9a0c-0305e82c3301"
+
+	var (
+		server   *httptest.Server
+		adds     atomic.Int32
+		searches atomic.Int32
+		deletes  atomic.Int32
+		saved    atomic.Bool
+		// addStatus returns the status of the nth create, where the create is saved when it is below 300 or 500.
+		addStatus func(n int32) int
+	)
+
+	override := &opnsense.UnboundHostOverride{
+		Enabled:     "1",
+		Hostname:    "test",
+		Domain:      "example.com",
+		Type:        "A",
+		Server:      "192.168.1.1",
+		Description: "external-dns",
+	}
+
+	BeforeEach(func() {
+		adds.Store(0)
+		searches.Store(0)
+		deletes.Store(0)
+		saved.Store(false)
+		addStatus = func(int32) int {
+			return http.StatusOK
+		}
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch {
+			case strings.HasSuffix(r.URL.Path, "/unbound/settings/addHostOverride"):
+				status := addStatus(adds.Add(1))
+				if status == http.StatusOK || status == http.StatusInternalServerError {
+					saved.Store(true)
+				}
+
+				w.WriteHeader(status)
+				_, _ = w.Write([]byte(`{"result":"saved","uuid":"` + uuid + `"}`))
+			case strings.HasSuffix(r.URL.Path, "/unbound/settings/search_host_override"):
+				searches.Add(1)
+
+				if !saved.Load() {
+					_, _ = w.Write([]byte(`{"rows":[]}`))
+
+					return
+				}
+
+				_, _ = w.Write([]byte(`{"total":1,"rows":[{"uuid":"` + uuid + `","enabled":"1","hostname":"test","domain":"example.com","rr":"A","server":"192.168.1.1","description":"external-dns"}]}`))
+			case strings.Contains(r.URL.Path, "/unbound/settings/delHostOverride"):
+				if deletes.Add(1) == 1 {
+					w.WriteHeader(http.StatusInternalServerError)
+
+					return
+				}
+
+				_, _ = w.Write([]byte(`{"result":"deleted"}`))
+			}
+		}))
+		DeferCleanup(server.Close)
+	})
+
+	newClient := func(retries int) *opnsense.Client {
+		client, err := opnsense.NewClient(
+			&opnsense.ClientSvc{
+				Logger:  fixtures.NewTestLogger(),
+				Metrics: services.NewMetrics(),
+			},
+			opnsense.ClientConfig{
+				Uri:        server.URL,
+				APIKey:     "key",
+				APISecret:  "secret",
+				MaxRetries: retries,
+				MinBackoff: time.Millisecond,
+				MaxBackoff: time.Millisecond,
+			},
+		)
+		Expect(err).ToNot(HaveOccurred())
+
+		return client
+	}
+
+	It("should adopt the host override that has been saved by a failed create", func(ctx SpecContext) {
+		addStatus = func(int32) int {
+			return http.StatusInternalServerError
+		}
+
+		id, err := newClient(3).UnboundCreateHostOverride(ctx, override)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(id).To(Equal(uuid))
+		Expect(adds.Load()).To(BeEquivalentTo(1))
+		Expect(searches.Load()).To(BeEquivalentTo(1))
+	})
+
+	It("should retry the create when the failed create has not been saved", func(ctx SpecContext) {
+		addStatus = func(n int32) int {
+			if n == 1 {
+				return http.StatusBadGateway
+			}
+
+			return http.StatusOK
+		}
+
+		id, err := newClient(3).UnboundCreateHostOverride(ctx, override)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(id).To(Equal(uuid))
+		Expect(adds.Load()).To(BeEquivalentTo(2))
+		Expect(searches.Load()).To(BeEquivalentTo(1))
+	})
+
+	It("should give up the create after the retries", func(ctx SpecContext) {
+		addStatus = func(int32) int {
+			return http.StatusBadGateway
+		}
+
+		_, err := newClient(2).UnboundCreateHostOverride(ctx, override)
+		Expect(err).To(MatchError(opnsense.ErrServer))
+		Expect(adds.Load()).To(BeEquivalentTo(3))
+		Expect(searches.Load()).To(BeEquivalentTo(3))
+	})
+
+	It("should retry the create that has been rejected before processing without looking it up", func(ctx SpecContext) {
+		addStatus = func(n int32) int {
+			if n == 1 {
+				return http.StatusTooManyRequests
+			}
+
+			return http.StatusOK
+		}
+
+		id, err := newClient(3).UnboundCreateHostOverride(ctx, override)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(id).To(Equal(uuid))
+		Expect(adds.Load()).To(BeEquivalentTo(2))
+		Expect(searches.Load()).To(BeZero())
+	})
+
+	It("should retry the idempotent requests on the server errors", func(ctx SpecContext) {
+		Expect(newClient(3).UnboundDeleteHostOverride(ctx, uuid)).To(Succeed())
+		Expect(deletes.Load()).To(BeEquivalentTo(2))
+	})
+})
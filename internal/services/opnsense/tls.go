@@ -0,0 +1,54 @@
+package opnsense
+
+import (
+	"crypto/tls"
+
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services"
+)
+
+// NewTLSConfig creates the TLS configuration for the connection to OPNsense, reading the certificates from the configured files.
+func NewTLSConfig(conf ClientConfig) (*tls.Config, error) {
+	version, err := services.ParseTLSVersion(conf.TLSMinVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig := &tls.Config{
+		//nolint:gosec // explicitly requested by the user
+		InsecureSkipVerify: conf.AllowInsecure,
+		ServerName:         conf.TLSServerName,
+		MinVersion:         version,
+	}
+
+	if conf.CAFile != "" {
+		pool, err := services.LoadCertPool(conf.CAFile, true)
+		if err != nil {
+			return nil, err
+		}
+
+		tlsConfig.RootCAs = pool
+	}
+
+	if conf.ClientCertFile != "" {
+		certificate, err := services.LoadCertificate(conf.ClientCertFile, conf.ClientKeyFile)
+		if err != nil {
+			return nil, err
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{*certificate}
+	}
+
+	return tlsConfig, nil
+}
+
+// tlsFiles returns the certificate files that are used in the TLS configuration.
+func tlsFiles(conf ClientConfig) []string {
+	files := []string{}
+	for _, file := range []string{conf.CAFile, conf.ClientCertFile, conf.ClientKeyFile} {
+		if file != "" {
+			files = append(files, file)
+		}
+	}
+
+	return files
+}
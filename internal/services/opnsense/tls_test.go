@@ -0,0 +1,108 @@
+package opnsense_test
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"os"
+
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/opnsense"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/test/fixtures"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Opnsense TLS", func() {
+	var server *httptest.Server
+	var serverCert, clientCert, clientKey string
+
+	BeforeEach(func() {
+		dir := GinkgoT().TempDir()
+
+		var serverKey string
+		serverCert, serverKey = fixtures.WriteSelfSignedCertificate(dir, "server")
+		clientCert, clientKey = fixtures.WriteSelfSignedCertificate(dir, "client")
+
+		certificate, err := services.LoadCertificate(serverCert, serverKey)
+		Expect(err).ToNot(HaveOccurred())
+		clientCAs, err := services.LoadCertPool(clientCert, false)
+		Expect(err).ToNot(HaveOccurred())
+
+		server = httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"rows":[{"name":"unbound","running":1}]}`))
+		}))
+		server.TLS = &tls.Config{
+			Certificates: []tls.Certificate{*certificate},
+			ClientCAs:    clientCAs,
+			ClientAuth:   tls.RequireAndVerifyClientCert,
+			MinVersion:   tls.VersionTLS12,
+		}
+		server.StartTLS()
+		DeferCleanup(server.Close)
+	})
+
+	newClient := func(conf opnsense.ClientConfig) *opnsense.Client {
+		conf.Uri = server.URL
+		conf.APIKey = "key"
+		conf.APISecret = "secret"
+
+		client, err := opnsense.NewClient(
+			&opnsense.ClientSvc{
+				Logger: fixtures.NewTestLogger(),
+			},
+			conf,
+		)
+		Expect(err).ToNot(HaveOccurred())
+
+		return client
+	}
+
+	It("should connect with the CA bundle and the client certificate", func(ctx SpecContext) {
+		client := newClient(opnsense.ClientConfig{
+			CAFile:         serverCert,
+			ClientCertFile: clientCert,
+			ClientKeyFile:  clientKey,
+			TLSServerName:  "server",
+			TLSMinVersion:  "1.2",
+		})
+
+		Expect(client.CheckUnboundService(ctx)).To(Succeed())
+	})
+
+	It("should not connect without the client certificate", func(ctx SpecContext) {
+		client := newClient(opnsense.ClientConfig{
+			CAFile: serverCert,
+		})
+
+		Expect(client.CheckUnboundService(ctx)).ToNot(Succeed())
+	})
+
+	It("should not connect without trusting the server certificate", func(ctx SpecContext) {
+		client := newClient(opnsense.ClientConfig{
+			ClientCertFile: clientCert,
+			ClientKeyFile:  clientKey,
+		})
+
+		Expect(client.CheckUnboundService(ctx)).ToNot(Succeed())
+	})
+
+	It("should keep the current certificates when the reload fails", func(ctx SpecContext) {
+		client := newClient(opnsense.ClientConfig{
+			CAFile:         serverCert,
+			ClientCertFile: clientCert,
+			ClientKeyFile:  clientKey,
+		})
+
+		Expect(os.WriteFile(clientCert, []byte("invalid"), 0o600)).To(Succeed())
+		Expect(client.ReloadTLS()).ToNot(Succeed())
+
+		Expect(client.CheckUnboundService(ctx)).To(Succeed())
+	})
+
+	It("should reject an unsupported TLS version", func() {
+		_, err := opnsense.NewTLSConfig(opnsense.ClientConfig{TLSMinVersion: "2.0"})
+
+		Expect(err).To(HaveOccurred())
+	})
+})
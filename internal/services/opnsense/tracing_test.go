@@ -0,0 +1,69 @@
+package opnsense_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"time"
+
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/opnsense"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/test/fixtures"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+var _ = Describe("Opnsense Tracing", func() {
+	It("should record a span for the request with the status and the retries", func(ctx SpecContext) {
+		recorder := tracetest.NewSpanRecorder()
+		previous := otel.GetTracerProvider()
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+		DeferCleanup(otel.SetTracerProvider, previous)
+		otel.SetTextMapPropagator(propagation.TraceContext{})
+
+		var calls atomic.Int32
+		var traceparent atomic.Value
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			traceparent.Store(r.Header.Get("traceparent"))
+
+			if calls.Add(1) == 1 {
+				w.WriteHeader(http.StatusBadGateway)
+
+				return
+			}
+
+			_, _ = w.Write([]byte(`{"status":"ok"}`))
+		}))
+		defer server.Close()
+
+		client, err := opnsense.NewClient(
+			&opnsense.ClientSvc{
+				Logger: fixtures.NewTestLogger(),
+			},
+			opnsense.ClientConfig{
+				Uri:        server.URL,
+				APIKey:     "key",
+				APISecret:  "secret",
+				MaxRetries: 2,
+				MinBackoff: time.Millisecond,
+				MaxBackoff: time.Millisecond,
+			},
+		)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(client.ReconfigureService(ctx)).To(Succeed())
+
+		spans := recorder.Ended()
+		Expect(spans).To(HaveLen(1))
+		Expect(spans[0].Name()).To(Equal("OPNsense POST /unbound/service/reconfigure"))
+		Expect(spans[0].Attributes()).To(ContainElements(
+			attribute.Int("http.response.status_code", http.StatusOK),
+			attribute.Int("http.request.resend_count", 1),
+		))
+		Expect(traceparent.Load()).To(ContainSubstring(spans[0].SpanContext().TraceID().String()))
+	})
+})
@@ -0,0 +1,439 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+)
+
+type ApprovalConfig struct {
+	// Domains are the domains of the records that are held for approval instead of being applied, disabled when empty.
+	Domains []string
+	// Directory is where the pending batches are persisted, so that they survive a restart and can be managed by the operator commands.
+	Directory string
+}
+
+// IsEnabled returns whether the changes of any domain are held for approval.
+func (c ApprovalConfig) IsEnabled() bool {
+	return len(c.Domains) > 0 && c.Directory != ""
+}
+
+// Validate checks whether the directory is set for the domains, which is not validated with a tag,
+// since the flags leave the domains as an empty slice rather than nil when they are not set.
+func (c ApprovalConfig) Validate() error {
+	if len(c.Domains) > 0 && c.Directory == "" {
+		return errors.New("approval directory is required with the approval domains")
+	}
+
+	return nil
+}
+
+// PendingBatch is a batch of changes that is held for approval instead of being applied.
+type PendingBatch struct {
+	ID        string        `json:"id"`
+	CreatedAt time.Time     `json:"created_at"`
+	Changes   *plan.Changes `json:"changes"`
+}
+
+// Diff returns the changes of the batch in the order that they are applied, prefixed with - for the deletes,
+// ~ for the updates and + for the creates.
+func (b *PendingBatch) Diff() []string {
+	return diffChanges(b.Changes)
+}
+
+func diffChanges(changes *plan.Changes) []string {
+	lines := []string{}
+
+	for _, ep := range changes.Delete {
+		lines = append(lines, fmt.Sprintf("- %s %s %s", ep.DNSName, ep.RecordType, strings.Join(ep.Targets, ",")))
+	}
+
+	for i, ep := range changes.UpdateNew {
+		old := []string{}
+		if i < len(changes.UpdateOld) {
+			old = changes.UpdateOld[i].Targets
+		}
+
+		lines = append(lines, fmt.Sprintf("~ %s %s %s -> %s", ep.DNSName, ep.RecordType, strings.Join(old, ","), strings.Join(ep.Targets, ",")))
+	}
+
+	for _, ep := range changes.Create {
+		lines = append(lines, fmt.Sprintf("+ %s %s %s", ep.DNSName, ep.RecordType, strings.Join(ep.Targets, ",")))
+	}
+
+	return lines
+}
+
+// approvedContextKey marks the batch in the context as approved, so that it is applied rather than being held again.
+type approvedContextKey struct{}
+
+// partialContextKey marks the batch in the context as a part of the desired state, like the changes to heal the drift,
+// which does not supersede the pending batches like a plan from external-dns does.
+type partialContextKey struct{}
+
+// holdForApproval splits the changes of the domains that require approval off the batch and persists them as a pending batch,
+// returning the rest of the changes to be applied. A newer plan from external-dns expires the pending batches,
+// since they are computed against a state that is not current anymore, unless it holds the same changes again.
+// It is called with the pending batches locked.
+func (p *Provider) holdForApproval(ctx context.Context, id string, changes *plan.Changes) (*plan.Changes, error) {
+	conf := p.Config.Approval
+	if !conf.IsEnabled() {
+		return changes, nil
+	}
+
+	if approved, _ := ctx.Value(approvedContextKey{}).(bool); approved {
+		return changes, nil
+	}
+
+	filter := endpoint.NewDomainFilter(conf.Domains)
+	held := &plan.Changes{}
+	rest := &plan.Changes{}
+
+	for _, ep := range changes.Create {
+		if filter.Match(ep.DNSName) {
+			held.Create = append(held.Create, ep)
+		} else {
+			rest.Create = append(rest.Create, ep)
+		}
+	}
+
+	// UpdateOld and UpdateNew are parallel arrays, so the pairs are held together
+	for i, newEp := range changes.UpdateNew {
+		oldEp := changes.UpdateOld[i]
+
+		if filter.Match(oldEp.DNSName) || filter.Match(newEp.DNSName) {
+			held.UpdateOld = append(held.UpdateOld, oldEp)
+			held.UpdateNew = append(held.UpdateNew, newEp)
+		} else {
+			rest.UpdateOld = append(rest.UpdateOld, oldEp)
+			rest.UpdateNew = append(rest.UpdateNew, newEp)
+		}
+	}
+
+	for _, ep := range changes.Delete {
+		if filter.Match(ep.DNSName) {
+			held.Delete = append(held.Delete, ep)
+		} else {
+			rest.Delete = append(rest.Delete, ep)
+		}
+	}
+
+	partial, _ := ctx.Value(partialContextKey{}).(bool)
+	if !held.HasChanges() && partial {
+		return changes, nil
+	}
+
+	log := p.requestLog(ctx)
+
+	pending, err := p.loadPendingBatches()
+	if err != nil {
+		return nil, err
+	}
+
+	var kept *PendingBatch
+	for _, batch := range pending {
+		if held.HasChanges() && sameChanges(batch.Changes, held) {
+			kept = batch
+
+			continue
+		}
+
+		if partial {
+			continue
+		}
+
+		if err := p.removePendingBatch(batch.ID); err != nil {
+			return nil, err
+		}
+
+		log.Infof("Expired the pending batch %s, since it is superseded by a newer plan.", batch.ID)
+	}
+
+	switch {
+	case !held.HasChanges():
+	case kept != nil:
+		log.Debugf("Changes are already pending for approval in the batch %s.", kept.ID)
+	default:
+		kept = &PendingBatch{
+			ID:        id,
+			CreatedAt: time.Now(),
+			Changes:   held,
+		}
+
+		if err := p.savePendingBatch(kept); err != nil {
+			return nil, err
+		}
+
+		log.Infof(
+			"Holding %d creates, %d updates, %d deletes for approval in the pending batch %s.",
+			len(held.Create), len(held.UpdateNew), len(held.Delete), kept.ID,
+		)
+	}
+
+	p.observePendingBatches()
+
+	return rest, nil
+}
+
+// PendingBatches returns the batches that are held for approval, the oldest first.
+func (p *Provider) PendingBatches() ([]*PendingBatch, error) {
+	if p.Config.Approval.Directory == "" {
+		return nil, ErrApprovalDisabled
+	}
+
+	unlock, err := p.lockPendingBatches()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	return p.loadPendingBatches()
+}
+
+// PendingBatch returns the batch that is held for approval with the given identifier.
+func (p *Provider) PendingBatch(id string) (*PendingBatch, error) {
+	if p.Config.Approval.Directory == "" {
+		return nil, ErrApprovalDisabled
+	}
+
+	unlock, err := p.lockPendingBatches()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	return p.loadPendingBatch(id)
+}
+
+// ApproveBatch applies the batch that is held for approval, which goes through all the guards of applying a batch.
+// The batch stays pending when it fails to be applied, so that it can be approved again.
+func (p *Provider) ApproveBatch(ctx context.Context, id string) error {
+	if p.Config.Approval.Directory == "" {
+		return ErrApprovalDisabled
+	}
+
+	// the lock is held while applying, so that the batch is not expired by a plan or interleaved with another batch in the meantime,
+	// which also holds for the webhook server while the batch is approved with the operator commands
+	unlock, err := p.lockPendingBatches()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	batch, err := p.loadPendingBatch(id)
+	if err != nil {
+		return err
+	}
+
+	p.requestLog(ctx).Infof("Approved the pending batch %s.", batch.ID)
+
+	if err := p.ApplyChanges(context.WithValue(ctx, approvedContextKey{}, true), batch.Changes); err != nil {
+		return fmt.Errorf("failed to apply the pending batch %s: %w", batch.ID, err)
+	}
+
+	if err := p.removePendingBatch(batch.ID); err != nil {
+		return err
+	}
+
+	p.observePendingBatches()
+
+	return nil
+}
+
+// RejectBatch discards the batch that is held for approval.
+func (p *Provider) RejectBatch(ctx context.Context, id string) error {
+	if p.Config.Approval.Directory == "" {
+		return ErrApprovalDisabled
+	}
+
+	unlock, err := p.lockPendingBatches()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	batch, err := p.loadPendingBatch(id)
+	if err != nil {
+		return err
+	}
+
+	if err := p.removePendingBatch(batch.ID); err != nil {
+		return err
+	}
+
+	p.requestLog(ctx).Infof("Rejected the pending batch %s.", batch.ID)
+	p.observePendingBatches()
+
+	return nil
+}
+
+// lockPendingBatches serializes the access to the pending batches, with a lock file in their directory next to the lock of the process,
+// since the operator commands manage them from another process than the webhook server. It returns the function that releases the lock.
+func (p *Provider) lockPendingBatches() (func(), error) {
+	p.approvals.Lock()
+
+	if err := os.MkdirAll(p.Config.Approval.Directory, 0o700); err != nil {
+		p.approvals.Unlock()
+
+		return nil, fmt.Errorf("failed to create the directory of the pending batches: %w", err)
+	}
+
+	release, err := lockFile(filepath.Join(p.Config.Approval.Directory, ".lock"))
+	if err != nil {
+		p.approvals.Unlock()
+
+		return nil, fmt.Errorf("failed to lock the pending batches: %w", err)
+	}
+
+	return func() {
+		release()
+		p.approvals.Unlock()
+	}, nil
+}
+
+func (p *Provider) observePendingBatches() {
+	entries, err := os.ReadDir(p.Config.Approval.Directory)
+	if err != nil {
+		return
+	}
+
+	count := 0
+	for _, entry := range entries {
+		if isPendingBatchFile(entry.Name()) {
+			count++
+		}
+	}
+
+	p.Metrics.SetPendingBatches(count)
+}
+
+func (p *Provider) loadPendingBatches() ([]*PendingBatch, error) {
+	entries, err := os.ReadDir(p.Config.Approval.Directory)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read the pending batches: %w", err)
+	}
+
+	batches := []*PendingBatch{}
+	for _, entry := range entries {
+		if !isPendingBatchFile(entry.Name()) {
+			continue
+		}
+
+		batch, err := p.loadPendingBatch(strings.TrimSuffix(entry.Name(), ".json"))
+		if err != nil {
+			return nil, err
+		}
+
+		batches = append(batches, batch)
+	}
+
+	slices.SortFunc(batches, func(a, b *PendingBatch) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+
+	return batches, nil
+}
+
+func (p *Provider) loadPendingBatch(id string) (*PendingBatch, error) {
+	// the identifier is validated, since it ends up in a path
+	if _, err := hex.DecodeString(id); err != nil || id == "" {
+		return nil, fmt.Errorf("%w: %s", ErrPendingBatchNotFound, id)
+	}
+
+	data, err := os.ReadFile(p.pendingBatchPath(id))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrPendingBatchNotFound, id)
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read the pending batch %s: %w", id, err)
+	}
+
+	batch := &PendingBatch{}
+	if err := json.Unmarshal(data, batch); err != nil {
+		return nil, fmt.Errorf("failed to decode the pending batch %s: %w", id, err)
+	}
+
+	if batch.Changes == nil || len(batch.Changes.UpdateOld) != len(batch.Changes.UpdateNew) {
+		return nil, fmt.Errorf("failed to decode the pending batch %s: changes are malformed", id)
+	}
+
+	return batch, nil
+}
+
+// savePendingBatch writes the batch to a temporary file first, so that a batch is never read half written.
+func (p *Provider) savePendingBatch(batch *PendingBatch) error {
+	if err := os.MkdirAll(p.Config.Approval.Directory, 0o700); err != nil {
+		return fmt.Errorf("failed to create the directory of the pending batches: %w", err)
+	}
+
+	data, err := json.MarshalIndent(batch, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode the pending batch %s: %w", batch.ID, err)
+	}
+
+	file, err := os.CreateTemp(p.Config.Approval.Directory, ".pending-*")
+	if err != nil {
+		return fmt.Errorf("failed to write the pending batch %s: %w", batch.ID, err)
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+
+		return fmt.Errorf("failed to write the pending batch %s: %w", batch.ID, err)
+	}
+
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write the pending batch %s: %w", batch.ID, err)
+	}
+
+	if err := os.Rename(file.Name(), p.pendingBatchPath(batch.ID)); err != nil {
+		return fmt.Errorf("failed to write the pending batch %s: %w", batch.ID, err)
+	}
+
+	return nil
+}
+
+func (p *Provider) removePendingBatch(id string) error {
+	if err := os.Remove(p.pendingBatchPath(id)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to remove the pending batch %s: %w", id, err)
+	}
+
+	return nil
+}
+
+func (p *Provider) pendingBatchPath(id string) string {
+	return filepath.Join(p.Config.Approval.Directory, id+".json")
+}
+
+func isPendingBatchFile(name string) bool {
+	return strings.HasSuffix(name, ".json") && !strings.HasPrefix(name, ".")
+}
+
+// sameChanges returns whether the changes are the same, comparing them as they are persisted.
+func sameChanges(a, b *plan.Changes) bool {
+	x, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+
+	y, err := json.Marshal(b)
+	if err != nil {
+		return false
+	}
+
+	return bytes.Equal(x, y)
+}
@@ -0,0 +1,8 @@
+//go:build windows
+
+package provider
+
+// lockFile does not lock the file on windows, where the pending batches are only serialized within the process.
+func lockFile(string) (func(), error) {
+	return func() {}, nil
+}
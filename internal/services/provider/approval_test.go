@@ -0,0 +1,172 @@
+package provider_test
+
+import (
+	"context"
+
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/opnsense"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/provider"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/test/fixtures"
+	mockservices "github.com/cenk1cenk2/external-dns-webhook-opnsense/test/mocks/services"
+	"github.com/stretchr/testify/mock"
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Approval", func() {
+	var (
+		client *mockservices.MockClientAdapter
+		p      *provider.Provider
+	)
+
+	creates := func(targets ...string) *plan.Changes {
+		changes := &plan.Changes{}
+		for _, target := range targets {
+			changes.Create = append(changes.Create, endpoint.NewEndpoint("app.internal.example.com", endpoint.RecordTypeA, target))
+		}
+
+		return changes
+	}
+
+	BeforeEach(func() {
+		client = mockservices.NewMockClientAdapter(GinkgoT())
+
+		p = newProvider(&provider.ProviderSvc{Client: client}, provider.ProviderConfig{
+			Approval: provider.ApprovalConfig{
+				Domains:   []string{"internal.example.com"},
+				Directory: GinkgoT().TempDir(),
+			},
+		})
+	})
+
+	It("should hold the changes of the matching domains and apply the rest", func(ctx SpecContext) {
+		client.EXPECT().UnboundCreateHostOverride(mock.Anything, mock.MatchedBy(func(o *opnsense.UnboundHostOverride) bool {
+			return o.Hostname == "app" && o.Domain == "example.org"
+		})).Return("id-1", nil).Once()
+		client.EXPECT().ReconfigureService(mock.Anything).Return(nil).Once()
+
+		changes := creates("10.0.0.1")
+		changes.Create = append(changes.Create, endpoint.NewEndpoint("app.example.org", endpoint.RecordTypeA, "192.168.1.1"))
+		changes.UpdateOld = []*endpoint.Endpoint{
+			endpoint.NewEndpoint("db.internal.example.com", endpoint.RecordTypeA, "10.0.0.2").WithLabel(provider.EndpointLabelUUID.String(), "id-2"),
+		}
+		changes.UpdateNew = []*endpoint.Endpoint{endpoint.NewEndpoint("db.internal.example.com", endpoint.RecordTypeA, "10.0.0.3")}
+
+		Expect(p.ApplyChanges(ctx, changes)).To(Succeed())
+
+		batches, err := p.PendingBatches()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(batches).To(HaveLen(1))
+		Expect(batches[0].Diff()).To(Equal([]string{
+			"~ db.internal.example.com A 10.0.0.2 -> 10.0.0.3",
+			"+ app.internal.example.com A 10.0.0.1",
+		}))
+	})
+
+	It("should keep the pending batch when the same changes are sent again", func(ctx SpecContext) {
+		Expect(p.ApplyChanges(ctx, creates("10.0.0.1"))).To(Succeed())
+		Expect(p.ApplyChanges(ctx, creates("10.0.0.1"))).To(Succeed())
+
+		batches, err := p.PendingBatches()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(batches).To(HaveLen(1))
+	})
+
+	It("should expire the pending batch when a newer plan is sent", func(ctx SpecContext) {
+		Expect(p.ApplyChanges(ctx, creates("10.0.0.1"))).To(Succeed())
+		Expect(p.ApplyChanges(ctx, creates("10.0.0.2"))).To(Succeed())
+
+		batches, err := p.PendingBatches()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(batches).To(HaveLen(1))
+		Expect(batches[0].Diff()).To(Equal([]string{"+ app.internal.example.com A 10.0.0.2"}))
+	})
+
+	It("should apply the approved batch", func(ctx SpecContext) {
+		client.EXPECT().UnboundCreateHostOverride(mock.Anything, mock.MatchedBy(func(o *opnsense.UnboundHostOverride) bool {
+			return o.Hostname == "app" && o.Domain == "internal.example.com"
+		})).Return("id-1", nil).Once()
+		client.EXPECT().ReconfigureService(mock.Anything).Return(nil).Once()
+
+		Expect(p.ApplyChanges(ctx, creates("10.0.0.1"))).To(Succeed())
+
+		batches, err := p.PendingBatches()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(batches).To(HaveLen(1))
+
+		Expect(p.ApproveBatch(ctx, batches[0].ID)).To(Succeed())
+
+		batches, err = p.PendingBatches()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(batches).To(BeEmpty())
+	})
+
+	It("should discard the rejected batch", func(ctx SpecContext) {
+		Expect(p.ApplyChanges(ctx, creates("10.0.0.1"))).To(Succeed())
+
+		batches, err := p.PendingBatches()
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(p.RejectBatch(ctx, batches[0].ID)).To(Succeed())
+		Expect(p.RejectBatch(ctx, batches[0].ID)).To(MatchError(provider.ErrPendingBatchNotFound))
+		Expect(p.ApproveBatch(ctx, "../secret")).To(MatchError(provider.ErrPendingBatchNotFound))
+	})
+
+	It("should not interleave the batches with an approval from another process", func(ctx SpecContext) {
+		// another provider on the same directory stands for the webhook server, while the batch is approved with the operator commands
+		other := newProvider(&provider.ProviderSvc{Client: client}, p.Config)
+
+		Expect(other.ApplyChanges(ctx, creates("10.0.0.1"))).To(Succeed())
+
+		batches, err := p.PendingBatches()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(batches).To(HaveLen(1))
+
+		applying := make(chan struct{})
+		release := make(chan struct{})
+		client.EXPECT().UnboundCreateHostOverride(mock.Anything, mock.Anything).RunAndReturn(func(context.Context, *opnsense.UnboundHostOverride) (string, error) {
+			close(applying)
+			<-release
+
+			return "id-1", nil
+		}).Once()
+		client.EXPECT().ReconfigureService(mock.Anything).Return(nil).Once()
+
+		approved := make(chan error, 1)
+		go func() {
+			approved <- p.ApproveBatch(ctx, batches[0].ID)
+		}()
+		Eventually(applying).Should(BeClosed())
+
+		applied := make(chan error, 1)
+		go func() {
+			applied <- other.ApplyChanges(ctx, creates("10.0.0.2"))
+		}()
+		Consistently(applied, "100ms").ShouldNot(Receive())
+
+		close(release)
+		Eventually(approved).Should(Receive(BeNil()))
+		Eventually(applied).Should(Receive(BeNil()))
+
+		batches, err = other.PendingBatches()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(batches).To(HaveLen(1))
+		Expect(batches[0].Diff()).To(Equal([]string{"+ app.internal.example.com A 10.0.0.2"}))
+	})
+
+	It("should require the directory with the domains", func() {
+		svc := &provider.ProviderSvc{Logger: fixtures.NewTestLogger()}
+
+		_, err := provider.NewProvider(svc, provider.ProviderConfig{
+			Approval: provider.ApprovalConfig{Domains: []string{"internal.example.com"}},
+		})
+		Expect(err).To(MatchError(ContainSubstring("approval directory is required")))
+
+		_, err = provider.NewProvider(svc, provider.ProviderConfig{
+			Approval: provider.ApprovalConfig{Domains: []string{}},
+		})
+		Expect(err).ToNot(HaveOccurred())
+	})
+})
@@ -0,0 +1,49 @@
+package provider
+
+import (
+	"context"
+	"time"
+
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/opnsense"
+)
+
+type CacheConfig struct {
+	// TTL is how long the host overrides are served from the cache for the record queries, disabled when zero.
+	TTL time.Duration `validate:"gte=0"`
+}
+
+// searchHostOverrides returns the host overrides for the record queries, from the cache while it is fresh.
+// The cache is flushed when a batch of changes is applied, so that the guards decide on the current state.
+func (p *Provider) searchHostOverrides(ctx context.Context) (*opnsense.UnboundSearchHostOverrideResponse, error) {
+	ttl := p.Config.Cache.TTL
+	if ttl <= 0 {
+		return p.Client.UnboundSearchHostOverrides(ctx, nil)
+	}
+
+	p.cacheMu.Lock()
+	defer p.cacheMu.Unlock()
+
+	if p.cached != nil && time.Since(p.cachedAt) < ttl {
+		p.requestLog(ctx).Debugf("Serving the host overrides from the cache.")
+
+		return p.cached, nil
+	}
+
+	result, err := p.Client.UnboundSearchHostOverrides(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	p.cached = result
+	p.cachedAt = time.Now()
+
+	return result, nil
+}
+
+// FlushCache drops the cached host overrides, so that the next record query reaches OPNsense.
+func (p *Provider) FlushCache() {
+	p.cacheMu.Lock()
+	defer p.cacheMu.Unlock()
+
+	p.cached = nil
+}
@@ -0,0 +1,68 @@
+package provider_test
+
+import (
+	"time"
+
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/opnsense"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/provider"
+	mockservices "github.com/cenk1cenk2/external-dns-webhook-opnsense/test/mocks/services"
+	"github.com/stretchr/testify/mock"
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Cache", func() {
+	var (
+		client *mockservices.MockClientAdapter
+		p      *provider.Provider
+	)
+
+	rows := &opnsense.UnboundSearchHostOverrideResponse{
+		Rows: []opnsense.UnboundSearchHostOverrideItem{
+			{Id: "id-1", Enabled: "1", Hostname: "app", Domain: "example.com", Type: endpoint.RecordTypeA, Server: "192.168.1.1"},
+		},
+	}
+
+	BeforeEach(func() {
+		client = mockservices.NewMockClientAdapter(GinkgoT())
+
+		p = newProvider(&provider.ProviderSvc{Client: client}, provider.ProviderConfig{
+			Cache: provider.CacheConfig{TTL: time.Minute},
+		})
+	})
+
+	It("should serve the records from the cache until it is flushed", func(ctx SpecContext) {
+		client.EXPECT().UnboundSearchHostOverrides(mock.Anything, mock.Anything).Return(rows, nil).Twice()
+
+		for range 3 {
+			records, err := p.Records(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(records).To(HaveLen(1))
+		}
+
+		p.FlushCache()
+
+		_, err := p.Records(ctx)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("should flush the cache when a batch of changes is applied", func(ctx SpecContext) {
+		client.EXPECT().UnboundSearchHostOverrides(mock.Anything, mock.Anything).Return(rows, nil).Twice()
+		client.EXPECT().UnboundCreateHostOverride(mock.Anything, mock.Anything).Return("id-2", nil).Once()
+		client.EXPECT().ReconfigureService(mock.Anything).Return(nil).Once()
+
+		_, err := p.Records(ctx)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(p.ApplyChanges(ctx, &plan.Changes{
+			Create: []*endpoint.Endpoint{endpoint.NewEndpoint("web.example.com", endpoint.RecordTypeA, "192.168.1.2")},
+		})).To(Succeed())
+
+		_, err = p.Records(ctx)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+})
@@ -0,0 +1,199 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/opnsense"
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+)
+
+type DeletionConfig struct {
+	MaxDeletes          int     `validate:"gte=0"`
+	MaxDeletePercentage float64 `validate:"gte=0,lte=100"`
+	MinRecordsPerDomain int     `validate:"gte=0"`
+	// AllowMassDeletes skips the thresholds for all the batches, which is meant to be enabled for an intentional mass delete.
+	AllowMassDeletes bool
+}
+
+// IsEnabled returns whether any of the deletion thresholds is set.
+func (c DeletionConfig) IsEnabled() bool {
+	return c.MaxDeletes > 0 || c.MaxDeletePercentage > 0 || c.MinRecordsPerDomain > 0
+}
+
+// deletionOverrideContextKey marks the batch in the context as an intentional mass delete.
+type deletionOverrideContextKey struct{}
+
+// WithDeletionOverride marks the batch in the context as an intentional mass delete, like a pending batch that the operator
+// approves together with its deletes.
+func WithDeletionOverride(ctx context.Context) context.Context {
+	return context.WithValue(ctx, deletionOverrideContextKey{}, true)
+}
+
+// ArmDeletionOverride arms or disarms a single use override of the deletion thresholds at runtime, which lets the next batch
+// that exceeds the thresholds through, so that an intentional mass delete by external-dns does not require disabling them.
+func (p *Provider) ArmDeletionOverride(armed bool) {
+	if p.deletionOverride.Swap(armed) == armed {
+		return
+	}
+
+	if armed {
+		p.Log.Warnf("Deletion override is armed, the next batch that exceeds the deletion thresholds is applied.")
+	} else {
+		p.Log.Infof("Deletion override is disarmed.")
+	}
+}
+
+// IsDeletionOverrideArmed returns whether the next batch that exceeds the deletion thresholds is applied.
+func (p *Provider) IsDeletionOverrideArmed() bool {
+	return p.deletionOverride.Load()
+}
+
+// SetDeletionSafety replaces the deletion thresholds of the provider at runtime.
+func (p *Provider) SetDeletionSafety(conf DeletionConfig) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.Config.Deletion = conf
+}
+
+func (p *Provider) deletionConfig() DeletionConfig {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.Config.Deletion
+}
+
+// guardDeletion refuses the batch before any change is applied, when it deletes more records than the thresholds allow,
+// like a plan that deletes every record because of a broken source in external-dns.
+func (p *Provider) guardDeletion(ctx context.Context, changes *plan.Changes) error {
+	conf := p.deletionConfig()
+	deletes := len(changes.Delete)
+	if !conf.IsEnabled() || deletes == 0 {
+		return nil
+	}
+
+	log := p.requestLog(ctx)
+
+	if overridden, _ := ctx.Value(deletionOverrideContextKey{}).(bool); overridden || conf.AllowMassDeletes {
+		log.Warnf("Skipping the deletion thresholds for the batch with %d deletes, since they are overridden.", deletes)
+
+		return nil
+	}
+
+	errs := []error{}
+
+	if conf.MaxDeletes > 0 && deletes > conf.MaxDeletes {
+		errs = append(errs, fmt.Errorf("batch deletes %d records, more than the maximum of %d", deletes, conf.MaxDeletes))
+	}
+
+	if conf.MaxDeletePercentage > 0 || conf.MinRecordsPerDomain > 0 {
+		result, err := p.searchHostOverrides(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to query for host overrides to check the deletion thresholds: %w", err)
+		}
+
+		if managed := len(p.managedRows(result.Rows)); conf.MaxDeletePercentage > 0 && managed > 0 {
+			deleted := countManagedDeletes(changes)
+			percentage := float64(deleted) / float64(managed) * 100
+			if percentage > conf.MaxDeletePercentage {
+				errs = append(errs, fmt.Errorf(
+					"batch deletes %d of %d managed records (%.1f%%), more than the maximum of %.1f%%",
+					deleted, managed, percentage, conf.MaxDeletePercentage,
+				))
+			}
+		}
+
+		if conf.MinRecordsPerDomain > 0 {
+			errs = append(errs, checkRemainingRecords(conf.MinRecordsPerDomain, p.GetDomainFilter(), result.Rows, changes)...)
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	err := fmt.Errorf("%w: %w", ErrDeletionThreshold, errors.Join(errs...))
+
+	if p.deletionOverride.CompareAndSwap(true, false) {
+		log.Warnf("Applying the batch with %d deletes, since the deletion override is armed, which is now used up: %v", deletes, err)
+
+		return nil
+	}
+
+	log.Warnf("Refusing the batch: %v", err)
+
+	return err
+}
+
+// countManagedDeletes counts the host overrides that the batch deletes, in the same way as the managed records are counted,
+// where an endpoint is a host override per target and the registry records are left out.
+func countManagedDeletes(changes *plan.Changes) int {
+	deleted := 0
+	for _, ep := range changes.Delete {
+		if !isRegistryEndpoint(ep) {
+			deleted += max(len(ep.Targets), 1)
+		}
+	}
+
+	return deleted
+}
+
+// checkRemainingRecords checks the number of the address records in the domain filter that remain in every domain
+// that the batch deletes from. The registry records are not counted, since they come and go with the address records.
+func checkRemainingRecords(
+	minimum int,
+	filter endpoint.DomainFilterInterface,
+	rows []opnsense.UnboundSearchHostOverrideItem,
+	changes *plan.Changes,
+) []error {
+	remaining := map[string]int{}
+	deleted := map[string]bool{}
+
+	for _, row := range rows {
+		record := NewDnsRecord(row)
+		if (record.Type == endpoint.RecordTypeA || record.Type == endpoint.RecordTypeAAAA) && filter.Match(record.GetFQDN()) {
+			remaining[recordDomain(record.GetFQDN())]++
+		}
+	}
+
+	for _, ep := range changes.Delete {
+		if isAddressRecord(ep) {
+			remaining[recordDomain(ep.DNSName)] -= max(len(ep.Targets), 1)
+			deleted[recordDomain(ep.DNSName)] = true
+		}
+	}
+
+	for _, ep := range changes.Create {
+		if isAddressRecord(ep) {
+			remaining[recordDomain(ep.DNSName)] += len(ep.Targets)
+		}
+	}
+
+	errs := []error{}
+	for _, domain := range slices.Sorted(maps.Keys(deleted)) {
+		if count := max(remaining[domain], 0); count < minimum {
+			errs = append(errs, fmt.Errorf("batch leaves %d records in %s, less than the minimum of %d", count, domain, minimum))
+		}
+	}
+
+	return errs
+}
+
+func isAddressRecord(ep *endpoint.Endpoint) bool {
+	return ep.RecordType == endpoint.RecordTypeA || ep.RecordType == endpoint.RecordTypeAAAA
+}
+
+// recordDomain returns the domain of the host override, which is the name without its first label.
+func recordDomain(name string) string {
+	if _, domain, ok := strings.Cut(name, "."); ok {
+		return domain
+	}
+
+	return name
+}
@@ -0,0 +1,138 @@
+package provider_test
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/opnsense"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/provider"
+	mockservices "github.com/cenk1cenk2/external-dns-webhook-opnsense/test/mocks/services"
+	"github.com/stretchr/testify/mock"
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Deletion", func() {
+	var (
+		client *mockservices.MockClientAdapter
+		p      *provider.Provider
+	)
+
+	rows := &opnsense.UnboundSearchHostOverrideResponse{}
+	for i := range 4 {
+		rows.Rows = append(rows.Rows, opnsense.UnboundSearchHostOverrideItem{
+			Id:       fmt.Sprintf("id-%d", i),
+			Enabled:  "1",
+			Hostname: fmt.Sprintf("host%d", i),
+			Domain:   "example.com",
+			Type:     endpoint.RecordTypeA,
+			Server:   fmt.Sprintf("192.168.1.%d", i),
+		})
+	}
+
+	deletes := func(count int) *plan.Changes {
+		changes := &plan.Changes{}
+		for _, row := range rows.Rows[:count] {
+			changes.Delete = append(changes.Delete,
+				endpoint.NewEndpoint(row.Hostname+"."+row.Domain, row.Type, row.Server).
+					WithLabel(provider.EndpointLabelUUID.String(), row.Id),
+			)
+		}
+
+		return changes
+	}
+
+	BeforeEach(func() {
+		client = mockservices.NewMockClientAdapter(GinkgoT())
+
+		p = newProvider(&provider.ProviderSvc{Client: client}, provider.ProviderConfig{})
+	})
+
+	It("should refuse the batches with more deletes than the maximum", func(ctx SpecContext) {
+		p.SetDeletionSafety(provider.DeletionConfig{MaxDeletes: 2})
+
+		err := p.ApplyChanges(ctx, deletes(3))
+		Expect(err).To(MatchError(provider.ErrDeletionThreshold))
+		Expect(err.Error()).To(ContainSubstring("batch deletes 3 records, more than the maximum of 2"))
+	})
+
+	It("should refuse the batches that delete more than the percentage of the managed records", func(ctx SpecContext) {
+		p.SetDeletionSafety(provider.DeletionConfig{MaxDeletePercentage: 50})
+		client.EXPECT().UnboundSearchHostOverrides(mock.Anything, mock.Anything).Return(rows, nil).Once()
+
+		err := p.ApplyChanges(ctx, deletes(3))
+		Expect(err).To(MatchError(provider.ErrDeletionThreshold))
+		Expect(err.Error()).To(ContainSubstring("batch deletes 3 of 4 managed records (75.0%), more than the maximum of 50.0%"))
+	})
+
+	It("should refuse the batches that leave less than the minimum records in a domain", func(ctx SpecContext) {
+		p.SetDeletionSafety(provider.DeletionConfig{MinRecordsPerDomain: 2})
+		client.EXPECT().UnboundSearchHostOverrides(mock.Anything, mock.Anything).Return(rows, nil).Once()
+
+		err := p.ApplyChanges(ctx, deletes(3))
+		Expect(err).To(MatchError(provider.ErrDeletionThreshold))
+		Expect(err.Error()).To(ContainSubstring("batch leaves 1 records in example.com, less than the minimum of 2"))
+	})
+
+	It("should apply the batches within the thresholds", func(ctx SpecContext) {
+		p.SetDeletionSafety(provider.DeletionConfig{MaxDeletes: 2, MaxDeletePercentage: 50, MinRecordsPerDomain: 2})
+		client.EXPECT().UnboundSearchHostOverrides(mock.Anything, mock.Anything).Return(rows, nil).Once()
+		client.EXPECT().UnboundDeleteHostOverride(mock.Anything, mock.Anything).Return(nil).Twice()
+		client.EXPECT().ReconfigureService(mock.Anything).Return(nil).Once()
+
+		Expect(p.ApplyChanges(ctx, deletes(2))).To(Succeed())
+	})
+
+	It("should count only the managed records for the percentage", func(ctx SpecContext) {
+		p.Config.Ownership = provider.OwnershipConfig{DescriptionMarker: "managed"}
+		p.SetDeletionSafety(provider.DeletionConfig{MaxDeletePercentage: 40})
+
+		mixed := &opnsense.UnboundSearchHostOverrideResponse{Rows: slices.Clone(rows.Rows)}
+		mixed.Rows[0].Description = "managed"
+		mixed.Rows[1].Description = "managed"
+		mixed.Rows = append(mixed.Rows, opnsense.UnboundSearchHostOverrideItem{
+			Id:          "id-txt",
+			Enabled:     "1",
+			Domain:      "host0.example.com",
+			Type:        endpoint.RecordTypeTXT,
+			TxtData:     "heritage=external-dns,external-dns/owner=default",
+			Description: "managed",
+		})
+		client.EXPECT().UnboundSearchHostOverrides(mock.Anything, mock.Anything).Return(mixed, nil).Twice()
+
+		err := p.ApplyChanges(ctx, deletes(1))
+		Expect(err).To(MatchError(provider.ErrDeletionThreshold))
+		Expect(err.Error()).To(ContainSubstring("batch deletes 1 of 2 managed records (50.0%), more than the maximum of 40.0%"))
+	})
+
+	It("should apply the next batch that exceeds the thresholds when the override is armed", func(ctx SpecContext) {
+		p.SetDeletionSafety(provider.DeletionConfig{MaxDeletes: 1})
+		client.EXPECT().UnboundDeleteHostOverride(mock.Anything, mock.Anything).Return(nil).Twice()
+		client.EXPECT().ReconfigureService(mock.Anything).Return(nil).Once()
+
+		p.ArmDeletionOverride(true)
+		Expect(p.ApplyChanges(ctx, deletes(2))).To(Succeed())
+		Expect(p.IsDeletionOverrideArmed()).To(BeFalse())
+
+		Expect(p.ApplyChanges(ctx, deletes(2))).To(MatchError(provider.ErrDeletionThreshold))
+	})
+
+	It("should apply the batches that are marked as an intentional mass delete", func(ctx SpecContext) {
+		p.SetDeletionSafety(provider.DeletionConfig{MaxDeletes: 1})
+		client.EXPECT().UnboundDeleteHostOverride(mock.Anything, mock.Anything).Return(nil).Times(4)
+		client.EXPECT().ReconfigureService(mock.Anything).Return(nil).Once()
+
+		Expect(p.ApplyChanges(provider.WithDeletionOverride(ctx), deletes(4))).To(Succeed())
+	})
+
+	It("should apply the batches when the mass deletes are allowed", func(ctx SpecContext) {
+		p.SetDeletionSafety(provider.DeletionConfig{MaxDeletes: 1, AllowMassDeletes: true})
+		client.EXPECT().UnboundDeleteHostOverride(mock.Anything, mock.Anything).Return(nil).Twice()
+		client.EXPECT().ReconfigureService(mock.Anything).Return(nil).Once()
+
+		Expect(p.ApplyChanges(ctx, deletes(2))).To(Succeed())
+	})
+})
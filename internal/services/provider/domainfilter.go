@@ -13,8 +13,8 @@ type DomainFilter struct {
 type DomainFilterConfig struct {
 	DomainFilter         []string
 	ExcludeDomains       []string
-	RegexDomainFilter    string
-	RegexDomainExclusion string
+	RegexDomainFilter    string `validate:"omitempty,regexp"`
+	RegexDomainExclusion string `validate:"omitempty,regexp"`
 }
 
 var _ endpoint.DomainFilterInterface = (*DomainFilter)(nil)
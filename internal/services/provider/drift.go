@@ -0,0 +1,398 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/opnsense"
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+)
+
+const (
+	DriftKindMissing    = "missing"
+	DriftKindModified   = "modified"
+	DriftKindUnexpected = "unexpected"
+)
+
+type DriftConfig struct {
+	Interval time.Duration `validate:"gte=0"`
+	SelfHeal bool
+}
+
+// DriftRecord is a record on OPNsense that differs from the desired state that is last received from external-dns.
+type DriftRecord struct {
+	Kind       string   `json:"kind"`
+	DNSName    string   `json:"dns_name"`
+	RecordType string   `json:"record_type"`
+	Desired    []string `json:"desired,omitempty"`
+	Actual     []string `json:"actual,omitempty"`
+
+	desired *desiredRecord
+	actual  []*endpoint.Endpoint
+}
+
+type desiredKey struct {
+	DNSName    string
+	RecordType string
+}
+
+// desiredRecord keeps the targets of a name and type, since the endpoints with multiple targets are split into a record for each target.
+type desiredRecord struct {
+	Targets          []string
+	ProviderSpecific endpoint.ProviderSpecific
+}
+
+// setDesired replaces the desired state with the endpoints of the sources that are adjusted for external-dns.
+func (p *Provider) setDesired(endpoints []*endpoint.Endpoint) {
+	desired := map[desiredKey]*desiredRecord{}
+	for _, ep := range endpoints {
+		addDesired(desired, ep, ep.Targets)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.desired = desired
+}
+
+// applyDesired updates the desired state with a batch of changes that is applied, so that it does not drift until the next adjustment.
+func (p *Provider) applyDesired(changes *plan.Changes) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.desired == nil {
+		return
+	}
+
+	for _, ep := range changes.Delete {
+		removeDesired(p.desired, ep, ep.Targets)
+	}
+
+	for i, ep := range changes.UpdateNew {
+		if i < len(changes.UpdateOld) {
+			removeDesired(p.desired, changes.UpdateOld[i], changes.UpdateOld[i].Targets)
+		}
+		addDesired(p.desired, ep, ep.Targets)
+	}
+
+	for _, ep := range changes.Create {
+		addDesired(p.desired, ep, ep.Targets)
+	}
+}
+
+func addDesired(desired map[desiredKey]*desiredRecord, ep *endpoint.Endpoint, targets []string) {
+	if !isDriftTracked(ep) {
+		return
+	}
+
+	key := desiredKey{DNSName: ep.DNSName, RecordType: ep.RecordType}
+	record, ok := desired[key]
+	if !ok {
+		record = &desiredRecord{ProviderSpecific: ep.ProviderSpecific}
+		desired[key] = record
+	}
+
+	for _, target := range targets {
+		if !slices.Contains(record.Targets, target) {
+			record.Targets = append(record.Targets, target)
+		}
+	}
+}
+
+func removeDesired(desired map[desiredKey]*desiredRecord, ep *endpoint.Endpoint, targets []string) {
+	key := desiredKey{DNSName: ep.DNSName, RecordType: ep.RecordType}
+	record, ok := desired[key]
+	if !ok {
+		return
+	}
+
+	record.Targets = slices.DeleteFunc(record.Targets, func(target string) bool {
+		return slices.Contains(targets, target)
+	})
+
+	if len(record.Targets) == 0 {
+		delete(desired, key)
+	}
+}
+
+// isDriftTracked returns whether the endpoint is compared with the desired state, where the registry records of external-dns are skipped,
+// since they are generated while applying the changes and are never part of the desired state of the sources.
+func isDriftTracked(ep *endpoint.Endpoint) bool {
+	switch ep.RecordType {
+	case endpoint.RecordTypeA, endpoint.RecordTypeAAAA:
+		return true
+	case endpoint.RecordTypeTXT:
+		return !isRegistryEndpoint(ep)
+	default:
+		return false
+	}
+}
+
+// CheckDrift compares the records on OPNsense with the desired state that is last received from external-dns.
+// The records that are desired but do not exist are missing, the records with different targets are modified, and the records
+// of another type on a desired name, like an AAAA record that is added by hand next to a managed A record, are unexpected.
+// Nothing is reported before external-dns has sent the desired state for the first time.
+func (p *Provider) CheckDrift(ctx context.Context) (drift []DriftRecord, err error) {
+	defer func() {
+		p.Metrics.ObserveDriftCheck(err)
+	}()
+
+	p.mu.RLock()
+	if p.desired == nil {
+		p.mu.RUnlock()
+		p.Log.Debugf("Skipping the drift check, since the desired state has not been received yet.")
+
+		return nil, nil
+	}
+	filter := p.DomainFilter
+	desired := make(map[desiredKey]*desiredRecord, len(p.desired))
+	for key, record := range p.desired {
+		if !filter.Match(key.DNSName) {
+			continue
+		}
+
+		desired[key] = &desiredRecord{
+			Targets:          slices.Clone(record.Targets),
+			ProviderSpecific: record.ProviderSpecific,
+		}
+	}
+	p.mu.RUnlock()
+
+	records, err := p.Records(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch the records for the drift check: %w", err)
+	}
+
+	actual := map[desiredKey][]*endpoint.Endpoint{}
+	for _, ep := range records {
+		if !isDriftTracked(ep) {
+			continue
+		}
+
+		key := desiredKey{DNSName: ep.DNSName, RecordType: ep.RecordType}
+		actual[key] = append(actual[key], ep)
+	}
+	names := map[string]bool{}
+	drift = []DriftRecord{}
+	for key, record := range desired {
+		names[key.DNSName] = true
+		eps := actual[key]
+		targets := endpointTargets(eps)
+
+		switch {
+		case len(eps) == 0:
+			drift = append(drift, DriftRecord{
+				Kind:       DriftKindMissing,
+				DNSName:    key.DNSName,
+				RecordType: key.RecordType,
+				Desired:    record.Targets,
+				desired:    record,
+			})
+		case !sameTargets(record.Targets, targets):
+			drift = append(drift, DriftRecord{
+				Kind:       DriftKindModified,
+				DNSName:    key.DNSName,
+				RecordType: key.RecordType,
+				Desired:    record.Targets,
+				Actual:     targets,
+				desired:    record,
+				actual:     eps,
+			})
+		}
+	}
+
+	for key, eps := range actual {
+		if _, ok := desired[key]; ok || !names[key.DNSName] || key.RecordType == endpoint.RecordTypeTXT {
+			continue
+		}
+
+		drift = append(drift, DriftRecord{
+			Kind:       DriftKindUnexpected,
+			DNSName:    key.DNSName,
+			RecordType: key.RecordType,
+			Actual:     endpointTargets(eps),
+			actual:     eps,
+		})
+	}
+
+	slices.SortFunc(drift, func(a, b DriftRecord) int {
+		return strings.Compare(a.DNSName+" "+a.RecordType, b.DNSName+" "+b.RecordType)
+	})
+
+	counts := map[string]int{DriftKindMissing: 0, DriftKindModified: 0, DriftKindUnexpected: 0}
+	for _, d := range drift {
+		counts[d.Kind]++
+
+		p.Log.Warnf("Drift detected, %s record: %s (%s), desired %v, actual %v", d.Kind, d.DNSName, d.RecordType, d.Desired, d.Actual)
+	}
+	p.Metrics.SetDrift(counts)
+
+	if len(drift) == 0 {
+		p.Log.Debugf("No drift detected from the desired state.")
+	}
+
+	return drift, nil
+}
+
+// HealDrift applies the changes that bring the missing and modified records back to the desired state, through the same path as external-dns.
+// The unexpected records are left as they are, since they are not managed by external-dns. The records are only recreated or deleted when
+// their registry record exists, since a record without it is not owned by external-dns, which recreates both on its next sync.
+func (p *Provider) HealDrift(ctx context.Context, drift []DriftRecord) error {
+	changes := &plan.Changes{}
+
+	result, err := p.searchHostOverrides(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to query for host overrides to heal the drift: %w", err)
+	}
+	registered := p.registeredSetIdentifiers(result.Rows)
+
+	for _, d := range drift {
+		switch d.Kind {
+		case DriftKindMissing:
+			if targets := p.registeredTargets(d, d.desired.Targets, registered); len(targets) > 0 {
+				changes.Create = append(changes.Create, newDriftEndpoint(d, targets))
+			}
+		case DriftKindModified:
+			targets := []string{}
+			for _, ep := range d.actual {
+				if slices.ContainsFunc(ep.Targets, func(target string) bool {
+					return !slices.Contains(d.desired.Targets, target)
+				}) {
+					// an override without a registry record may have been added by hand, so it is left reported as drift
+					if registered[ep.SetIdentifier] {
+						changes.Delete = append(changes.Delete, ep)
+					} else {
+						p.Log.Warnf(
+							"Skipping healing the %s record: %s (%s) -> %v with id %s, since it does not have a registry record.",
+							d.Kind, d.DNSName, d.RecordType, ep.Targets, ep.Labels[EndpointLabelUUID.String()],
+						)
+					}
+
+					continue
+				}
+
+				targets = append(targets, ep.Targets...)
+			}
+
+			missing := slices.DeleteFunc(slices.Clone(d.desired.Targets), func(target string) bool {
+				return slices.Contains(targets, target)
+			})
+			if missing = p.registeredTargets(d, missing, registered); len(missing) > 0 {
+				changes.Create = append(changes.Create, newDriftEndpoint(d, missing))
+			}
+		}
+	}
+
+	if !changes.HasChanges() {
+		return nil
+	}
+
+	p.Log.Infof("Healing the drift: %d creates, %d deletes", len(changes.Create), len(changes.Delete))
+
+	// healing the drift is not a plan of external-dns, so it does not expire the pending batches
+	if err := p.ApplyChanges(context.WithValue(ctx, partialContextKey{}, true), changes); err != nil {
+		return fmt.Errorf("failed to heal the drift: %w", err)
+	}
+
+	return nil
+}
+
+// registeredSetIdentifiers returns the set identifiers of the records that have a registry record, with the owner when it is configured.
+func (p *Provider) registeredSetIdentifiers(rows []opnsense.UnboundSearchHostOverrideItem) map[string]bool {
+	owner := p.Config.Ownership.OwnerID
+
+	registered := map[string]bool{}
+	for _, row := range rows {
+		if row.Type != endpoint.RecordTypeTXT {
+			continue
+		}
+
+		labels, err := endpoint.NewLabelsFromStringPlain(row.TxtData)
+		if err != nil || (owner != "" && labels[endpoint.OwnerLabelKey] != owner) {
+			continue
+		}
+
+		if id := labels[EndpointLabelSetIdentifier.String()]; id != "" {
+			registered[id] = true
+		}
+	}
+
+	return registered
+}
+
+// registeredTargets returns the targets of the drifted record that have a registry record, skipping the rest.
+func (p *Provider) registeredTargets(d DriftRecord, targets []string, registered map[string]bool) []string {
+	return slices.DeleteFunc(slices.Clone(targets), func(target string) bool {
+		records, err := NewDnsRecordsFromEndpoint(endpoint.NewEndpoint(d.DNSName, d.RecordType, target))
+		if err == nil && registered[records[0].GenerateSetIdentifier()] {
+			return false
+		}
+
+		p.Log.Warnf("Skipping healing the %s record: %s (%s) -> %s, since its registry record does not exist anymore.", d.Kind, d.DNSName, d.RecordType, target)
+
+		return true
+	})
+}
+
+func newDriftEndpoint(d DriftRecord, targets []string) *endpoint.Endpoint {
+	ep := endpoint.NewEndpoint(d.DNSName, d.RecordType, targets...)
+	ep.ProviderSpecific = d.desired.ProviderSpecific
+
+	return ep
+}
+
+// RunDriftCheck periodically checks the drift from the desired state, and heals it when enabled, until the context is cancelled.
+func (p *Provider) RunDriftCheck(ctx context.Context) {
+	conf := p.Config.Drift
+	if conf.Interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(conf.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			drift, err := p.CheckDrift(ctx)
+			if err != nil {
+				p.Log.Warnf("Failed to check the drift: %v", err)
+
+				continue
+			}
+
+			if !conf.SelfHeal || len(drift) == 0 {
+				continue
+			}
+
+			if err := p.HealDrift(ctx, drift); errors.Is(err, ErrBatchInProgress) {
+				p.Log.Debugf("Skipping healing the drift, since a batch of changes is being applied.")
+			} else if err != nil {
+				p.Log.Warnf("Failed to heal the drift: %v", err)
+			}
+		}
+	}
+}
+
+func endpointTargets(eps []*endpoint.Endpoint) []string {
+	targets := []string{}
+	for _, ep := range eps {
+		targets = append(targets, ep.Targets...)
+	}
+
+	return targets
+}
+
+func sameTargets(a []string, b []string) bool {
+	a = slices.Sorted(slices.Values(a))
+	b = slices.Sorted(slices.Values(b))
+
+	return slices.Equal(a, b)
+}
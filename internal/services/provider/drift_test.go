@@ -0,0 +1,162 @@
+package provider_test
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/opnsense"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/provider"
+	mockservices "github.com/cenk1cenk2/external-dns-webhook-opnsense/test/mocks/services"
+	"github.com/stretchr/testify/mock"
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Drift", func() {
+	var (
+		client *mockservices.MockClientAdapter
+		p      *provider.Provider
+	)
+
+	rows := &opnsense.UnboundSearchHostOverrideResponse{
+		Rows: []opnsense.UnboundSearchHostOverrideItem{
+			{Id: "id-1", Enabled: "1", Hostname: "app", Domain: "example.com", Type: endpoint.RecordTypeA, Server: "192.168.1.1"},
+			{Id: "id-9", Enabled: "1", Hostname: "app", Domain: "example.com", Type: endpoint.RecordTypeA, Server: "192.168.1.9"},
+			{Id: "id-6", Enabled: "1", Hostname: "app", Domain: "example.com", Type: endpoint.RecordTypeAAAA, Server: "fd00::1"},
+			{Id: "id-t", Enabled: "1", Hostname: "a-app", Domain: "example.com", Type: endpoint.RecordTypeTXT, TxtData: `"heritage=external-dns,external-dns/owner=default"`},
+			{Id: "id-o", Enabled: "1", Hostname: "other", Domain: "example.com", Type: endpoint.RecordTypeA, Server: "192.168.1.5"},
+		},
+	}
+
+	BeforeEach(func() {
+		client = mockservices.NewMockClientAdapter(GinkgoT())
+
+		p = newProvider(&provider.ProviderSvc{Client: client}, provider.ProviderConfig{})
+	})
+
+	adjust := func() {
+		_, err := p.AdjustEndpoints([]*endpoint.Endpoint{
+			endpoint.NewEndpoint("app.example.com", endpoint.RecordTypeA, "192.168.1.1", "192.168.1.2"),
+			endpoint.NewEndpoint("missing.example.com", endpoint.RecordTypeA, "192.168.1.3"),
+		})
+		Expect(err).ToNot(HaveOccurred())
+	}
+
+	It("should not check the drift before the desired state is received", func(ctx SpecContext) {
+		drift, err := p.CheckDrift(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(drift).To(BeEmpty())
+	})
+
+	It("should report the missing, modified and unexpected records", func(ctx SpecContext) {
+		client.EXPECT().UnboundSearchHostOverrides(mock.Anything, mock.Anything).Return(rows, nil).Once()
+		adjust()
+
+		drift, err := p.CheckDrift(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(drift).To(HaveLen(3))
+
+		Expect(drift[0].Kind).To(Equal(provider.DriftKindModified))
+		Expect(drift[0].DNSName).To(Equal("app.example.com"))
+		Expect(drift[0].RecordType).To(Equal(endpoint.RecordTypeA))
+		Expect(drift[0].Desired).To(ConsistOf("192.168.1.1", "192.168.1.2"))
+		Expect(drift[0].Actual).To(ConsistOf("192.168.1.1", "192.168.1.9"))
+
+		Expect(drift[1].Kind).To(Equal(provider.DriftKindUnexpected))
+		Expect(drift[1].RecordType).To(Equal(endpoint.RecordTypeAAAA))
+
+		Expect(drift[2].Kind).To(Equal(provider.DriftKindMissing))
+		Expect(drift[2].DNSName).To(Equal("missing.example.com"))
+	})
+
+	It("should not report drift for the applied changes", func(ctx SpecContext) {
+		client.EXPECT().UnboundSearchHostOverrides(mock.Anything, mock.Anything).Return(rows, nil).Once()
+		client.EXPECT().UnboundCreateHostOverride(mock.Anything, mock.Anything).Return("id-6", nil).Once()
+		client.EXPECT().ReconfigureService(mock.Anything).Return(nil).Once()
+		_, err := p.AdjustEndpoints([]*endpoint.Endpoint{
+			endpoint.NewEndpoint("app.example.com", endpoint.RecordTypeA, "192.168.1.1", "192.168.1.9"),
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(p.ApplyChanges(ctx, &plan.Changes{
+			Create: []*endpoint.Endpoint{endpoint.NewEndpoint("app.example.com", endpoint.RecordTypeAAAA, "fd00::1")},
+		})).To(Succeed())
+
+		drift, err := p.CheckDrift(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(drift).To(BeEmpty())
+	})
+
+	registry := func(name string, target string) opnsense.UnboundSearchHostOverrideItem {
+		records, err := provider.NewDnsRecordsFromEndpoint(endpoint.NewEndpoint(name+".example.com", endpoint.RecordTypeA, target))
+		Expect(err).ToNot(HaveOccurred())
+
+		return opnsense.UnboundSearchHostOverrideItem{
+			Id:       "id-r-" + name,
+			Enabled:  "1",
+			Hostname: "a-" + name,
+			Domain:   "example.com",
+			Type:     endpoint.RecordTypeTXT,
+			TxtData:  fmt.Sprintf(`"heritage=external-dns,external-dns/owner=default,external-dns/set-identifier=%s"`, records[0].GenerateSetIdentifier()),
+		}
+	}
+
+	It("should heal the missing and modified records and leave the unexpected records", func(ctx SpecContext) {
+		client.EXPECT().UnboundSearchHostOverrides(mock.Anything, mock.Anything).Return(rows, nil).Once()
+		adjust()
+
+		drift, err := p.CheckDrift(ctx)
+		Expect(err).ToNot(HaveOccurred())
+
+		client.EXPECT().UnboundSearchHostOverrides(mock.Anything, mock.Anything).Return(&opnsense.UnboundSearchHostOverrideResponse{
+			Rows: append(slices.Clone(rows.Rows), registry("app", "192.168.1.2"), registry("missing", "192.168.1.3")),
+		}, nil).Once()
+
+		// the override of 192.168.1.9 does not have a registry record, so it is kept
+		client.EXPECT().UnboundCreateHostOverride(mock.Anything, mock.MatchedBy(func(o *opnsense.UnboundHostOverride) bool {
+			return o.Hostname == "app" && o.Server == "192.168.1.2"
+		})).Return("id-2", nil).Once()
+		client.EXPECT().UnboundCreateHostOverride(mock.Anything, mock.MatchedBy(func(o *opnsense.UnboundHostOverride) bool {
+			return o.Hostname == "missing" && o.Server == "192.168.1.3"
+		})).Return("id-3", nil).Once()
+		client.EXPECT().ReconfigureService(mock.Anything).Return(nil).Once()
+
+		Expect(p.HealDrift(ctx, drift)).To(Succeed())
+		client.AssertNotCalled(GinkgoT(), "UnboundDeleteHostOverride", mock.Anything, "id-9")
+	})
+
+	It("should delete the modified records with a registry record", func(ctx SpecContext) {
+		client.EXPECT().UnboundSearchHostOverrides(mock.Anything, mock.Anything).Return(rows, nil).Once()
+		adjust()
+
+		drift, err := p.CheckDrift(ctx)
+		Expect(err).ToNot(HaveOccurred())
+
+		client.EXPECT().UnboundSearchHostOverrides(mock.Anything, mock.Anything).Return(&opnsense.UnboundSearchHostOverrideResponse{
+			Rows: append(slices.Clone(rows.Rows), registry("app", "192.168.1.9")),
+		}, nil).Once()
+
+		client.EXPECT().UnboundDeleteHostOverride(mock.Anything, "id-9").Return(nil).Once()
+		client.EXPECT().ReconfigureService(mock.Anything).Return(nil).Once()
+
+		Expect(p.HealDrift(ctx, drift)).To(Succeed())
+	})
+
+	It("should not recreate the records without a registry record", func(ctx SpecContext) {
+		client.EXPECT().UnboundSearchHostOverrides(mock.Anything, mock.Anything).Return(rows, nil).Twice()
+		_, err := p.AdjustEndpoints([]*endpoint.Endpoint{
+			endpoint.NewEndpoint("missing.example.com", endpoint.RecordTypeA, "192.168.1.3"),
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		drift, err := p.CheckDrift(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(drift).To(HaveLen(1))
+		Expect(drift[0].Kind).To(Equal(provider.DriftKindMissing))
+
+		Expect(p.HealDrift(ctx, drift)).To(Succeed())
+	})
+})
@@ -0,0 +1,66 @@
+package provider
+
+import (
+	"errors"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+var (
+	// ErrInvalidEndpoint is matched by the failures of the endpoints that can not be turned into records.
+	ErrInvalidEndpoint = errors.New("invalid endpoint")
+	// ErrBatchInProgress is returned when the changes are applied while another batch is still being applied.
+	ErrBatchInProgress = errors.New("another batch of changes is being applied")
+	// ErrUnmanagedRecord is returned when a host override that is not proven to be managed would be changed.
+	ErrUnmanagedRecord = errors.New("host override is not managed by external-dns")
+	// ErrProtectedRecord is returned when a host override with a protected name would be changed.
+	ErrProtectedRecord = errors.New("host override is protected")
+	// ErrDeletionThreshold is returned when a batch deletes more records than the deletion thresholds allow.
+	ErrDeletionThreshold = errors.New("deletion threshold is exceeded")
+	// ErrReadOnly is matched by the batches of changes that are rejected while the read-only mode is enabled.
+	ErrReadOnly = errors.New("provider is in read-only mode")
+	// ErrChangeFreeze is matched by the batches of changes that are rejected in a change freeze window.
+	ErrChangeFreeze = errors.New("change freeze window is active")
+	// ErrChangesDiscarded is matched by the batches of changes that are acknowledged without being applied while the changes are frozen.
+	ErrChangesDiscarded = errors.New("changes are discarded")
+	// ErrApprovalDisabled is returned when the pending batches are managed without a directory to persist them.
+	ErrApprovalDisabled = errors.New("approval of the changes is not enabled")
+	// ErrPendingBatchNotFound is returned when there is no pending batch with the given identifier.
+	ErrPendingBatchNotFound = errors.New("pending batch is not found")
+)
+
+// EndpointError is the failure of the change of a single endpoint, so that it can be reported per endpoint.
+type EndpointError struct {
+	DNSName    string
+	RecordType string
+	// Invalid is set when the endpoint itself is invalid, rather than failing to be applied.
+	Invalid bool
+	Err     error
+}
+
+func newEndpointError(ep *endpoint.Endpoint, err error) *EndpointError {
+	return &EndpointError{
+		DNSName:    ep.DNSName,
+		RecordType: ep.RecordType,
+		Err:        err,
+	}
+}
+
+func newInvalidEndpointError(ep *endpoint.Endpoint, err error) *EndpointError {
+	e := newEndpointError(ep, err)
+	e.Invalid = true
+
+	return e
+}
+
+func (e *EndpointError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *EndpointError) Unwrap() error {
+	return e.Err
+}
+
+func (e *EndpointError) Is(target error) bool {
+	return e.Invalid && target == ErrInvalidEndpoint
+}
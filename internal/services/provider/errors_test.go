@@ -0,0 +1,68 @@
+package provider_test
+
+import (
+	"context"
+	"errors"
+
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/provider"
+	mockservices "github.com/cenk1cenk2/external-dns-webhook-opnsense/test/mocks/services"
+	"github.com/stretchr/testify/mock"
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Errors", func() {
+	var (
+		client *mockservices.MockClientAdapter
+		p      *provider.Provider
+	)
+
+	BeforeEach(func() {
+		client = mockservices.NewMockClientAdapter(GinkgoT())
+
+		p = newProvider(&provider.ProviderSvc{Client: client}, provider.ProviderConfig{})
+	})
+
+	It("should reject a batch while another batch is being applied", func(ctx SpecContext) {
+		release := make(chan struct{})
+		deleting := make(chan struct{})
+		client.EXPECT().UnboundDeleteHostOverride(mock.Anything, "id-A").RunAndReturn(func(context.Context, string) error {
+			close(deleting)
+			<-release
+
+			return nil
+		}).Once()
+		client.EXPECT().ReconfigureService(mock.Anything).Return(nil).Once()
+
+		done := make(chan error)
+		go func() {
+			done <- p.ApplyChanges(ctx, &plan.Changes{
+				Delete: []*endpoint.Endpoint{
+					endpoint.NewEndpoint("example.com", endpoint.RecordTypeA, "192.168.1.1").
+						WithLabel(provider.EndpointLabelUUID.String(), "id-A"),
+				},
+			})
+		}()
+
+		Eventually(deleting).Should(BeClosed())
+		Expect(p.ApplyChanges(ctx, &plan.Changes{})).To(MatchError(provider.ErrBatchInProgress))
+
+		close(release)
+		Eventually(done).Should(Receive(BeNil()))
+	})
+
+	It("should report the invalid endpoints with their names", func(ctx SpecContext) {
+		_, err := p.AdjustEndpoints([]*endpoint.Endpoint{
+			endpoint.NewEndpoint("example.com", "SRV", "0 5 5060 sip.example.com"),
+		})
+		Expect(err).To(MatchError(provider.ErrInvalidEndpoint))
+
+		var e *provider.EndpointError
+		Expect(errors.As(err, &e)).To(BeTrue())
+		Expect(e.DNSName).To(Equal("example.com"))
+		Expect(e.RecordType).To(Equal("SRV"))
+	})
+})
@@ -0,0 +1,225 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/health"
+	"github.com/robfig/cron/v3"
+)
+
+const (
+	// FreezeModeReject rejects the batches of changes while the changes are frozen, so that external-dns retries them.
+	FreezeModeReject = "reject"
+	// FreezeModeDiscard acknowledges the batches of changes without applying them while the changes are frozen.
+	FreezeModeDiscard = "discard"
+)
+
+type FreezeConfig struct {
+	// ReadOnly rejects or discards the batches of changes while the records are still served.
+	ReadOnly bool
+	// Mode is how the batches of changes are handled while the changes are frozen.
+	Mode string `validate:"omitempty,oneof=reject discard"`
+	// Windows are the change freeze windows in the form of "<cron expression> for <duration>".
+	Windows []string
+}
+
+// Validate returns an error when any of the change freeze windows can not be parsed.
+func (c FreezeConfig) Validate() error {
+	_, err := parseFreezeWindows(c.Windows)
+
+	return err
+}
+
+// FreezeWindow is a recurring window where the changes are frozen, starting on the schedule and lasting for the duration.
+type FreezeWindow struct {
+	Spec     string
+	Duration time.Duration
+
+	schedule cron.Schedule
+}
+
+// ParseFreezeWindow parses a change freeze window in the form of "<cron expression> for <duration>",
+// like "0 18 * * FRI for 62h", where the cron expression can start with CRON_TZ= for the time zone.
+func ParseFreezeWindow(spec string) (*FreezeWindow, error) {
+	expr, duration, ok := strings.Cut(spec, " for ")
+	if !ok {
+		return nil, fmt.Errorf("invalid change freeze window %q: expected <cron expression> for <duration>", spec)
+	}
+
+	schedule, err := cron.ParseStandard(strings.TrimSpace(expr))
+	if err != nil {
+		return nil, fmt.Errorf("invalid change freeze window %q: %w", spec, err)
+	}
+
+	d, err := time.ParseDuration(strings.TrimSpace(duration))
+	if err != nil {
+		return nil, fmt.Errorf("invalid change freeze window %q: %w", spec, err)
+	} else if d <= 0 {
+		return nil, fmt.Errorf("invalid change freeze window %q: duration must be positive", spec)
+	}
+
+	return &FreezeWindow{
+		Spec:     spec,
+		Duration: d,
+		schedule: schedule,
+	}, nil
+}
+
+// ActiveUntil returns when the window ends, if it is active at the given time.
+// The overlapping occurrences of the window extend it, like an hourly schedule that lasts for two hours.
+func (w *FreezeWindow) ActiveUntil(now time.Time) (time.Time, bool) {
+	var until time.Time
+
+	for start := w.schedule.Next(now.Add(-w.Duration)); !start.IsZero() && !start.After(now); start = w.schedule.Next(start) {
+		until = start.Add(w.Duration)
+	}
+
+	return until, !until.IsZero()
+}
+
+func parseFreezeWindows(specs []string) ([]*FreezeWindow, error) {
+	windows := make([]*FreezeWindow, 0, len(specs))
+	for _, spec := range specs {
+		window, err := ParseFreezeWindow(spec)
+		if err != nil {
+			return nil, err
+		}
+
+		windows = append(windows, window)
+	}
+
+	return windows, nil
+}
+
+// FreezeError is returned when a batch of changes is not applied, since the changes are frozen.
+type FreezeError struct {
+	Reason string
+	// Until is when the change freeze window ends, which is zero for the read-only mode that lasts until it is disabled.
+	Until time.Time
+	// Discarded is set when the batch is acknowledged without being applied, rather than being rejected.
+	Discarded bool
+}
+
+func (e *FreezeError) Error() string {
+	return e.Reason
+}
+
+func (e *FreezeError) Is(target error) bool {
+	switch target {
+	case ErrChangesDiscarded:
+		return e.Discarded
+	case ErrReadOnly:
+		return !e.Discarded && e.Until.IsZero()
+	case ErrChangeFreeze:
+		return !e.Discarded && !e.Until.IsZero()
+	}
+
+	return false
+}
+
+// SetFreeze replaces the read-only mode and the change freeze windows at runtime.
+// The read-only mode is only replaced when it has changed in the configuration,
+// so that it is not reset by a reload while it is toggled at runtime.
+func (p *Provider) SetFreeze(conf FreezeConfig) error {
+	windows, err := parseFreezeWindows(conf.Windows)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	previous := p.Config.Freeze
+	p.Config.Freeze = conf
+	p.freezeWindows = windows
+	p.mu.Unlock()
+
+	if previous.ReadOnly != conf.ReadOnly {
+		p.readOnly.Store(conf.ReadOnly)
+	}
+
+	p.observeFreeze(time.Now(), p.Health.Set)
+
+	return nil
+}
+
+func (p *Provider) freezeConfig() (FreezeConfig, []*FreezeWindow) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.Config.Freeze, p.freezeWindows
+}
+
+// checkFreeze returns why the changes are frozen at the given time, which is nil when they are not.
+func (p *Provider) checkFreeze(now time.Time) *FreezeError {
+	conf, windows := p.freezeConfig()
+	discard := conf.Mode == FreezeModeDiscard
+
+	// the client skips the mutating requests in the dry run, so the batches are discarded rather than counted as applied
+	if p.isDryRun() {
+		return &FreezeError{
+			Reason:    "dry run is enabled",
+			Discarded: true,
+		}
+	}
+
+	if p.IsReadOnly() {
+		return &FreezeError{
+			Reason:    "read-only mode is enabled",
+			Discarded: discard,
+		}
+	}
+
+	for _, window := range windows {
+		if until, ok := window.ActiveUntil(now); ok {
+			return &FreezeError{
+				Reason:    fmt.Sprintf("change freeze window %q is active until %s", window.Spec, until.Format(time.RFC3339)),
+				Until:     until,
+				Discarded: discard,
+			}
+		}
+	}
+
+	return nil
+}
+
+// observeFreeze checks whether the changes are frozen, logging when it changes and reporting it for the readiness details.
+func (p *Provider) observeFreeze(now time.Time, report health.ReportFunc) *FreezeError {
+	freeze := p.checkFreeze(now)
+
+	reason := ""
+	if freeze != nil {
+		reason = freeze.Reason
+	}
+
+	p.freezeMu.Lock()
+	previous := p.freezeReason
+	p.freezeReason = reason
+	p.freezeMu.Unlock()
+
+	if previous != reason {
+		switch {
+		case freeze == nil:
+			p.Log.Infof("Changes are not frozen anymore, the batches of changes are applied.")
+		case freeze.Discarded:
+			p.Log.Warnf("Changes are frozen, the batches of changes are discarded: %s", reason)
+		default:
+			p.Log.Warnf("Changes are frozen, the batches of changes are rejected: %s", reason)
+		}
+	}
+
+	if freeze == nil {
+		report(health.ComponentChangeFreeze, nil)
+	} else {
+		report(health.ComponentChangeFreeze, freeze)
+	}
+
+	return freeze
+}
+
+// FreezeHealthCheck reports whether the changes are frozen, so that the change freeze windows are reported
+// in the readiness details as they start and end.
+func (p *Provider) FreezeHealthCheck(_ context.Context, report health.ReportFunc) {
+	p.observeFreeze(time.Now(), report)
+}
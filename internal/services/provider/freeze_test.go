@@ -0,0 +1,120 @@
+package provider_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/provider"
+	mockservices "github.com/cenk1cenk2/external-dns-webhook-opnsense/test/mocks/services"
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type dryRunClient struct {
+	*mockservices.MockClientAdapter
+}
+
+func (c *dryRunClient) IsDryRun() bool {
+	return true
+}
+
+var _ = Describe("Freeze", func() {
+	var p *provider.Provider
+
+	changes := func() *plan.Changes {
+		return &plan.Changes{
+			Create: []*endpoint.Endpoint{endpoint.NewEndpoint("web.example.com", endpoint.RecordTypeA, "192.168.1.2")},
+		}
+	}
+
+	BeforeEach(func() {
+		p = newProvider(&provider.ProviderSvc{Client: mockservices.NewMockClientAdapter(GinkgoT())}, provider.ProviderConfig{})
+	})
+
+	DescribeTable("should parse the change freeze windows",
+		func(spec string, valid bool) {
+			_, err := provider.ParseFreezeWindow(spec)
+			if valid {
+				Expect(err).ToNot(HaveOccurred())
+			} else {
+				Expect(err).To(HaveOccurred())
+			}
+		},
+		Entry("weekly", "0 18 * * FRI for 62h", true),
+		Entry("time zone", "CRON_TZ=Europe/Berlin 0 22 * * * for 8h", true),
+		Entry("missing duration", "0 18 * * FRI", false),
+		Entry("invalid expression", "0 18 * * SOMEDAY for 1h", false),
+		Entry("invalid duration", "0 18 * * FRI for a while", false),
+		Entry("negative duration", "0 18 * * FRI for -1h", false),
+	)
+
+	It("should tell whether the window is active", func() {
+		window, err := provider.ParseFreezeWindow("CRON_TZ=UTC 0 18 * * FRI for 62h")
+		Expect(err).ToNot(HaveOccurred())
+
+		// 2026-10-17 is a Saturday
+		until, ok := window.ActiveUntil(time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC))
+		Expect(ok).To(BeTrue())
+		Expect(until).To(BeTemporally("==", time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)))
+
+		_, ok = window.ActiveUntil(time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC))
+		Expect(ok).To(BeFalse())
+	})
+
+	It("should reject the batches of changes in a change freeze window", func(ctx SpecContext) {
+		Expect(p.SetFreeze(provider.FreezeConfig{Windows: []string{"* * * * * for 1h"}})).To(Succeed())
+
+		err := p.ApplyChanges(ctx, changes())
+		Expect(err).To(MatchError(provider.ErrChangeFreeze))
+		Expect(err).ToNot(MatchError(provider.ErrReadOnly))
+	})
+
+	It("should discard the batches of changes in the discard mode", func(ctx SpecContext) {
+		Expect(p.SetFreeze(provider.FreezeConfig{ReadOnly: true, Mode: provider.FreezeModeDiscard})).To(Succeed())
+
+		Expect(p.ApplyChanges(ctx, changes())).To(MatchError(provider.ErrChangesDiscarded))
+	})
+
+	It("should discard the batches of changes in the dry run and audit them as a dry run", func(ctx SpecContext) {
+		path := filepath.Join(GinkgoT().TempDir(), "audit.log")
+		audit, err := services.NewAudit(services.AuditConfig{Sink: "file", File: path})
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(audit.Close)
+
+		// the mock fails on any request, since nothing is applied in the dry run
+		p = newProvider(&provider.ProviderSvc{Client: &dryRunClient{mockservices.NewMockClientAdapter(GinkgoT())}, Audit: audit}, provider.ProviderConfig{})
+
+		err = p.ApplyChanges(ctx, changes())
+		Expect(err).To(MatchError(provider.ErrChangesDiscarded))
+		Expect(err.Error()).To(Equal("dry run is enabled"))
+
+		data, err := os.ReadFile(path)
+		Expect(err).ToNot(HaveOccurred())
+		lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+		Expect(lines).To(HaveLen(1))
+
+		event := map[string]any{}
+		Expect(json.Unmarshal([]byte(lines[0]), &event)).To(Succeed())
+		Expect(event).To(HaveKeyWithValue("operation", provider.MetricsOperationCreate))
+		Expect(event).To(HaveKeyWithValue("fqdn", "web.example.com"))
+		Expect(event).To(HaveKeyWithValue("dry_run", true))
+	})
+
+	It("should keep the read-only mode of the runtime when the configuration has not changed it", func() {
+		Expect(p.SetFreeze(provider.FreezeConfig{})).To(Succeed())
+		p.SetReadOnly(true)
+
+		Expect(p.SetFreeze(provider.FreezeConfig{Windows: []string{"0 18 * * FRI for 62h"}})).To(Succeed())
+		Expect(p.IsReadOnly()).To(BeTrue())
+
+		Expect(p.SetFreeze(provider.FreezeConfig{Windows: []string{"0 18 * * FRI for 62h", "bad"}})).ToNot(Succeed())
+		Expect(p.IsReadOnly()).To(BeTrue())
+	})
+})
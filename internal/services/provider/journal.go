@@ -0,0 +1,164 @@
+package provider
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	appctx "github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/ctx"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/notifier"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/opnsense"
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+)
+
+// journal collects the changes of a batch while they are applied, writing each of them to the audit log
+// and sending the summary of the batch to the notifier when it is done.
+type journal struct {
+	provider  *Provider
+	requestID string
+	batchID   string
+	dryRun    bool
+	changes   []notifier.Change
+	mu        sync.Mutex
+}
+
+func (p *Provider) newJournal(ctx context.Context) *journal {
+	return &journal{
+		provider:  p,
+		requestID: appctx.LogField(ctx, "request_id"),
+		batchID:   appctx.LogField(ctx, "batch_id"),
+		dryRun:    p.isDryRun(),
+	}
+}
+
+// record writes the change to the audit log and keeps it for the notification of the batch.
+func (j *journal) record(event services.AuditEvent) {
+	event.RequestID = j.requestID
+	event.BatchID = j.batchID
+	event.DryRun = j.dryRun
+
+	j.provider.Audit.Record(event)
+
+	// reconfiguring the service is not a change of the records by itself
+	if event.Operation == MetricsOperationReconfigure {
+		return
+	}
+
+	outcome := services.AuditOutcomeSuccess
+	if event.Error != nil {
+		outcome = services.AuditOutcomeFailure
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.changes = append(j.changes, notifier.Change{
+		Operation: event.Operation,
+		FQDN:      event.FQDN,
+		Type:      event.Type,
+		OldValue:  event.OldValue,
+		NewValue:  event.NewValue,
+		UUID:      event.UUID,
+		Owner:     event.Owner,
+		Outcome:   outcome,
+	})
+}
+
+// notify sends the summary of the batch, which never fails the batch itself.
+func (j *journal) notify(ctx context.Context, err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	summary := notifier.Summary{
+		Timestamp: time.Now(),
+		RequestID: j.requestID,
+		BatchID:   j.batchID,
+		DryRun:    j.dryRun,
+		Changes:   j.changes,
+	}
+
+	if err != nil {
+		summary.Error = err.Error()
+	}
+
+	j.provider.Notifier.Notify(ctx, summary)
+}
+
+// discard logs the changes of a batch that is discarded in the dry run, and writes them to the audit log and the notification
+// flagged as a dry run, so that the intended changes can be followed without being applied or counted.
+func (j *journal) discard(ctx context.Context, changes *plan.Changes) {
+	log := j.provider.requestLog(ctx)
+
+	for _, line := range diffChanges(changes) {
+		log.Infof("Dry run enabled, skipping: %s", line)
+	}
+
+	for _, ep := range changes.Delete {
+		j.record(services.AuditEvent{
+			Operation: MetricsOperationDelete,
+			FQDN:      ep.DNSName,
+			Type:      ep.RecordType,
+			OldValue:  ep.Targets,
+			UUID:      ep.Labels[EndpointLabelUUID.String()],
+			Owner:     getOwner(ep),
+		})
+	}
+
+	for i, ep := range changes.UpdateNew {
+		event := services.AuditEvent{
+			Operation: MetricsOperationUpdate,
+			FQDN:      ep.DNSName,
+			Type:      ep.RecordType,
+			NewValue:  ep.Targets,
+			Owner:     getOwner(ep),
+		}
+		if i < len(changes.UpdateOld) {
+			event.OldValue = changes.UpdateOld[i].Targets
+			event.UUID = changes.UpdateOld[i].Labels[EndpointLabelUUID.String()]
+		}
+
+		j.record(event)
+	}
+
+	for _, ep := range changes.Create {
+		j.record(services.AuditEvent{
+			Operation: MetricsOperationCreate,
+			FQDN:      ep.DNSName,
+			Type:      ep.RecordType,
+			NewValue:  ep.Targets,
+			Owner:     getOwner(ep),
+		})
+	}
+
+	j.notify(ctx, nil)
+}
+
+// isDryRun returns whether the client skips the mutating requests.
+func (p *Provider) isDryRun() bool {
+	client, ok := p.Client.(opnsense.DryRunner)
+
+	return ok && client.IsDryRun()
+}
+
+// getOwner returns the owner of the endpoint from the labels that are set by the TXT registry,
+// or from the content of the registry record itself when the endpoint is a TXT record.
+func getOwner(ep *endpoint.Endpoint) string {
+	if owner := ep.Labels[endpoint.OwnerLabelKey]; owner != "" {
+		return owner
+	}
+
+	if ep.RecordType != endpoint.RecordTypeTXT {
+		return ""
+	}
+
+	for _, target := range ep.Targets {
+		labels, err := endpoint.NewLabelsFromStringPlain(target)
+		if err == nil {
+			return labels[endpoint.OwnerLabelKey]
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,106 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/health"
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+// SetReadOnly enables or disables the read-only mode at runtime, where the records are still served
+// while the batches of changes are rejected or discarded, like while OPNsense is in maintenance.
+func (p *Provider) SetReadOnly(enabled bool) {
+	if p.readOnly.Swap(enabled) == enabled {
+		return
+	}
+
+	p.observeFreeze(time.Now(), p.Health.Set)
+}
+
+// IsReadOnly returns whether the read-only mode is enabled.
+func (p *Provider) IsReadOnly() bool {
+	return p.readOnly.Load()
+}
+
+// Reconfigure reconfigures the Unbound service to apply the host overrides, like after they are changed by hand.
+func (p *Provider) Reconfigure(ctx context.Context) error {
+	log := p.requestLog(ctx)
+
+	log.Infof("Reconfiguring Unbound service on request.")
+
+	start := time.Now()
+	err := p.Client.ReconfigureService(ctx)
+	p.Metrics.ObserveChange(MetricsOperationReconfigure, err)
+	p.Health.Set(health.ComponentReconfigure, err)
+	if err != nil {
+		return fmt.Errorf("failed to reconfigure Unbound service: %w", err)
+	}
+	p.Metrics.ObserveReconfigure(time.Since(start))
+
+	log.Infof("Unbound service reconfigured.")
+
+	return nil
+}
+
+// ManagedRecord is a record in the domain filter with the owner from its registry record.
+type ManagedRecord struct {
+	UUID        string   `json:"uuid"`
+	DNSName     string   `json:"dns_name"`
+	RecordType  string   `json:"record_type"`
+	Targets     []string `json:"targets"`
+	Description string   `json:"description,omitempty"`
+	// Owner is empty when there is no registry record for the record, like for the records that are added by hand.
+	Owner string `json:"owner,omitempty"`
+}
+
+// ManagedRecords returns the records in the domain filter with their owners, where the owner of a record comes from
+// the registry record with its set identifier, and the registry records carry the owner themselves.
+func (p *Provider) ManagedRecords(ctx context.Context) ([]ManagedRecord, error) {
+	endpoints, err := p.Records(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	owners := map[string]string{}
+	for _, ep := range endpoints {
+		if ep.RecordType != endpoint.RecordTypeTXT {
+			continue
+		}
+
+		for _, target := range ep.Targets {
+			labels, err := endpoint.NewLabelsFromStringPlain(target)
+			if err != nil {
+				continue
+			}
+
+			if id := labels[EndpointLabelSetIdentifier.String()]; id != "" {
+				owners[id] = labels[endpoint.OwnerLabelKey]
+			}
+		}
+	}
+
+	records := make([]ManagedRecord, 0, len(endpoints))
+	for _, ep := range endpoints {
+		record := ManagedRecord{
+			UUID:       ep.Labels[EndpointLabelUUID.String()],
+			DNSName:    ep.DNSName,
+			RecordType: ep.RecordType,
+			Targets:    ep.Targets,
+			Owner:      owners[ep.SetIdentifier],
+		}
+
+		if description, ok := ep.GetProviderSpecificProperty(ProviderSpecificDescription.String()); ok {
+			record.Description = description
+		}
+
+		if owner := getOwner(ep); owner != "" {
+			record.Owner = owner
+		}
+
+		records = append(records, record)
+	}
+
+	return records, nil
+}
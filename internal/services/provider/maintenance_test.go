@@ -0,0 +1,44 @@
+package provider_test
+
+import (
+	"errors"
+
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/provider"
+	mockservices "github.com/cenk1cenk2/external-dns-webhook-opnsense/test/mocks/services"
+	"github.com/stretchr/testify/mock"
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Maintenance", func() {
+	var (
+		client *mockservices.MockClientAdapter
+		p      *provider.Provider
+	)
+
+	BeforeEach(func() {
+		client = mockservices.NewMockClientAdapter(GinkgoT())
+
+		p = newProvider(&provider.ProviderSvc{Client: client}, provider.ProviderConfig{})
+	})
+
+	It("should reject the batches of changes in the read-only mode", func(ctx SpecContext) {
+		p.SetReadOnly(true)
+		Expect(p.IsReadOnly()).To(BeTrue())
+
+		Expect(p.ApplyChanges(ctx, &plan.Changes{
+			Create: []*endpoint.Endpoint{endpoint.NewEndpoint("web.example.com", endpoint.RecordTypeA, "192.168.1.2")},
+		})).To(MatchError(provider.ErrReadOnly))
+	})
+
+	It("should reconfigure the service on request", func(ctx SpecContext) {
+		client.EXPECT().ReconfigureService(mock.Anything).Return(nil).Once()
+		Expect(p.Reconfigure(ctx)).To(Succeed())
+
+		client.EXPECT().ReconfigureService(mock.Anything).Return(errors.New("unbound is not running")).Once()
+		Expect(p.Reconfigure(ctx)).To(MatchError(ContainSubstring("unbound is not running")))
+	})
+})
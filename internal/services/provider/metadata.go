@@ -21,3 +21,10 @@ const (
 func (l EndpointLabel) String() string {
 	return string(l)
 }
+
+const (
+	MetricsOperationCreate      = "create"
+	MetricsOperationUpdate      = "update"
+	MetricsOperationDelete      = "delete"
+	MetricsOperationReconfigure = "reconfigure"
+)
@@ -0,0 +1,220 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/opnsense"
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+)
+
+type OwnershipConfig struct {
+	// OwnerID is the owner of the registry records of external-dns, where a host override is managed
+	// when there is a registry record with the owner for its set identifier.
+	OwnerID string
+	// DescriptionMarker marks the host overrides that are managed when their description contains it.
+	DescriptionMarker string
+	// ManagedNames are the name patterns of the host overrides that are always managed.
+	ManagedNames []string
+	// ProtectedNames are the name patterns of the host overrides that are never changed, even when they are managed.
+	ProtectedNames []string
+}
+
+// IsEnabled returns whether the host overrides have to be proven to be managed before they are changed.
+func (c OwnershipConfig) IsEnabled() bool {
+	return c.OwnerID != "" || c.DescriptionMarker != "" || len(c.ManagedNames) > 0
+}
+
+// Validate checks whether the name patterns can be matched.
+func (c OwnershipConfig) Validate() error {
+	for _, pattern := range slices.Concat(c.ManagedNames, c.ProtectedNames) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid name pattern %q: %w", pattern, err)
+		}
+	}
+
+	return nil
+}
+
+// matchName returns whether the name matches any of the patterns, where * matches a single label of the name.
+func matchName(patterns []string, name string) bool {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(strings.ToLower(strings.TrimSuffix(pattern, ".")), name); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// guardOwnership refuses the batch before any change is applied, when it creates, updates or deletes a protected name,
+// or when it updates or deletes a host override that is not proven to be managed. The host overrides are identified
+// with the records on OPNsense rather than the labels of the endpoints, so that a stale or crafted payload can not
+// pass for a managed host override.
+func (p *Provider) guardOwnership(ctx context.Context, changes *plan.Changes) error {
+	conf := p.Config.Ownership
+	if !conf.IsEnabled() && len(conf.ProtectedNames) == 0 {
+		return nil
+	}
+
+	errs := []error{}
+
+	for _, ep := range slices.Concat(changes.Create, changes.UpdateNew) {
+		if matchName(conf.ProtectedNames, ep.DNSName) {
+			errs = append(errs, newEndpointError(ep, fmt.Errorf("%w: %s", ErrProtectedRecord, ep.DNSName)))
+		}
+	}
+
+	existing := slices.Concat(changes.UpdateOld, changes.Delete)
+	if len(existing) == 0 {
+		return errors.Join(errs...)
+	}
+
+	result, err := p.Client.UnboundSearchHostOverrides(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to query for host overrides to check the ownership: %w", err)
+	}
+
+	for _, ep := range existing {
+		for _, row := range findRows(ep, result.Rows) {
+			record := NewDnsRecord(row)
+
+			switch {
+			case matchName(conf.ProtectedNames, record.GetFQDN()):
+				errs = append(errs, newEndpointError(ep, fmt.Errorf("%w: %s with UUID %s", ErrProtectedRecord, record.GetFQDN(), row.Id)))
+			case conf.IsEnabled() && !isManaged(conf, record, result.Rows):
+				errs = append(errs, newEndpointError(ep, fmt.Errorf("%w: %s with UUID %s", ErrUnmanagedRecord, record.GetFQDN(), row.Id)))
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		p.requestLog(ctx).Warnf("Refusing the batch, since it changes host overrides that are not allowed to be changed: %v", errors.Join(errs...))
+	}
+
+	return errors.Join(errs...)
+}
+
+// findRows returns the host overrides that the endpoint refers to, with its UUID or with the labels of a registry record.
+// The registry records are resolved the same way as they are changed, rather than with their UUID, so that a crafted UUID
+// can not stand in for the registry record of another owner. The host overrides that do not exist anymore are not returned,
+// since there is nothing left to protect.
+func findRows(ep *endpoint.Endpoint, rows []opnsense.UnboundSearchHostOverrideItem) []opnsense.UnboundSearchHostOverrideItem {
+	if ep.RecordType == endpoint.RecordTypeTXT {
+		if record, err := NewDnsRecordFromEndpoint(ep); err == nil {
+			if labels, err := endpoint.NewLabelsFromString(record.TxtData, nil); err == nil {
+				if record := matchRegistryRecord(ep, labels, rows); record != nil {
+					return []opnsense.UnboundSearchHostOverrideItem{record.UnboundSearchHostOverrideItem}
+				}
+
+				return nil
+			}
+		}
+	}
+
+	if id := ep.Labels[EndpointLabelUUID.String()]; id != "" {
+		for _, row := range rows {
+			if row.Id == id {
+				return []opnsense.UnboundSearchHostOverrideItem{row}
+			}
+		}
+	}
+
+	return nil
+}
+
+// isRegistryEndpoint returns whether the endpoint is a registry record of external-dns, which carries the labels of a record.
+func isRegistryEndpoint(ep *endpoint.Endpoint) bool {
+	if ep.RecordType != endpoint.RecordTypeTXT {
+		return false
+	}
+
+	for _, target := range ep.Targets {
+		if _, err := endpoint.NewLabelsFromStringPlain(target); err == nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isRegistryRecord returns whether the host override is a registry record of external-dns.
+func isRegistryRecord(record *DnsRecord) bool {
+	if record.Type != endpoint.RecordTypeTXT {
+		return false
+	}
+
+	_, err := endpoint.NewLabelsFromStringPlain(record.TxtData)
+
+	return err == nil
+}
+
+// managedRows returns the host overrides in the domain filter that are proven to be managed, or all of them when the ownership
+// is not enabled. The registry records are left out, since they come and go with the records that they own.
+func (p *Provider) managedRows(rows []opnsense.UnboundSearchHostOverrideItem) []opnsense.UnboundSearchHostOverrideItem {
+	conf := p.Config.Ownership
+	filter := p.GetDomainFilter()
+
+	managed := []opnsense.UnboundSearchHostOverrideItem{}
+	for _, row := range rows {
+		record := NewDnsRecord(row)
+		if !filter.Match(record.GetFQDN()) || isRegistryRecord(record) {
+			continue
+		}
+
+		if conf.IsEnabled() && !isManaged(conf, record, rows) {
+			continue
+		}
+
+		managed = append(managed, row)
+	}
+
+	return managed
+}
+
+// isManaged returns whether the host override is proven to be managed, by its description, its name, or a registry record with the owner.
+func isManaged(conf OwnershipConfig, record *DnsRecord, rows []opnsense.UnboundSearchHostOverrideItem) bool {
+	if conf.DescriptionMarker != "" && strings.Contains(record.Description, conf.DescriptionMarker) {
+		return true
+	}
+
+	if matchName(conf.ManagedNames, record.GetFQDN()) {
+		return true
+	}
+
+	if conf.OwnerID == "" {
+		return false
+	}
+
+	// the registry records carry the owner themselves
+	if record.Type == endpoint.RecordTypeTXT {
+		if labels, err := endpoint.NewLabelsFromStringPlain(record.TxtData); err == nil {
+			return labels[endpoint.OwnerLabelKey] == conf.OwnerID
+		}
+	}
+
+	setIdentifier := record.GenerateSetIdentifier()
+	for _, row := range rows {
+		if row.Type != endpoint.RecordTypeTXT {
+			continue
+		}
+
+		labels, err := endpoint.NewLabelsFromStringPlain(row.TxtData)
+		if err != nil {
+			continue
+		}
+
+		if labels[endpoint.OwnerLabelKey] == conf.OwnerID && labels[EndpointLabelSetIdentifier.String()] == setIdentifier {
+			return true
+		}
+	}
+
+	return false
+}
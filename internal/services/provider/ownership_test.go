@@ -0,0 +1,155 @@
+package provider_test
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/opnsense"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/provider"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/test/fixtures"
+	mockservices "github.com/cenk1cenk2/external-dns-webhook-opnsense/test/mocks/services"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/mock"
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Ownership", func() {
+	var (
+		client *mockservices.MockClientAdapter
+		p      *provider.Provider
+	)
+
+	owned := opnsense.UnboundSearchHostOverrideItem{Id: "id-owned", Enabled: "1", Hostname: "app", Domain: "example.com", Type: endpoint.RecordTypeA, Server: "192.168.1.1"}
+	manual := opnsense.UnboundSearchHostOverrideItem{Id: "id-manual", Enabled: "1", Hostname: "nas", Domain: "example.com", Type: endpoint.RecordTypeA, Server: "192.168.1.2", Description: "added by hand"}
+	marked := opnsense.UnboundSearchHostOverrideItem{Id: "id-marked", Enabled: "1", Hostname: "web", Domain: "example.com", Type: endpoint.RecordTypeA, Server: "192.168.1.3", Description: "managed by external-dns"}
+	router := opnsense.UnboundSearchHostOverrideItem{Id: "id-router", Enabled: "1", Hostname: "router", Domain: "example.com", Type: endpoint.RecordTypeA, Server: "192.168.1.254", Description: "managed by external-dns"}
+	registry := opnsense.UnboundSearchHostOverrideItem{
+		Id:      "id-registry",
+		Enabled: "1",
+		Domain:  "a-app.example.com",
+		Type:    endpoint.RecordTypeTXT,
+		TxtData: fmt.Sprintf(`"heritage=external-dns,external-dns/owner=default,external-dns/set-identifier=%s"`, provider.NewDnsRecord(owned).GenerateSetIdentifier()),
+	}
+
+	rows := &opnsense.UnboundSearchHostOverrideResponse{
+		Rows: []opnsense.UnboundSearchHostOverrideItem{owned, manual, marked, router, registry},
+	}
+
+	deleteOf := func(row opnsense.UnboundSearchHostOverrideItem) *plan.Changes {
+		return &plan.Changes{
+			Delete: []*endpoint.Endpoint{
+				endpoint.NewEndpoint(provider.NewDnsRecord(row).GetFQDN(), row.Type, row.Server).
+					WithLabel(provider.EndpointLabelUUID.String(), row.Id),
+			},
+		}
+	}
+
+	BeforeEach(func() {
+		client = mockservices.NewMockClientAdapter(GinkgoT())
+
+		p = newProvider(&provider.ProviderSvc{Client: client}, provider.ProviderConfig{
+			Ownership: provider.OwnershipConfig{
+				OwnerID:           "default",
+				DescriptionMarker: "external-dns",
+				ManagedNames:      []string{"*.apps.example.com"},
+				ProtectedNames:    []string{"router.example.com"},
+			},
+		})
+	})
+
+	It("should delete the host overrides that are owned by the registry records", func(ctx SpecContext) {
+		client.EXPECT().UnboundSearchHostOverrides(mock.Anything, mock.Anything).Return(rows, nil).Once()
+		client.EXPECT().UnboundDeleteHostOverride(mock.Anything, "id-owned").Return(nil).Once()
+		client.EXPECT().ReconfigureService(mock.Anything).Return(nil).Once()
+
+		Expect(p.ApplyChanges(ctx, deleteOf(owned))).To(Succeed())
+	})
+
+	It("should delete the host overrides with the description marker", func(ctx SpecContext) {
+		client.EXPECT().UnboundSearchHostOverrides(mock.Anything, mock.Anything).Return(rows, nil).Once()
+		client.EXPECT().UnboundDeleteHostOverride(mock.Anything, "id-marked").Return(nil).Once()
+		client.EXPECT().ReconfigureService(mock.Anything).Return(nil).Once()
+
+		Expect(p.ApplyChanges(ctx, deleteOf(marked))).To(Succeed())
+	})
+
+	It("should refuse to delete the host overrides that are not managed", func(ctx SpecContext) {
+		client.EXPECT().UnboundSearchHostOverrides(mock.Anything, mock.Anything).Return(rows, nil).Once()
+
+		err := p.ApplyChanges(ctx, deleteOf(manual))
+		Expect(err).To(MatchError(provider.ErrUnmanagedRecord))
+		Expect(err.Error()).To(ContainSubstring("nas.example.com with UUID id-manual"))
+	})
+
+	It("should refuse a crafted payload that refers to an unmanaged host override with a managed name", func(ctx SpecContext) {
+		client.EXPECT().UnboundSearchHostOverrides(mock.Anything, mock.Anything).Return(rows, nil).Once()
+
+		changes := deleteOf(owned)
+		changes.Delete[0].Labels[provider.EndpointLabelUUID.String()] = "id-manual"
+
+		Expect(p.ApplyChanges(ctx, changes)).To(MatchError(provider.ErrUnmanagedRecord))
+	})
+
+	It("should refuse a crafted payload that refers to a managed UUID with the labels of the registry record of another owner", func(ctx SpecContext) {
+		foreign := opnsense.UnboundSearchHostOverrideItem{
+			Id:      "id-foreign",
+			Enabled: "1",
+			Domain:  "a-nas.example.com",
+			Type:    endpoint.RecordTypeTXT,
+			TxtData: fmt.Sprintf(`"heritage=external-dns,external-dns/owner=other,external-dns/set-identifier=%s"`, provider.NewDnsRecord(manual).GenerateSetIdentifier()),
+		}
+		client.EXPECT().UnboundSearchHostOverrides(mock.Anything, mock.Anything).Return(&opnsense.UnboundSearchHostOverrideResponse{
+			Rows: append(slices.Clone(rows.Rows), foreign),
+		}, nil).Once()
+
+		ep := endpoint.NewEndpoint(foreign.Domain, endpoint.RecordTypeTXT, foreign.TxtData).
+			WithLabel(provider.EndpointLabelUUID.String(), registry.Id)
+		ep.SetIdentifier = provider.NewDnsRecord(manual).GenerateSetIdentifier()
+
+		err := p.ApplyChanges(ctx, &plan.Changes{Delete: []*endpoint.Endpoint{ep}})
+		Expect(err).To(MatchError(provider.ErrUnmanagedRecord))
+		Expect(err.Error()).To(ContainSubstring("a-nas.example.com with UUID id-foreign"))
+	})
+
+	It("should refuse to change the protected names even when they are managed", func(ctx SpecContext) {
+		client.EXPECT().UnboundSearchHostOverrides(mock.Anything, mock.Anything).Return(rows, nil).Once()
+
+		Expect(p.ApplyChanges(ctx, deleteOf(router))).To(MatchError(provider.ErrProtectedRecord))
+	})
+
+	It("should refuse to create the protected names", func(ctx SpecContext) {
+		Expect(p.ApplyChanges(ctx, &plan.Changes{
+			Create: []*endpoint.Endpoint{endpoint.NewEndpoint("Router.example.com", endpoint.RecordTypeA, "192.168.1.1")},
+		})).To(MatchError(provider.ErrProtectedRecord))
+	})
+
+	It("should only count the managed records in the metrics", func(ctx SpecContext) {
+		client.EXPECT().UnboundSearchHostOverrides(mock.Anything, mock.Anything).Return(rows, nil).Once()
+		p.Metrics = services.NewMetrics()
+
+		_, err := p.Records(ctx)
+		Expect(err).ToNot(HaveOccurred())
+
+		// the record that is added by hand and the registry record are not counted
+		Expect(testutil.GatherAndCompare(p.Metrics.Registry, strings.NewReader(`
+# HELP external_dns_opnsense_provider_managed_records Number of the records that are proven to be managed by the provider by domain and type, without the registry records.
+# TYPE external_dns_opnsense_provider_managed_records gauge
+external_dns_opnsense_provider_managed_records{domain="example.com",type="A"} 3
+`), "external_dns_opnsense_provider_managed_records")).To(Succeed())
+	})
+
+	It("should reject the invalid name patterns", func() {
+		_, err := provider.NewProvider(&provider.ProviderSvc{
+			Logger: fixtures.NewTestLogger(),
+		}, provider.ProviderConfig{
+			Ownership: provider.OwnershipConfig{ProtectedNames: []string{"[router"}},
+		})
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -2,10 +2,21 @@ package provider
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	appctx "github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/ctx"
 	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/health"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/notifier"
 	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/opnsense"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	"sigs.k8s.io/external-dns/endpoint"
 	"sigs.k8s.io/external-dns/plan"
@@ -19,33 +30,105 @@ type Provider struct {
 
 	Log          services.ZapSugaredLogger
 	Client       opnsense.ClientAdapter
+	Metrics      *services.Metrics
+	Audit        *services.Audit
+	Notifier     *notifier.Notifier
+	Health       *health.Checker
 	DomainFilter endpoint.DomainFilterInterface
+
+	mu       sync.RWMutex
+	applying sync.Mutex
+	// approvals serializes the access to the pending batches on the disk within the process, next to the lock file for the other processes.
+	approvals sync.Mutex
+	// readOnly freezes the changes while the records are still served.
+	readOnly atomic.Bool
+	// deletionOverride lets the next batch that exceeds the deletion thresholds through.
+	deletionOverride atomic.Bool
+	// freezeWindows are the parsed change freeze windows of the configuration.
+	freezeWindows []*FreezeWindow
+	// freezeMu guards the reason that the changes are frozen for, so that it is only logged when it changes.
+	freezeMu     sync.Mutex
+	freezeReason string
+
+	cacheMu  sync.Mutex
+	cached   *opnsense.UnboundSearchHostOverrideResponse
+	cachedAt time.Time
+	// desired is the last desired state that is received from external-dns, which is nil until the first adjustment.
+	desired map[desiredKey]*desiredRecord
 }
 
 type ProviderSvc struct {
-	Client opnsense.ClientAdapter
-	Logger *services.Logger
+	Client   opnsense.ClientAdapter
+	Logger   *services.Logger
+	Metrics  *services.Metrics
+	Audit    *services.Audit
+	Notifier *notifier.Notifier
+	Health   *health.Checker
 }
 
 type ProviderConfig struct {
 	DomainFilter DomainFilterConfig
+	Startup      StartupConfig
+	Drift        DriftConfig
+	Ownership    OwnershipConfig
+	Deletion     DeletionConfig
+	Approval     ApprovalConfig
+	Cache        CacheConfig
+	Freeze       FreezeConfig
 }
 
 var _ provider.Provider = (*Provider)(nil)
 
+// Validate returns an error for the configuration that can not be checked with the tags of the fields.
+func (c ProviderConfig) Validate() error {
+	if err := c.Ownership.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.Approval.Validate(); err != nil {
+		return err
+	}
+
+	return c.Freeze.Validate()
+}
+
 // NewProvider creates a new OPNsense DNS provider.
 func NewProvider(svc *ProviderSvc, conf ProviderConfig) (*Provider, error) {
-	return &Provider{
+	if err := conf.Validate(); err != nil {
+		return nil, err
+	}
+
+	windows, err := parseFreezeWindows(conf.Freeze.Windows)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Provider{
 		Config:       conf,
 		Client:       svc.Client,
+		Metrics:      svc.Metrics,
+		Audit:        svc.Audit,
+		Notifier:     svc.Notifier,
+		Health:       svc.Health,
 		Log:          svc.Logger.WithCaller().With(zap.String("service", "provider")),
 		DomainFilter: NewDomainFilter(conf.DomainFilter),
-	}, nil
+
+		freezeWindows: windows,
+	}
+	p.readOnly.Store(conf.Freeze.ReadOnly)
+	p.observeFreeze(time.Now(), p.Health.Set)
+
+	// the batches that are pending from before a restart are reported right away
+	p.observePendingBatches()
+
+	return p, nil
 }
 
 // Records returns the list of records from OPNsense Unbound DNS.
 func (p *Provider) Records(ctx context.Context) ([]*endpoint.Endpoint, error) {
-	result, err := p.Client.UnboundSearchHostOverrides(ctx, nil)
+	log := p.requestLog(ctx)
+
+	result, err := p.searchHostOverrides(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query for host overrides: %w", err)
 	}
@@ -54,10 +137,10 @@ func (p *Provider) Records(ctx context.Context) ([]*endpoint.Endpoint, error) {
 
 	for _, row := range result.Rows {
 		record := NewDnsRecord(row)
-		p.Log.Debugf("Processing record: %+v", record)
+		log.Debugf("Processing record: %+v", record)
 
 		if !p.GetDomainFilter().Match(record.GetFQDN()) {
-			p.Log.Debugf("Skipping record due to domain filter: %s", record.GetFQDN())
+			log.Debugf("Skipping record due to domain filter: %s", record.GetFQDN())
 			continue
 		}
 
@@ -81,11 +164,18 @@ func (p *Provider) Records(ctx context.Context) ([]*endpoint.Endpoint, error) {
 			)
 		}
 
-		p.Log.Debugf("Endpoint processed: %+v", ep)
+		log.Debugf("Endpoint processed: %+v", ep)
 
 		endpoints = append(endpoints, ep)
 	}
 
+	// only the records that are proven to be managed are counted, like for the deletion thresholds
+	managed := map[services.ManagedRecordsKey]int{}
+	for _, row := range p.managedRows(result.Rows) {
+		managed[services.ManagedRecordsKey{Domain: row.Domain, Type: row.Type}]++
+	}
+	p.Metrics.SetManagedRecords(managed)
+
 	return endpoints, nil
 }
 
@@ -93,6 +183,8 @@ func (p *Provider) AdjustEndpoints(endpoints []*endpoint.Endpoint) ([]*endpoint.
 	// opnsense unbound dns doesn't support multiple targets per record.
 	// split endpoints with multiple targets into separate endpoints with unique setidentifiers.
 	adjusted := make([]*endpoint.Endpoint, 0, len(endpoints))
+	// all the invalid endpoints are reported at once
+	errs := []error{}
 
 	for _, ep := range endpoints {
 		p.Log.Debugf("AdjustEndpoints processing: %+v", ep)
@@ -110,7 +202,9 @@ func (p *Provider) AdjustEndpoints(endpoints []*endpoint.Endpoint) ([]*endpoint.
 			// Create a record to generate SetIdentifier
 			records, err := NewDnsRecordsFromEndpoint(ep)
 			if err != nil {
-				return nil, fmt.Errorf("failed to create record from endpoint %s: %v", ep.DNSName, err)
+				errs = append(errs, newInvalidEndpointError(ep, fmt.Errorf("failed to create record from endpoint %s: %w", ep.DNSName, err)))
+
+				continue
 			}
 
 			ep.SetIdentifier = records[0].GenerateSetIdentifier()
@@ -123,7 +217,9 @@ func (p *Provider) AdjustEndpoints(endpoints []*endpoint.Endpoint) ([]*endpoint.
 		// Multiple targets - need to split into separate endpoints
 		records, err := NewDnsRecordsFromEndpoint(ep)
 		if err != nil {
-			return nil, fmt.Errorf("failed to create records from endpoint %s: %v", ep.DNSName, err)
+			errs = append(errs, newInvalidEndpointError(ep, fmt.Errorf("failed to create records from endpoint %s: %w", ep.DNSName, err)))
+
+			continue
 		}
 
 		p.Log.Debugf("Normalized endpoint %s into %d record(s)", ep.DNSName, len(records))
@@ -153,164 +249,401 @@ func (p *Provider) AdjustEndpoints(endpoints []*endpoint.Endpoint) ([]*endpoint.
 		}
 	}
 
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
 	p.Log.Debugf("AdjustEndpoints returning %d endpoint(s)", len(adjusted))
 
+	p.setDesired(adjusted)
+
 	return adjusted, nil
 }
 
 // ApplyChanges applies a set of changes to OPNsense Unbound DNS.
-func (p *Provider) ApplyChanges(ctx context.Context, changes *plan.Changes) error {
-	p.Log.Debugf("ApplyChanges called with %d creates, %d updates, %d deletes", len(changes.Create), len(changes.UpdateNew), len(changes.Delete))
-
-	for _, ep := range changes.Delete {
-		p.Log.Debugf("Delete request for: %+v", ep)
+func (p *Provider) ApplyChanges(ctx context.Context, changes *plan.Changes) (err error) {
+	batchID := NewBatchID()
+
+	ctx, span := services.Tracer().Start(ctx, "ApplyChanges", trace.WithAttributes(
+		attribute.String("batch.id", batchID),
+		attribute.Int("changes.create", len(changes.Create)),
+		attribute.Int("changes.update", len(changes.UpdateNew)),
+		attribute.Int("changes.delete", len(changes.Delete)),
+	))
+	defer func() {
+		services.RecordSpanError(span, err)
+		span.End()
+	}()
+
+	ctx = appctx.WithLogFields(ctx, zap.String("batch_id", batchID))
+	log := p.requestLog(ctx)
+
+	// the changes are frozen before anything else, so that the discarded batches are not counted as applied
+	if freeze := p.observeFreeze(time.Now(), p.Health.Set); freeze != nil {
+		if freeze.Discarded {
+			log.Warnf(
+				"Discarding the batch with %d creates, %d updates, %d deletes, since the changes are frozen: %s",
+				len(changes.Create), len(changes.UpdateNew), len(changes.Delete), freeze.Reason,
+			)
 
-		switch ep.RecordType {
-		case endpoint.RecordTypeA, endpoint.RecordTypeAAAA:
-			record, err := NewDnsRecordFromExistingEndpoint(ep)
-			if err != nil {
-				return fmt.Errorf("failed to create record from endpoint %s: %w", ep.DNSName, err)
+			if p.isDryRun() {
+				p.newJournal(ctx).discard(ctx, changes)
 			}
+		} else {
+			log.Warnf("Rejecting the batch, since the changes are frozen: %s", freeze.Reason)
+		}
 
-			if err := p.Client.UnboundDeleteHostOverride(ctx, record.Id); err != nil {
-				return fmt.Errorf("failed to delete host override %s with correct UUID %s: %w", ep.DNSName, record.Id, err)
-			}
+		return freeze
+	}
 
-			p.Log.Infof(
-				"Deleted host override: %s (%s) with id %s, SetIdentifier: %s",
-				ep.DNSName,
-				ep.RecordType,
-				record.Id,
-				ep.SetIdentifier,
-			)
-		case endpoint.RecordTypeTXT:
-			p.Log.Debugf("Processing TXT record delete: %s", ep.DNSName)
+	// the pending batches are locked until the batch is applied, so that it is not interleaved with a batch that is approved
+	// with the operator commands, while the approved batches are already applied with the lock held
+	if approved, _ := ctx.Value(approvedContextKey{}).(bool); !approved && p.Config.Approval.IsEnabled() {
+		unlock, err := p.lockPendingBatches()
+		if err != nil {
+			return err
+		}
+		defer unlock()
+	}
 
-			record, err := p.handleTxtRecordMatching(ctx, ep)
-			if err != nil {
-				return fmt.Errorf("failed to match TXT record for delete: %w", err)
-			}
+	rest, err := p.holdForApproval(ctx, batchID, changes)
+	if err != nil {
+		return err
+	}
 
-			p.Log.Debugf("Found matching TXT record to delete: %s with UUID %s", record.GetFQDN(), record.Id)
+	// there is nothing left to apply when all the changes are held for approval
+	if changes.HasChanges() && !rest.HasChanges() {
+		return nil
+	}
+	changes = rest
 
-			if err := p.Client.UnboundDeleteHostOverride(ctx, record.Id); err != nil {
-				return fmt.Errorf("failed to delete TXT host override %s with UUID %s: %w", ep.DNSName, record.Id, err)
-			}
+	// the batches are not interleaved, since the changes of one batch are reconfigured at once
+	if !p.applying.TryLock() {
+		log.Warnf("Rejecting the batch, since another batch of changes is being applied.")
 
-			p.Log.Infof(
-				"Deleted host override: %s (%s) with id %s, SetIdentifier: %s",
-				ep.DNSName,
-				ep.RecordType,
-				record.Id,
-				ep.SetIdentifier,
-			)
+		return ErrBatchInProgress
+	}
+	defer p.applying.Unlock()
 
-		default:
-			p.Log.Warnf("Record type is not supported: %s -> %s", ep.RecordType, ep.DNSName)
-		}
+	p.FlushCache()
+	defer p.FlushCache()
+
+	journal := p.newJournal(ctx)
+	defer func() {
+		journal.notify(ctx, err)
+	}()
+
+	log.Debugf("ApplyChanges called with %d creates, %d updates, %d deletes", len(changes.Create), len(changes.UpdateNew), len(changes.Delete))
+
+	if err := p.guardOwnership(ctx, changes); err != nil {
+		return err
 	}
 
-	// UpdateOld and UpdateNew are parallel arrays with matching indices
-	for i, newEp := range changes.UpdateNew {
-		oldEp := changes.UpdateOld[i]
-		p.Log.Debugf("Update request for: from %+v to %+v", oldEp, newEp)
+	if err := p.guardDeletion(ctx, changes); err != nil {
+		return err
+	}
 
-		switch newEp.RecordType {
-		case endpoint.RecordTypeA, endpoint.RecordTypeAAAA:
-			oldRecord, err := NewDnsRecordFromExistingEndpoint(oldEp)
-			if err != nil {
-				return fmt.Errorf("failed to create record from existing endpoint %s: %w", oldEp.DNSName, err)
-			}
+	if err := p.phase(ctx, "delete", len(changes.Delete), func(ctx context.Context) error {
+		for _, ep := range changes.Delete {
+			log.Debugf("Delete request for: %+v", ep)
 
-			newRecord, err := NewDnsRecordFromEndpoint(newEp)
-			if err != nil {
-				return fmt.Errorf("failed to create record from endpoint %s: %w", newEp.DNSName, err)
-			}
-			newRecord.Id = oldRecord.Id
+			switch ep.RecordType {
+			case endpoint.RecordTypeA, endpoint.RecordTypeAAAA:
+				record, err := NewDnsRecordFromExistingEndpoint(ep)
+				if err != nil {
+					return newInvalidEndpointError(ep, fmt.Errorf("failed to create record from endpoint %s: %w", ep.DNSName, err))
+				}
 
-			p.Log.Debugf("Updating host override: %s (%s) with id %s", newEp.DNSName, newEp.RecordType, newRecord.Id)
-			if err := p.Client.UnboundUpdateHostOverride(ctx, newRecord.Id, newRecord.IntoHostOverride()); err != nil {
-				return fmt.Errorf("failed to update host override %s: %w", newEp.DNSName, err)
-			}
-			p.Log.Infof("Updated host override: %s (%s) with id %s", newEp.DNSName, newEp.RecordType, newRecord.Id)
+				err = p.Client.UnboundDeleteHostOverride(ctx, record.Id)
+				if errors.Is(err, opnsense.ErrNotFound) {
+					log.Warnf("Host override is already deleted: %s with UUID %s", ep.DNSName, record.Id)
 
-		case endpoint.RecordTypeTXT:
-			p.Log.Debugf("Processing TXT record update: %s", oldEp.DNSName)
+					err = nil
+				}
+				p.Metrics.ObserveChange(MetricsOperationDelete, err)
+				journal.record(services.AuditEvent{
+					Operation: MetricsOperationDelete,
+					FQDN:      ep.DNSName,
+					Type:      ep.RecordType,
+					OldValue:  ep.Targets,
+					UUID:      record.Id,
+					Owner:     getOwner(ep),
+					Error:     err,
+				})
+				if err != nil {
+					return newEndpointError(ep, fmt.Errorf("failed to delete host override %s with correct UUID %s: %w", ep.DNSName, record.Id, err))
+				}
 
-			oldRecord, err := p.handleTxtRecordMatching(ctx, oldEp)
-			if err != nil {
-				return fmt.Errorf("failed to match TXT record for update: %w", err)
-			}
+				log.Infof(
+					"Deleted host override: %s (%s) with id %s, SetIdentifier: %s",
+					ep.DNSName,
+					ep.RecordType,
+					record.Id,
+					ep.SetIdentifier,
+				)
+			case endpoint.RecordTypeTXT:
+				log.Debugf("Processing TXT record delete: %s", ep.DNSName)
+
+				record, err := p.handleTxtRecordMatching(ctx, ep)
+				if err != nil {
+					return fmt.Errorf("failed to match TXT record for delete: %w", err)
+				}
 
-			newRecord, err := NewDnsRecordFromEndpoint(newEp)
-			if err != nil {
-				return fmt.Errorf("failed to create record from endpoint %s: %w", newEp.DNSName, err)
-			}
+				log.Debugf("Found matching TXT record to delete: %s with UUID %s", record.GetFQDN(), record.Id)
 
-			newRecord.Id = oldRecord.Id
+				err = p.Client.UnboundDeleteHostOverride(ctx, record.Id)
+				if errors.Is(err, opnsense.ErrNotFound) {
+					log.Warnf("Host override is already deleted: %s with UUID %s", ep.DNSName, record.Id)
 
-			p.Log.Debugf("Updating host override: %s (%s) with id %s", newEp.DNSName, newEp.RecordType, newRecord.Id)
-			if err := p.Client.UnboundUpdateHostOverride(ctx, newRecord.Id, newRecord.IntoHostOverride()); err != nil {
-				return fmt.Errorf("failed to update host override %s: %w", newEp.DNSName, err)
-			}
-			p.Log.Infof("Updated host override: %s (%s) with id %s", newEp.DNSName, newEp.RecordType, newRecord.Id)
+					err = nil
+				}
+				p.Metrics.ObserveChange(MetricsOperationDelete, err)
+				journal.record(services.AuditEvent{
+					Operation: MetricsOperationDelete,
+					FQDN:      ep.DNSName,
+					Type:      ep.RecordType,
+					OldValue:  ep.Targets,
+					UUID:      record.Id,
+					Owner:     getOwner(ep),
+					Error:     err,
+				})
+				if err != nil {
+					return newEndpointError(ep, fmt.Errorf("failed to delete TXT host override %s with UUID %s: %w", ep.DNSName, record.Id, err))
+				}
+
+				log.Infof(
+					"Deleted host override: %s (%s) with id %s, SetIdentifier: %s",
+					ep.DNSName,
+					ep.RecordType,
+					record.Id,
+					ep.SetIdentifier,
+				)
 
-		default:
-			p.Log.Warnf("Record type is not supported: %s -> %s", newEp.RecordType, newEp.DNSName)
+			default:
+				log.Warnf("Record type is not supported: %s -> %s", ep.RecordType, ep.DNSName)
+			}
 		}
+
+		return nil
+	}); err != nil {
+		return err
 	}
 
-	for _, ep := range changes.Create {
-		p.Log.Debugf("Create request for: %+v", ep)
+	// UpdateOld and UpdateNew are parallel arrays with matching indices
+	if err := p.phase(ctx, "update", len(changes.UpdateNew), func(ctx context.Context) error {
+		for i, newEp := range changes.UpdateNew {
+			oldEp := changes.UpdateOld[i]
+			log.Debugf("Update request for: from %+v to %+v", oldEp, newEp)
+
+			switch newEp.RecordType {
+			case endpoint.RecordTypeA, endpoint.RecordTypeAAAA:
+				oldRecord, err := NewDnsRecordFromExistingEndpoint(oldEp)
+				if err != nil {
+					return newInvalidEndpointError(oldEp, fmt.Errorf("failed to create record from existing endpoint %s: %w", oldEp.DNSName, err))
+				}
 
-		switch ep.RecordType {
-		case endpoint.RecordTypeA, endpoint.RecordTypeAAAA, endpoint.RecordTypeTXT:
-			records, err := NewDnsRecordsFromEndpoint(ep)
-			if err != nil {
-				return fmt.Errorf("failed to create records from endpoint %s: %w", ep.DNSName, err)
+				newRecord, err := NewDnsRecordFromEndpoint(newEp)
+				if err != nil {
+					return newInvalidEndpointError(newEp, fmt.Errorf("failed to create record from endpoint %s: %w", newEp.DNSName, err))
+				}
+				newRecord.Id = oldRecord.Id
+
+				log.Debugf("Updating host override: %s (%s) with id %s", newEp.DNSName, newEp.RecordType, newRecord.Id)
+				err = p.Client.UnboundUpdateHostOverride(ctx, newRecord.Id, newRecord.IntoHostOverride())
+				p.Metrics.ObserveChange(MetricsOperationUpdate, err)
+				journal.record(services.AuditEvent{
+					Operation: MetricsOperationUpdate,
+					FQDN:      newEp.DNSName,
+					Type:      newEp.RecordType,
+					OldValue:  oldEp.Targets,
+					NewValue:  newEp.Targets,
+					UUID:      newRecord.Id,
+					Owner:     getOwner(newEp),
+					Error:     err,
+				})
+				if err != nil {
+					return newEndpointError(newEp, fmt.Errorf("failed to update host override %s: %w", newEp.DNSName, err))
+				}
+				log.Infof("Updated host override: %s (%s) with id %s", newEp.DNSName, newEp.RecordType, newRecord.Id)
+
+			case endpoint.RecordTypeTXT:
+				log.Debugf("Processing TXT record update: %s", oldEp.DNSName)
+
+				oldRecord, err := p.handleTxtRecordMatching(ctx, oldEp)
+				if err != nil {
+					return fmt.Errorf("failed to match TXT record for update: %w", err)
+				}
+
+				newRecord, err := NewDnsRecordFromEndpoint(newEp)
+				if err != nil {
+					return newInvalidEndpointError(newEp, fmt.Errorf("failed to create record from endpoint %s: %w", newEp.DNSName, err))
+				}
+
+				newRecord.Id = oldRecord.Id
+
+				log.Debugf("Updating host override: %s (%s) with id %s", newEp.DNSName, newEp.RecordType, newRecord.Id)
+				err = p.Client.UnboundUpdateHostOverride(ctx, newRecord.Id, newRecord.IntoHostOverride())
+				p.Metrics.ObserveChange(MetricsOperationUpdate, err)
+				journal.record(services.AuditEvent{
+					Operation: MetricsOperationUpdate,
+					FQDN:      newEp.DNSName,
+					Type:      newEp.RecordType,
+					OldValue:  oldEp.Targets,
+					NewValue:  newEp.Targets,
+					UUID:      newRecord.Id,
+					Owner:     getOwner(newEp),
+					Error:     err,
+				})
+				if err != nil {
+					return newEndpointError(newEp, fmt.Errorf("failed to update host override %s: %w", newEp.DNSName, err))
+				}
+				log.Infof("Updated host override: %s (%s) with id %s", newEp.DNSName, newEp.RecordType, newRecord.Id)
+
+			default:
+				log.Warnf("Record type is not supported: %s -> %s", newEp.RecordType, newEp.DNSName)
 			}
+		}
 
-			for _, record := range records {
-				p.Log.Debugf("Creating host override: %s (%s) -> %+v", ep.DNSName, ep.RecordType, record.GetTarget())
-				uuid, err := p.Client.UnboundCreateHostOverride(ctx, record.IntoHostOverride())
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	if err := p.phase(ctx, "create", len(changes.Create), func(ctx context.Context) error {
+		for _, ep := range changes.Create {
+			log.Debugf("Create request for: %+v", ep)
+
+			switch ep.RecordType {
+			case endpoint.RecordTypeA, endpoint.RecordTypeAAAA, endpoint.RecordTypeTXT:
+				records, err := NewDnsRecordsFromEndpoint(ep)
 				if err != nil {
-					return fmt.Errorf("failed to create host override %s: %w", ep.DNSName, err)
+					return newInvalidEndpointError(ep, fmt.Errorf("failed to create records from endpoint %s: %w", ep.DNSName, err))
+				}
+
+				for _, record := range records {
+					log.Debugf("Creating host override: %s (%s) -> %+v", ep.DNSName, ep.RecordType, record.GetTarget())
+					uuid, err := p.Client.UnboundCreateHostOverride(ctx, record.IntoHostOverride())
+					p.Metrics.ObserveChange(MetricsOperationCreate, err)
+					journal.record(services.AuditEvent{
+						Operation: MetricsOperationCreate,
+						FQDN:      ep.DNSName,
+						Type:      ep.RecordType,
+						NewValue:  record.GetTarget(),
+						UUID:      uuid,
+						Owner:     getOwner(ep),
+						Error:     err,
+					})
+					if err != nil {
+						return newEndpointError(ep, fmt.Errorf("failed to create host override %s: %w", ep.DNSName, err))
+					}
+					log.Infof("Created host override: %s (%s) -> %+v, with id %s", ep.DNSName, ep.RecordType, record.GetTarget(), uuid)
 				}
-				p.Log.Infof("Created host override: %s (%s) -> %+v, with id %s", ep.DNSName, ep.RecordType, record.GetTarget(), uuid)
+
+			default:
+				log.Warnf("Record type is not supported: %s -> %s", ep.RecordType, ep.DNSName)
 			}
 
-		default:
-			p.Log.Warnf("Record type is not supported: %s -> %s", ep.RecordType, ep.DNSName)
 		}
 
+		return nil
+	}); err != nil {
+		return err
 	}
 
 	if len(changes.Create) > 0 || len(changes.UpdateNew) > 0 || len(changes.Delete) > 0 {
-		p.Log.Infof("Reconfiguring Unbound service: applied %d creates, %d updates, %d deletes", len(changes.Create), len(changes.UpdateNew), len(changes.Delete))
-		if err := p.Client.ReconfigureService(ctx); err != nil {
-			return fmt.Errorf("failed to reconfigure Unbound service: %w", err)
-		}
+		if err := p.phase(ctx, "reconfigure", 1, func(ctx context.Context) error {
+			log.Infof("Reconfiguring Unbound service: applied %d creates, %d updates, %d deletes", len(changes.Create), len(changes.UpdateNew), len(changes.Delete))
+			start := time.Now()
+			err := p.Client.ReconfigureService(ctx)
+			p.Metrics.ObserveChange(MetricsOperationReconfigure, err)
+			p.Health.Set(health.ComponentReconfigure, err)
+			journal.record(services.AuditEvent{
+				Operation: MetricsOperationReconfigure,
+				Error:     err,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to reconfigure Unbound service: %w", err)
+			}
+			p.Metrics.ObserveReconfigure(time.Since(start))
 
-		p.Log.Infof("Unbound service reconfigured.")
+			log.Infof("Unbound service reconfigured.")
+
+			return nil
+		}); err != nil {
+			return err
+		}
 	}
 
+	p.Metrics.SetLastSuccessfulSync(time.Now())
+	p.applyDesired(changes)
+
 	return nil
 }
 
+// requestLog returns the logger of the provider with the log fields of the request in the context.
+func (p *Provider) requestLog(ctx context.Context) services.ZapSugaredLogger {
+	return appctx.Logger(ctx, p.Log)
+}
+
+// NewBatchID generates an identifier for a batch of changes, which is attached to all the logs while applying it.
+func NewBatchID() string {
+	id := make([]byte, 8)
+	_, _ = rand.Read(id)
+
+	return hex.EncodeToString(id)
+}
+
+// phase runs a phase of applying the changes in its own span, so that the time spent in each phase can be told apart.
+func (p *Provider) phase(ctx context.Context, name string, count int, fn func(ctx context.Context) error) error {
+	ctx, span := services.Tracer().Start(ctx, "ApplyChanges "+name, trace.WithAttributes(
+		attribute.Int("changes", count),
+	))
+	defer span.End()
+
+	err := fn(ctx)
+	services.RecordSpanError(span, err)
+
+	return err
+}
+
 // GetDomainFilter returns the domain filter for this provider.
 func (p *Provider) GetDomainFilter() endpoint.DomainFilterInterface {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
 	return p.DomainFilter
 }
 
+// SetDomainFilter replaces the domain filter of the provider at runtime.
+func (p *Provider) SetDomainFilter(conf DomainFilterConfig) {
+	filter := NewDomainFilter(conf)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.Config.DomainFilter = conf
+	p.DomainFilter = filter
+}
+
 // handleTxtRecordMatching handles the logic for finding the correct DnsRecord for a given registry TXT record.
-func (p *Provider) handleTxtRecordMatching(ctx context.Context, ep *endpoint.Endpoint) (*DnsRecord, error) {
+func (p *Provider) handleTxtRecordMatching(ctx context.Context, ep *endpoint.Endpoint) (_ *DnsRecord, err error) {
+	ctx, span := services.Tracer().Start(ctx, "ApplyChanges match TXT record", trace.WithAttributes(
+		attribute.String("dns.name", ep.DNSName),
+	))
+	defer func() {
+		services.RecordSpanError(span, err)
+		span.End()
+	}()
+
+	log := p.requestLog(ctx)
+
 	record, err := NewDnsRecordFromEndpoint(ep)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create record from endpoint %s: %w", ep.DNSName, err)
+		return nil, newInvalidEndpointError(ep, fmt.Errorf("failed to create record from endpoint %s: %w", ep.DNSName, err))
 	}
 
 	if epLabels, err := endpoint.NewLabelsFromString(record.TxtData, nil); err == nil {
-		p.Log.Debugf("Endpoint corresponds to a registry record: %+v", ep)
+		log.Debugf("Endpoint corresponds to a registry record: %+v", ep)
 		overrides, err := p.Client.UnboundSearchHostOverrides(ctx, &opnsense.UnboundSearchHostOverrideRequest{
 			SearchPhrase: ep.DNSName,
 			RowCount:     -1,
@@ -319,33 +652,37 @@ func (p *Provider) handleTxtRecordMatching(ctx context.Context, ep *endpoint.End
 			return nil, fmt.Errorf("failed to fetch all records for matching TXT record: %w", err)
 		}
 
-		var record *DnsRecord
-		for _, row := range overrides.Rows {
-			rowLabels, err := endpoint.NewLabelsFromString(row.TxtData, nil)
-			if err != nil {
-				continue
-			}
-			if row.Domain == ep.DNSName && row.Type == ep.RecordType && rowLabels[EndpointLabelSetIdentifier.String()] == ep.SetIdentifier &&
-				rowLabels["owner"] == epLabels["owner"] &&
-				rowLabels["resource"] == epLabels["resource"] {
-				record = NewDnsRecord(row)
-				break
-			}
-		}
-
+		record := matchRegistryRecord(ep, epLabels, overrides.Rows)
 		if record == nil {
-			return nil, fmt.Errorf("failed to find matching TXT record for %s with SetIdentifier %s", ep.DNSName, ep.SetIdentifier)
+			return nil, newEndpointError(ep, fmt.Errorf("failed to find matching TXT record for %s with SetIdentifier %s", ep.DNSName, ep.SetIdentifier))
 		}
 
 		return record, nil
 	}
 
-	p.Log.Debugf("Endpoint corresponds to a normal TXT record: %+v", ep)
+	log.Debugf("Endpoint corresponds to a normal TXT record: %+v", ep)
 
 	record, err = NewDnsRecordFromExistingEndpoint(ep)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create record from endpoint %s: %w", ep.DNSName, err)
+		return nil, newInvalidEndpointError(ep, fmt.Errorf("failed to create record from endpoint %s: %w", ep.DNSName, err))
 	}
 
 	return record, nil
 }
+
+// matchRegistryRecord returns the registry record that the registry TXT endpoint refers to, with its set identifier, owner and resource.
+func matchRegistryRecord(ep *endpoint.Endpoint, labels endpoint.Labels, rows []opnsense.UnboundSearchHostOverrideItem) *DnsRecord {
+	for _, row := range rows {
+		rowLabels, err := endpoint.NewLabelsFromString(row.TxtData, nil)
+		if err != nil {
+			continue
+		}
+		if row.Domain == ep.DNSName && row.Type == ep.RecordType && rowLabels[EndpointLabelSetIdentifier.String()] == ep.SetIdentifier &&
+			rowLabels["owner"] == labels["owner"] &&
+			rowLabels["resource"] == labels["resource"] {
+			return NewDnsRecord(row)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,67 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/health"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/opnsense"
+)
+
+type StartupConfig struct {
+	FailFast        bool
+	CheckPrivileges bool
+	RetryInterval   time.Duration `validate:"gt=0"`
+}
+
+// Startup runs the startup checks until they pass, reporting the result to the health checker so that the service is not ready before.
+// The first failure is returned right away when fail-fast is enabled, otherwise it only returns when the context is cancelled.
+func (p *Provider) Startup(ctx context.Context) error {
+	conf := p.Config.Startup
+
+	for {
+		err := p.startup(ctx)
+		p.Health.Set(health.ComponentStartup, err)
+
+		if err == nil {
+			p.Log.Infof("Startup checks have passed.")
+
+			return nil
+		}
+
+		if conf.FailFast {
+			return fmt.Errorf("startup checks have failed: %w", err)
+		}
+
+		p.Log.Warnf("Startup checks have failed, retrying in %s: %v", conf.RetryInterval, err)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(conf.RetryInterval):
+		}
+	}
+}
+
+// startup fetches the records for the first time, and checks the privileges for applying the changes when enabled.
+func (p *Provider) startup(ctx context.Context) error {
+	if _, err := p.Records(ctx); err != nil {
+		return fmt.Errorf("failed to fetch the records: %w", err)
+	}
+
+	if !p.Config.Startup.CheckPrivileges {
+		return nil
+	}
+
+	checker, ok := p.Client.(opnsense.PrivilegeChecker)
+	if !ok {
+		return nil
+	}
+
+	if err := checker.CheckPrivileges(ctx); err != nil {
+		return fmt.Errorf("credentials are not allowed to apply the changes: %w", err)
+	}
+
+	return nil
+}
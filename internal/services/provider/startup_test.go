@@ -0,0 +1,100 @@
+package provider_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/health"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/opnsense"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/provider"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/test/fixtures"
+	mockservices "github.com/cenk1cenk2/external-dns-webhook-opnsense/test/mocks/services"
+	"github.com/stretchr/testify/mock"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// privilegedClient adds the privilege check to the mocked client.
+type privilegedClient struct {
+	*mockservices.MockClientAdapter
+
+	err error
+}
+
+func (c *privilegedClient) CheckPrivileges(context.Context) error {
+	return c.err
+}
+
+var _ = Describe("Startup", func() {
+	var (
+		client  *mockservices.MockClientAdapter
+		checker *health.Checker
+		p       *provider.Provider
+	)
+
+	BeforeEach(func() {
+		client = mockservices.NewMockClientAdapter(GinkgoT())
+		checker = health.NewChecker(&health.CheckerSvc{
+			Logger: fixtures.NewTestLogger(),
+		}, health.CheckerConfig{})
+		checker.Register(nil, true, health.ComponentStartup)
+
+		p = newProvider(&provider.ProviderSvc{Client: client, Health: checker}, provider.ProviderConfig{
+			Startup: provider.StartupConfig{
+				RetryInterval: time.Millisecond,
+			},
+		})
+	})
+
+	It("should be ready after fetching the records", func() {
+		client.EXPECT().UnboundSearchHostOverrides(mock.Anything, mock.Anything).Return(&opnsense.UnboundSearchHostOverrideResponse{}, nil).Once()
+
+		Expect(checker.IsReady()).To(BeFalse())
+		Expect(p.Startup(GinkgoT().Context())).To(Succeed())
+		Expect(checker.IsReady()).To(BeTrue())
+	})
+
+	It("should retry until the records can be fetched", func() {
+		client.EXPECT().UnboundSearchHostOverrides(mock.Anything, mock.Anything).Return(nil, errors.New("status code non-200; status code 403")).Twice()
+		client.EXPECT().UnboundSearchHostOverrides(mock.Anything, mock.Anything).Return(&opnsense.UnboundSearchHostOverrideResponse{}, nil).Once()
+
+		Expect(p.Startup(GinkgoT().Context())).To(Succeed())
+		Expect(checker.IsReady()).To(BeTrue())
+	})
+
+	It("should stop retrying when the context is cancelled", func() {
+		client.EXPECT().UnboundSearchHostOverrides(mock.Anything, mock.Anything).Return(nil, errors.New("connection refused")).Maybe()
+		p.Config.Startup.RetryInterval = time.Hour
+
+		ctx, cancel := context.WithTimeout(GinkgoT().Context(), 10*time.Millisecond)
+		defer cancel()
+
+		Expect(p.Startup(ctx)).To(MatchError(context.DeadlineExceeded))
+		Expect(checker.IsReady()).To(BeFalse())
+	})
+
+	It("should return the first failure when fail-fast is enabled", func() {
+		client.EXPECT().UnboundSearchHostOverrides(mock.Anything, mock.Anything).Return(nil, errors.New("connection refused")).Once()
+		p.Config.Startup.FailFast = true
+
+		err := p.Startup(GinkgoT().Context())
+		Expect(err).To(MatchError(ContainSubstring("startup checks have failed: failed to fetch the records")))
+		Expect(checker.IsReady()).To(BeFalse())
+		Expect(checker.Components()).To(ContainElement(SatisfyAll(
+			HaveField("Name", health.ComponentStartup),
+			HaveField("Error", ContainSubstring("connection refused")),
+		)))
+	})
+
+	It("should check the privileges when enabled", func() {
+		client.EXPECT().UnboundSearchHostOverrides(mock.Anything, mock.Anything).Return(&opnsense.UnboundSearchHostOverrideResponse{}, nil).Once()
+		p.Client = &privilegedClient{MockClientAdapter: client, err: &opnsense.APIError{Kind: opnsense.ErrForbidden, StatusCode: 403}}
+		p.Config.Startup.FailFast = true
+		p.Config.Startup.CheckPrivileges = true
+
+		Expect(p.Startup(GinkgoT().Context())).To(MatchError(ContainSubstring("credentials are not allowed to apply the changes")))
+		Expect(checker.IsReady()).To(BeFalse())
+	})
+})
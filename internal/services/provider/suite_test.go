@@ -0,0 +1,28 @@
+package provider_test
+
+import (
+	"testing"
+
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/provider"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/test/fixtures"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Service Provider")
+}
+
+// newProvider creates the provider through NewProvider like the service does, with a test logger unless one is given.
+func newProvider(svc *provider.ProviderSvc, conf provider.ProviderConfig) *provider.Provider {
+	if svc.Logger == nil {
+		svc.Logger = fixtures.NewTestLogger()
+	}
+
+	p, err := provider.NewProvider(svc, conf)
+	Expect(err).ToNot(HaveOccurred())
+
+	return p
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"regexp"
 	"strings"
 
 	"github.com/creasty/defaults"
@@ -18,7 +19,7 @@ func NewValidator() *Validator {
 	v := validator.New()
 
 	v.RegisterTagNameFunc(func(fld reflect.StructField) string {
-		if t := strings.SplitN(fld.Tag.Get("json"), ",", 2); len(t) >= 1 {
+		if t := strings.SplitN(fld.Tag.Get("json"), ",", 2); t[0] != "" {
 			return t[0]
 		} else if t := fld.Tag.Get("param"); t != "" {
 			return fmt.Sprintf("[Route parameter: %s]", t)
@@ -27,6 +28,12 @@ func NewValidator() *Validator {
 		return fld.Name
 	})
 
+	_ = v.RegisterValidation("regexp", func(fl validator.FieldLevel) bool {
+		_, err := regexp.Compile(fl.Field().String())
+
+		return err == nil
+	})
+
 	return &Validator{
 		Instance: v,
 	}
@@ -65,8 +72,9 @@ func (v *Validator) Validate(i any) error {
 					return fmt.Errorf("can not set defaults for element %d: %w", i, err)
 				}
 
-				if err := v.Instance.Struct(ptr); errors.Is(err, validator.ValidationErrors{}) {
-					return v.format(err.(validator.ValidationErrors))
+				var validationErrs validator.ValidationErrors
+				if err := v.Instance.Struct(ptr); errors.As(err, &validationErrs) {
+					return v.format(validationErrs)
 				} else if err != nil {
 					return fmt.Errorf("can not validate element %d: %w", i, err)
 				}
@@ -90,8 +98,9 @@ func (v *Validator) Validate(i any) error {
 		return fmt.Errorf("can not set defaults: %w", err)
 	}
 
-	if err := v.Instance.Struct(ptr); errors.Is(err, validator.ValidationErrors{}) {
-		return v.format(err.(validator.ValidationErrors))
+	var validationErrs validator.ValidationErrors
+	if err := v.Instance.Struct(ptr); errors.As(err, &validationErrs) {
+		return v.format(validationErrs)
 	} else if err != nil {
 		return fmt.Errorf("can not validate: %w", err)
 	}
@@ -8,11 +8,16 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/cenk1cenk2/external-dns-webhook-opnsense/api"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/api/admin"
 	"github.com/cenk1cenk2/external-dns-webhook-opnsense/api/probes"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/commands"
 	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/config"
 	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/health"
+	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/notifier"
 	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/opnsense"
 	"github.com/cenk1cenk2/external-dns-webhook-opnsense/internal/services/provider"
 	"github.com/urfave/cli/v3"
@@ -25,6 +30,18 @@ func main() {
 		Name:    "external-dns-webhook-opnsense",
 		Version: VERSION,
 		Flags:   config.BindFlags(conf),
+		Commands: []*cli.Command{
+			commands.NewRecordsCommand(conf),
+			commands.NewApprovalsCommand(conf),
+			commands.NewConfigCommand(conf),
+		},
+		Before: func(ctx context.Context, cmd *cli.Command) (context.Context, error) {
+			if conf.ConfigFile == "" {
+				return ctx, nil
+			}
+
+			return ctx, config.ValidateFile(conf.ConfigFile, cmd.Flags)
+		},
 		Action: func(_ context.Context, cmd *cli.Command) error {
 			logger, err := services.NewLogger(&services.LoggerConfig{
 				Level:   conf.LogLevel,
@@ -36,14 +53,16 @@ func main() {
 			log := logger.WithCaller()
 
 			validator := services.NewValidator()
+			metrics := services.NewMetrics()
 
-			if err := validator.Validate(conf); err != nil {
+			if err := conf.Validate(validator); err != nil {
 				return err
 			}
 
 			client, err := opnsense.NewClient(
 				&opnsense.ClientSvc{
-					Logger: logger,
+					Logger:  logger,
+					Metrics: metrics,
 				},
 				conf.OpnsenseClient,
 			)
@@ -51,10 +70,55 @@ func main() {
 				return fmt.Errorf("failed to create opnsense client: %w", err)
 			}
 
+			checker := health.NewChecker(&health.CheckerSvc{
+				Logger: logger,
+			}, conf.Health)
+
+			// the webhook goes through the circuit breaker, while the health checks always reach the API to report its real state
+			var adapter opnsense.ClientAdapter = client
+			if conf.CircuitBreaker.Threshold > 0 {
+				checker.Register(nil, false, health.ComponentCircuitBreaker)
+
+				adapter = opnsense.NewBreaker(
+					&opnsense.BreakerSvc{
+						Client: client,
+						Logger: logger,
+						Health: checker,
+					},
+					conf.CircuitBreaker,
+				)
+			}
+
+			audit, err := services.NewAudit(conf.Audit)
+			if err != nil {
+				return fmt.Errorf("failed to create the audit sink: %w", err)
+			}
+			defer func() {
+				if err := audit.Close(); err != nil {
+					log.Warnf("Failed to close the audit sink: %v", err)
+				}
+			}()
+
+			notifier, err := notifier.NewNotifier(
+				&notifier.NotifierSvc{
+					Logger:  logger,
+					Metrics: metrics,
+				},
+				conf.Notifier,
+			)
+			if err != nil {
+				return fmt.Errorf("failed to create the notifier: %w", err)
+			}
+			defer notifier.Wait()
+
 			provider, err := provider.NewProvider(
 				&provider.ProviderSvc{
-					Client: client,
-					Logger: logger,
+					Client:   adapter,
+					Logger:   logger,
+					Metrics:  metrics,
+					Audit:    audit,
+					Notifier: notifier,
+					Health:   checker,
 				},
 				conf.Provider,
 			)
@@ -65,21 +129,116 @@ func main() {
 			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 			defer stop()
 
+			shutdownTracing, err := services.NewTracing(ctx, conf.Tracing)
+			if err != nil {
+				return fmt.Errorf("failed to set up tracing: %w", err)
+			}
+			defer func() {
+				ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancel()
+
+				if err := shutdownTracing(ctx); err != nil {
+					log.Warnf("Failed to flush the traces: %v", err)
+				}
+			}()
+
+			reloader := config.NewReloader(&config.ReloaderSvc{
+				Logger:    logger,
+				Validator: validator,
+				Client:    client,
+				Provider:  provider,
+			}, config.ReloaderConfig{
+				Args:          os.Args,
+				ConfigFile:    conf.ConfigFile,
+				WatchInterval: conf.ConfigWatchInterval,
+			}, config.Values(cmd.Flags, false))
+
+			go reloader.Run(ctx)
+			go client.WatchCredentials(ctx)
+			go client.WatchTLS(ctx)
+
+			var certificates *services.Certificates
+			if conf.Api.TLSCertFile != "" {
+				certificates, err = services.NewCertificates(&services.CertificatesSvc{
+					Logger: logger,
+				}, services.CertificatesConfig{
+					CertFile:      conf.Api.TLSCertFile,
+					KeyFile:       conf.Api.TLSKeyFile,
+					ClientCAFile:  conf.Api.TLSClientCAFile,
+					MinVersion:    conf.Api.TLSMinVersion,
+					WatchInterval: conf.Api.TLSWatchInterval,
+				})
+				if err != nil {
+					return fmt.Errorf("failed to load the TLS certificates: %w", err)
+				}
+
+				go certificates.Watch(ctx)
+			}
+
 			a := api.NewApi(&api.ApiSvc{
-				Logger:         logger,
-				Validator:      validator,
-				OpnsenseClient: client,
-				Provider:       provider,
+				Logger:       logger,
+				Validator:    validator,
+				Metrics:      metrics,
+				Provider:     provider,
+				Certificates: certificates,
 			}, conf.Api)
 
 			p := probes.NewApi(&probes.ApiSvc{
-				Logger:     logger,
-				Validator:  validator,
-				WebhookApi: a,
+				Logger:    logger,
+				Validator: validator,
+				Metrics:   metrics,
+				Health:    checker,
 			}, conf.Probes)
 
+			var adm *admin.Api
+			if conf.GetAdminListenAddress() != "" {
+				adm = admin.NewApi(&admin.ApiSvc{
+					Logger:       logger,
+					Validator:    validator,
+					Provider:     provider,
+					Certificates: certificates,
+				}, conf.Admin)
+			}
+
+			checker.Register(func(_ context.Context, report health.ReportFunc) {
+				if a.Listener() == nil {
+					report(health.ComponentListener, errors.New("webhook server is not listening"))
+
+					return
+				}
+
+				report(health.ComponentListener, nil)
+			}, true, health.ComponentListener)
+			checker.Register(opnsense.HealthCheck(client), true, health.ComponentOpnsense, health.ComponentCredentials, health.ComponentUnbound)
+			checker.Register(nil, true, health.ComponentStartup)
+			checker.Register(nil, false, health.ComponentReconfigure)
+			checker.Register(provider.FreezeHealthCheck, false, health.ComponentChangeFreeze)
+
+			webhookErrCh := a.Start(conf.GetListenAddress())
+			probesErrCh := p.Start(conf.GetHealthListenAddress())
+
+			if adm != nil {
+				adminErrCh := adm.Start(conf.GetAdminListenAddress())
+
+				go func() {
+					if err := <-adminErrCh; err != nil && errors.Is(err, http.ErrServerClosed) {
+						log.Warnf("Shutting down the admin server.")
+					} else if err != nil {
+						log.Panicf("Failed to start the admin server: %w", err)
+					}
+				}()
+			}
+
+			go checker.Run(ctx)
+			go provider.RunDriftCheck(ctx)
+
+			startupErrCh := make(chan error, 1)
 			go func() {
-				if err := <-a.Start(fmt.Sprintf(":%d", conf.Port)); err != nil && errors.Is(err, http.ErrServerClosed) {
+				startupErrCh <- provider.Startup(ctx)
+			}()
+
+			go func() {
+				if err := <-webhookErrCh; err != nil && errors.Is(err, http.ErrServerClosed) {
 					log.Warnf("Shutting down the server.")
 				} else if err != nil {
 					log.Panicf("Failed to start the server: %w", err)
@@ -87,14 +246,26 @@ func main() {
 			}()
 
 			go func() {
-				if err := <-p.Start(fmt.Sprintf(":%d", conf.HealthPort)); err != nil && errors.Is(err, http.ErrServerClosed) {
+				if err := <-probesErrCh; err != nil && errors.Is(err, http.ErrServerClosed) {
 					log.Warnf("Shutting down the probe server.")
 				} else if err != nil {
 					log.Panicf("Failed to start the probe server: %w", err)
 				}
 			}()
 
-			<-ctx.Done()
+			var startupErr error
+			select {
+			case <-ctx.Done():
+			case err := <-startupErrCh:
+				if err != nil && !errors.Is(err, context.Canceled) {
+					log.Errorf("Shutting down: %v", err)
+
+					startupErr = err
+				} else {
+					<-ctx.Done()
+				}
+			}
+
 			if err := a.Shutdown(); err != nil {
 				log.Warnln(err)
 
@@ -105,8 +276,15 @@ func main() {
 
 				return err
 			}
+			if adm != nil {
+				if err := adm.Shutdown(); err != nil {
+					log.Warnln(err)
+
+					return err
+				}
+			}
 
-			return nil
+			return startupErr
 		},
 	}
 